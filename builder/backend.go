@@ -0,0 +1,312 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	v2execute "github.com/alexellis/go-execute/v2"
+	"github.com/forge4flow/forge-cli/schema"
+	"github.com/forge4flow/forge-cli/stack"
+)
+
+const (
+	// DockerBuilder builds images with the docker CLI, and publishes them
+	// with buildx
+	DockerBuilder = "docker"
+
+	// BuildxBuilder builds and publishes images with docker buildx
+	BuildxBuilder = "buildx"
+
+	// BuildahBuilder builds images with buildah, without a Docker daemon
+	BuildahBuilder = "buildah"
+
+	// PodmanBuilder builds images with podman, without a Docker daemon
+	PodmanBuilder = "podman"
+
+	// KanikoBuilder builds images with the kaniko executor, from within a
+	// container
+	KanikoBuilder = "kaniko"
+
+	// NativeBuilder builds images without a Docker daemon by appending the
+	// build context to the template's base image
+	NativeBuilder = "native"
+
+	// RemoteBuilder sends the build context to a builder API which builds and
+	// pushes the image
+	RemoteBuilder = "remote"
+)
+
+// BackendNames lists the builder backends which NewBackend accepts
+var BackendNames = []string{DockerBuilder, BuildxBuilder, BuildahBuilder, PodmanBuilder, KanikoBuilder, NativeBuilder, RemoteBuilder}
+
+// Backend builds the image of a function from its build context
+type Backend interface {
+	// Build builds the image so that it can be pushed with Push
+	Build(ctx context.Context, build BuildContext) error
+
+	// Push pushes an image built with Build to its registry
+	Push(ctx context.Context, functionName, image string, quiet bool) error
+
+	// Publish builds the image for each of build.Platforms and pushes it
+	Publish(ctx context.Context, build BuildContext) error
+}
+
+// BackendOptions configure the backends which need more than their name
+type BackendOptions struct {
+	// RemoteBuilder is the URL of the builder API for the remote backend
+	RemoteBuilder string
+
+	// PayloadSecretPath is the file with the secret used to sign requests to
+	// the remote builder
+	PayloadSecretPath string
+
+	// Token is sent as a bearer token to the remote builder
+	Token string
+
+	// Timeout limits how long a remote build may take, including waiting
+	// for its job to finish
+	Timeout time.Duration
+
+	// Retries is how many times a request to the remote builder is retried
+	Retries int
+
+	// TLSInsecure skips verification of the remote builder's certificate
+	TLSInsecure bool
+}
+
+// NewBackend gives the builder backend with the given name
+func NewBackend(name string, options BackendOptions) (Backend, error) {
+	switch name {
+	case DockerBuilder:
+		return dockerBackend{}, nil
+	case BuildxBuilder:
+		return buildxBackend{}, nil
+	case BuildahBuilder, PodmanBuilder:
+		return buildahBackend{command: name}, nil
+	case KanikoBuilder:
+		return kanikoBackend{executor: kanikoExecutor}, nil
+	case NativeBuilder:
+		return nativeBackend{}, nil
+	case RemoteBuilder:
+		if len(options.RemoteBuilder) == 0 {
+			return nil, fmt.Errorf("the remote builder needs the URL of the builder, give --remote-builder")
+		}
+		return newRemoteBackend(options), nil
+	}
+
+	return nil, fmt.Errorf("unknown builder %q, use one of: %s", name, strings.Join(BackendNames, ", "))
+}
+
+// BuildOptions are how the image of a function is built, from the stack file
+// and flags
+type BuildOptions struct {
+	Image          string
+	Handler        string
+	FunctionName   string
+	Language       string
+	NoCache        bool
+	Squash         bool
+	Shrinkwrap     bool
+	BuildArgMap    map[string]string
+	BuildOptions   []string
+	TagFormat      schema.BuildFormat
+	BuildLabelMap  map[string]string
+	Quiet          bool
+	CopyExtraPaths []string
+
+	// Platforms and ExtraTags are used by PublishImage
+	Platforms string
+	ExtraTags []string
+
+	// Cache skips the build when the build context is unchanged since the
+	// function was last built, unless Force or NoCache are set
+	Cache *BuildCache
+	Force bool
+}
+
+// BuildContext is the build context of a function, created from its template
+// and handler, which a Backend builds
+type BuildContext struct {
+	FunctionName string
+
+	// Image is the name of the image with its tag
+	Image string
+
+	// Path is the folder with the Dockerfile, under ./build/
+	Path string
+
+	BuildArgMap      map[string]string
+	BuildOptPackages []string
+	BuildLabelMap    map[string]string
+	NoCache          bool
+	Squash           bool
+	Quiet            bool
+
+	// MountSSH is set by templates which mount an SSH agent during the build
+	MountSSH bool
+
+	// Platforms is a comma-separated list of platforms to publish
+	Platforms string
+
+	// ExtraTags are pushed along with the image's own tag when publishing
+	ExtraTags []string
+}
+
+// BuildImage builds the image of a function with the backend
+func BuildImage(ctx context.Context, backend Backend, options BuildOptions) error {
+	build, err := prepareBuild(options)
+	if err != nil || build == nil {
+		return err
+	}
+
+	if options.Cache == nil {
+		return backend.Build(ctx, *build)
+	}
+
+	hash, err := build.hash()
+	if err != nil {
+		return fmt.Errorf("unable to hash the build context of %s: %w", options.FunctionName, err)
+	}
+
+	if !options.Force && !options.NoCache && options.Cache.Unchanged(options.FunctionName, hash) && imageExists(ctx, backend, *build) {
+		fmt.Printf("%s is unchanged since it was last built, skipping the build of %s, use --force to build it\n", options.FunctionName, build.Image)
+		return nil
+	}
+
+	if err := backend.Build(ctx, *build); err != nil {
+		return err
+	}
+	options.Cache.Built(options.FunctionName, build.Image, hash)
+	return nil
+}
+
+// imageStore is implemented by the backends which keep the image they built
+// on this machine, a build is only skipped while its image is still there
+type imageStore interface {
+	imageExists(ctx context.Context, build BuildContext) bool
+}
+
+// imageExists is true when the image of the build is still where the backend
+// built it, the backends which push the image themselves are trusted
+func imageExists(ctx context.Context, backend Backend, build BuildContext) bool {
+	store, ok := backend.(imageStore)
+	if !ok {
+		return true
+	}
+	return store.imageExists(ctx, build)
+}
+
+// PublishImage builds and pushes the image of a function for each of its
+// platforms with the backend
+func PublishImage(ctx context.Context, backend Backend, options BuildOptions) error {
+	build, err := prepareBuild(options)
+	if err != nil || build == nil {
+		return err
+	}
+
+	return backend.Publish(ctx, *build)
+}
+
+// prepareBuild creates the build context of a function from its template and
+// handler. The build is nil when the function is only shrink-wrapped.
+func prepareBuild(options BuildOptions) (*BuildContext, error) {
+	if !stack.IsValidTemplate(options.Language) {
+		return nil, fmt.Errorf("language template: %s not supported, build a custom Dockerfile", options.Language)
+	}
+
+	pathToTemplateYAML := fmt.Sprintf("./template/%s/template.yml", options.Language)
+	if _, err := os.Stat(pathToTemplateYAML); os.IsNotExist(err) {
+		return nil, err
+	}
+
+	langTemplate, err := stack.ParseYAMLForLanguageTemplate(pathToTemplateYAML)
+	if err != nil {
+		return nil, fmt.Errorf("error reading language template: %s", err.Error())
+	}
+
+	if err := ensureHandlerPath(options.Handler); err != nil {
+		return nil, fmt.Errorf("building %s, %s is an invalid path", options.FunctionName, options.Handler)
+	}
+
+	tempPath, err := createBuildContext(options.FunctionName, options.Handler, options.Language, isLanguageTemplate(options.Language), langTemplate.HandlerFolder, options.CopyExtraPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Shrinkwrap {
+		fmt.Printf("%s shrink-wrapped to %s\n", options.FunctionName, tempPath)
+		return nil, nil
+	}
+
+	branch, version, err := GetImageTagValues(options.TagFormat, options.Handler)
+	if err != nil {
+		return nil, err
+	}
+
+	buildOptPackages, err := getBuildOptionPackages(options.BuildOptions, options.Language, langTemplate.BuildOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	imageName := schema.BuildImageName(options.TagFormat, options.Image, version, branch)
+	fmt.Printf("Building: %s with %s template. Please wait..\n", imageName, options.Language)
+
+	return &BuildContext{
+		FunctionName:     options.FunctionName,
+		Image:            imageName,
+		Path:             tempPath,
+		BuildArgMap:      options.BuildArgMap,
+		BuildOptPackages: buildOptPackages,
+		BuildLabelMap:    options.BuildLabelMap,
+		NoCache:          options.NoCache,
+		Squash:           options.Squash,
+		Quiet:            options.Quiet,
+		MountSSH:         langTemplate.MountSSH,
+		Platforms:        options.Platforms,
+		ExtraTags:        options.ExtraTags,
+	}, nil
+}
+
+// dockerBuild gives the flags of docker build and compatible CLIs
+func (b BuildContext) dockerBuild() dockerBuild {
+	return dockerBuild{
+		Image:            b.Image,
+		NoCache:          b.NoCache,
+		Squash:           b.Squash,
+		HTTPProxy:        os.Getenv("http_proxy"),
+		HTTPSProxy:       os.Getenv("https_proxy"),
+		BuildArgMap:      b.BuildArgMap,
+		BuildOptPackages: b.BuildOptPackages,
+		BuildLabelMap:    b.BuildLabelMap,
+		Platforms:        b.Platforms,
+		ExtraTags:        b.ExtraTags,
+	}
+}
+
+// runCommand runs a build or push command, its output is streamed unless
+// quiet is set
+func runCommand(ctx context.Context, functionName, dir string, quiet bool, env []string, command string, args []string) error {
+	task := v2execute.ExecTask{
+		Cwd:         dir,
+		Command:     command,
+		Args:        args,
+		StreamStdio: !quiet,
+		Env:         env,
+	}
+
+	res, err := task.Execute(ctx)
+	if err != nil {
+		return err
+	}
+
+	if res.ExitCode != 0 {
+		return fmt.Errorf("[%s] received non-zero exit code from %s, error: %s", functionName, command, res.Stderr)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package builder
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_NewBackend(t *testing.T) {
+	for _, name := range BackendNames {
+		backend, err := NewBackend(name, BackendOptions{RemoteBuilder: "http://127.0.0.1:8081/build"})
+		if err != nil {
+			t.Errorf("builder %s: %s", name, err)
+		}
+		if backend == nil {
+			t.Errorf("builder %s: want a backend", name)
+		}
+	}
+
+	_, err := NewBackend("bazel", BackendOptions{})
+	if err == nil || !strings.Contains(err.Error(), `unknown builder "bazel"`) {
+		t.Errorf("want an unknown builder error, got: %v", err)
+	}
+}
+
+func Test_getDockerBuildxLoadCommand(t *testing.T) {
+	command, args := getDockerBuildxLoadCommand(dockerBuild{
+		Image:       "ttl.sh/echo:latest",
+		NoCache:     true,
+		BuildArgMap: map[string]string{"GO111MODULE": "on"},
+	})
+
+	want := "buildx build --progress=plain --load --no-cache --build-arg GO111MODULE=on --tag ttl.sh/echo:latest ."
+	if got := strings.Join(args, " "); command != "docker" || got != want {
+		t.Errorf("want: %q, got: %s %q", want, command, got)
+	}
+}
+
+func Test_getBuildahManifestCommand(t *testing.T) {
+	command, args := getBuildahManifestCommand("podman", dockerBuild{
+		Image:     "ttl.sh/echo:latest",
+		Platforms: "linux/amd64,linux/arm64",
+	})
+
+	want := "build --platform=linux/amd64,linux/arm64 --manifest ttl.sh/echo:latest ."
+	if got := strings.Join(args, " "); command != "podman" || got != want {
+		t.Errorf("want: %q, got: %s %q", want, command, got)
+	}
+}
+
+func Test_getKanikoArgs(t *testing.T) {
+	args, err := getKanikoArgs(BuildContext{
+		Path:          "./build/echo/",
+		NoCache:       true,
+		Squash:        true,
+		BuildLabelMap: map[string]string{"team": "payments"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contextPath, _ := filepath.Abs("./build/echo/")
+	want := "--context dir://" + contextPath + " --dockerfile " + filepath.Join(contextPath, "Dockerfile") + " --label team=payments"
+	if got := strings.Join(args, " "); got != want {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
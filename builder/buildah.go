@@ -0,0 +1,69 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package builder
+
+import (
+	"context"
+	"fmt"
+)
+
+// buildahBackend builds with buildah or podman, which take the same flags as
+// docker build but need no daemon
+type buildahBackend struct {
+	command string
+}
+
+func (b buildahBackend) Build(ctx context.Context, build BuildContext) error {
+	_, args := getDockerBuildCommand(build.dockerBuild())
+
+	if err := runCommand(ctx, build.FunctionName, build.Path, build.Quiet, nil, b.command, args); err != nil {
+		return err
+	}
+
+	fmt.Printf("Image: %s built.\n", build.Image)
+	return nil
+}
+
+func (b buildahBackend) imageExists(ctx context.Context, build BuildContext) bool {
+	return runCommand(ctx, build.FunctionName, "", true, nil, b.command, []string{"inspect", "--type", "image", build.Image}) == nil
+}
+
+func (b buildahBackend) Push(ctx context.Context, functionName, image string, quiet bool) error {
+	return pushWithCLI(ctx, b.command, functionName, image, quiet)
+}
+
+// Publish builds a manifest list with an image for each platform, then
+// pushes it with all of its images
+func (b buildahBackend) Publish(ctx context.Context, build BuildContext) error {
+	// a manifest list left by an earlier publish would be added to
+	_ = runCommand(ctx, build.FunctionName, build.Path, true, nil, b.command, []string{"manifest", "rm", build.Image})
+
+	command, args := getBuildahManifestCommand(b.command, build.dockerBuild())
+	fmt.Printf("Publishing with command: %v %v\n", command, args)
+
+	if err := runCommand(ctx, build.FunctionName, build.Path, build.Quiet, nil, command, args); err != nil {
+		return err
+	}
+
+	for _, tag := range append([]string{build.Image}, extraTagNames(build.Image, build.ExtraTags)...) {
+		args := []string{"manifest", "push", "--all", build.Image, "docker://" + tag}
+		if err := runCommand(ctx, build.FunctionName, build.Path, build.Quiet, nil, b.command, args); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Image: %s built.\n", build.Image)
+	return nil
+}
+
+func getBuildahManifestCommand(command string, build dockerBuild) (string, []string) {
+	flagSlice := buildFlagSlice(build.NoCache, build.Squash, build.HTTPProxy, build.HTTPSProxy, build.BuildArgMap,
+		build.BuildOptPackages, build.BuildLabelMap)
+
+	args := []string{"build", "--platform=" + build.Platforms, "--manifest", build.Image}
+	args = append(args, flagSlice...)
+	args = append(args, ".")
+
+	return command, args
+}
@@ -0,0 +1,233 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// BuildCachePath is the manifest of the functions built in ./build/, used to
+// skip the build, push and deploy of functions which have not changed
+const BuildCachePath = "./build/.forge-cache.json"
+
+// BuildCache records the hash of each function's build context when it was
+// last built, and whether its image was pushed and deployed since
+type BuildCache struct {
+	// Backend is the builder the functions were built with, the cache is
+	// emptied when another builder is used
+	Backend   string                `json:"backend"`
+	Functions map[string]CacheEntry `json:"functions"`
+
+	mu sync.Mutex
+
+	// changed is set when a build or push was recorded since the cache was read
+	changed bool
+}
+
+// CacheEntry is the last build of a function
+type CacheEntry struct {
+	Hash   string `json:"hash"`
+	Image  string `json:"image"`
+	Pushed bool   `json:"pushed,omitempty"`
+
+	// Deployed maps where the image was deployed to the hash of the spec it
+	// was deployed with
+	Deployed map[string]string `json:"deployed,omitempty"`
+}
+
+// LoadBuildCache reads the cache from path, a missing or unreadable cache, or
+// one written by another backend, gives an empty cache
+func LoadBuildCache(path, backend string) *BuildCache {
+	cache := ReadBuildCache(path)
+	if cache.Backend != backend {
+		return &BuildCache{Backend: backend, Functions: map[string]CacheEntry{}}
+	}
+	return cache
+}
+
+// ReadBuildCache reads the cache from path whichever backend wrote it, for
+// deploy which records deployments but builds nothing
+func ReadBuildCache(path string) *BuildCache {
+	cache := &BuildCache{Functions: map[string]CacheEntry{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+
+	saved := BuildCache{}
+	if err := json.Unmarshal(data, &saved); err != nil || saved.Functions == nil {
+		return cache
+	}
+
+	cache.Backend = saved.Backend
+	cache.Functions = saved.Functions
+	return cache
+}
+
+// Save writes the cache to path when a build or push was recorded
+func (c *BuildCache) Save(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.changed {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Unchanged is true when the function was last built with the same hash
+func (c *BuildCache) Unchanged(functionName, hash string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.Functions[functionName]
+	return ok && entry.Hash == hash
+}
+
+// Built records the build of a function, its image is yet to be pushed
+func (c *BuildCache) Built(functionName, image, hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Functions[functionName] = CacheEntry{Hash: hash, Image: image}
+	c.changed = true
+}
+
+// Pushed is true when image is the function's last build and has been pushed
+func (c *BuildCache) Pushed(functionName, image string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.Functions[functionName]
+	return ok && entry.Pushed && entry.Image == image
+}
+
+// MarkPushed records that the function's last build was pushed as image
+func (c *BuildCache) MarkPushed(functionName, image string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.Functions[functionName]; ok && entry.Image == image {
+		entry.Pushed = true
+		c.Functions[functionName] = entry
+		c.changed = true
+	}
+}
+
+// Deployed is true when image was last deployed to target with the spec hash
+func (c *BuildCache) Deployed(functionName, image, target, specHash string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.Functions[functionName]
+	return ok && entry.Image == image && entry.Deployed[target] == specHash
+}
+
+// MarkDeployed records that image was deployed to target with the spec hash,
+// an image which was not built here is recorded without a build hash
+func (c *BuildCache) MarkDeployed(functionName, image, target, specHash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.Functions[functionName]
+	if !ok || entry.Image != image {
+		entry = CacheEntry{Image: image}
+	}
+	if entry.Deployed == nil {
+		entry.Deployed = map[string]string{}
+	}
+	entry.Deployed[target] = specHash
+
+	c.Functions[functionName] = entry
+	c.changed = true
+}
+
+// hash is a content hash of the build context along with the image name and
+// everything passed to the backend, so that a change to the handler, the
+// template, the extra paths, the build args or the build options changes it
+func (b BuildContext) hash() (string, error) {
+	h := sha256.New()
+
+	inputs, _ := json.Marshal(struct {
+		Image            string
+		BuildArgMap      map[string]string
+		BuildOptPackages []string
+		BuildLabelMap    map[string]string
+		Squash           bool
+		MountSSH         bool
+		Platforms        string
+		ExtraTags        []string
+	}{b.Image, b.BuildArgMap, b.BuildOptPackages, b.BuildLabelMap, b.Squash, b.MountSSH, b.Platforms, b.ExtraTags})
+	h.Write(inputs)
+
+	var files []string
+	if err := filepath.Walk(b.Path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() || info.Mode()&os.ModeSymlink != 0 {
+			files = append(files, path)
+		}
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		rel, err := filepath.Rel(b.Path, file)
+		if err != nil {
+			return "", err
+		}
+		info, err := os.Lstat(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%o\x00", filepath.ToSlash(rel), info.Mode())
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(file)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(h, "%s\x00", target)
+			continue
+		}
+
+		if err := hashFile(h, file); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,128 @@
+package builder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_BuildContext_hash(t *testing.T) {
+	contextPath := t.TempDir()
+	writeFiles(t, contextPath, map[string]string{
+		"Dockerfile":          "FROM scratch\n",
+		"function/handler.go": "package function",
+	})
+
+	build := BuildContext{
+		Image:       "ttl.sh/echo:latest",
+		Path:        contextPath,
+		BuildArgMap: map[string]string{"GO111MODULE": "on"},
+	}
+
+	first, err := build.hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := build.hash(); again != first {
+		t.Fatalf("want the same hash for the same build context, got %s and %s", first, again)
+	}
+
+	withArg := build
+	withArg.BuildArgMap = map[string]string{"GO111MODULE": "off"}
+	if hash, _ := withArg.hash(); hash == first {
+		t.Errorf("want a changed build arg to change the hash")
+	}
+
+	withImage := build
+	withImage.Image = "ttl.sh/echo:0.1.0"
+	if hash, _ := withImage.hash(); hash == first {
+		t.Errorf("want a changed image to change the hash")
+	}
+
+	writeFiles(t, contextPath, map[string]string{"function/handler.go": "package function\n"})
+	if hash, _ := build.hash(); hash == first {
+		t.Errorf("want a changed handler to change the hash")
+	}
+
+	writeFiles(t, contextPath, map[string]string{"function/handler.go": "package function", "function/go.mod": ""})
+	if hash, _ := build.hash(); hash == first {
+		t.Errorf("want an added file to change the hash")
+	}
+}
+
+func Test_BuildCache_SaveAndLoad(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "build", ".forge-cache.json")
+
+	cache := LoadBuildCache(cachePath, DockerBuilder)
+	if cache.Unchanged("echo", "abc") {
+		t.Fatalf("want an empty cache when there is no manifest")
+	}
+
+	cache.Built("echo", "ttl.sh/echo:latest", "abc")
+	if cache.Pushed("echo", "ttl.sh/echo:latest") {
+		t.Fatalf("want a new build to need a push")
+	}
+	cache.MarkPushed("echo", "ttl.sh/echo:latest")
+
+	if err := cache.Save(cachePath); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := LoadBuildCache(cachePath, DockerBuilder)
+	if !loaded.Unchanged("echo", "abc") || loaded.Unchanged("echo", "def") {
+		t.Errorf("want the hash of the last build, got: %+v", loaded.Functions)
+	}
+	if !loaded.Pushed("echo", "ttl.sh/echo:latest") || loaded.Pushed("echo", "ttl.sh/echo:0.1.0") {
+		t.Errorf("want the pushed image of the last build, got: %+v", loaded.Functions)
+	}
+
+	loaded.Built("echo", "ttl.sh/echo:latest", "def")
+	if loaded.Pushed("echo", "ttl.sh/echo:latest") {
+		t.Errorf("want a rebuilt image to need a push")
+	}
+
+	if other := LoadBuildCache(cachePath, NativeBuilder); other.Unchanged("echo", "abc") {
+		t.Errorf("want an empty cache for another builder")
+	}
+}
+
+func Test_imageExists(t *testing.T) {
+	useBuildContext(t, "echo")
+	build := BuildContext{FunctionName: "echo", Image: "ttl.sh/echo:latest"}
+
+	if imageExists(context.Background(), nativeBackend{}, build) {
+		t.Fatalf("want no native image before the layout is written")
+	}
+	if err := os.MkdirAll(NativeLayoutPath("echo"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if !imageExists(context.Background(), nativeBackend{}, build) {
+		t.Fatalf("want the native image to be found in its layout")
+	}
+
+	if imageExists(context.Background(), kanikoBackend{}, build) {
+		t.Fatalf("want no kaniko image before the tarball is written")
+	}
+
+	if !imageExists(context.Background(), remoteBackend{}, build) {
+		t.Fatalf("want the image of the remote builder to be trusted")
+	}
+}
+
+func Test_BuildCache_Deployed(t *testing.T) {
+	cache := ReadBuildCache(filepath.Join(t.TempDir(), ".forge-cache.json"))
+
+	cache.MarkDeployed("echo", "ttl.sh/echo:latest", "gw echo", "abc")
+	if !cache.Deployed("echo", "ttl.sh/echo:latest", "gw echo", "abc") {
+		t.Fatalf("want the deployment to be recorded, got: %+v", cache.Functions)
+	}
+	if cache.Deployed("echo", "ttl.sh/echo:latest", "gw echo", "def") || cache.Deployed("echo", "ttl.sh/echo:latest", "other echo", "abc") {
+		t.Fatalf("want another spec or gateway to need a deploy")
+	}
+
+	cache.Built("echo", "ttl.sh/echo:latest", "hash")
+	if cache.Deployed("echo", "ttl.sh/echo:latest", "gw echo", "abc") {
+		t.Fatalf("want a rebuilt image to need a deploy")
+	}
+}
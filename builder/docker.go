@@ -0,0 +1,86 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package builder
+
+import (
+	"context"
+	"fmt"
+)
+
+// dockerBackend builds with docker build and publishes with docker buildx
+type dockerBackend struct{}
+
+func (dockerBackend) Build(ctx context.Context, build BuildContext) error {
+	command, args := getDockerBuildCommand(build.dockerBuild())
+
+	var env []string
+	if build.MountSSH {
+		env = append(env, "DOCKER_BUILDKIT=1")
+	}
+
+	if err := runCommand(ctx, build.FunctionName, build.Path, build.Quiet, env, command, args); err != nil {
+		return err
+	}
+
+	fmt.Printf("Image: %s built.\n", build.Image)
+	return nil
+}
+
+func (dockerBackend) imageExists(ctx context.Context, build BuildContext) bool {
+	return runCommand(ctx, build.FunctionName, "", true, nil, "docker", []string{"image", "inspect", build.Image}) == nil
+}
+
+func (dockerBackend) Push(ctx context.Context, functionName, image string, quiet bool) error {
+	return pushWithCLI(ctx, "docker", functionName, image, quiet)
+}
+
+func (dockerBackend) Publish(ctx context.Context, build BuildContext) error {
+	command, args := getDockerBuildxCommand(build.dockerBuild())
+	fmt.Printf("Publishing with command: %v %v\n", command, args)
+
+	if err := runCommand(ctx, build.FunctionName, build.Path, build.Quiet, nil, command, args); err != nil {
+		return err
+	}
+
+	fmt.Printf("Image: %s built.\n", build.Image)
+	return nil
+}
+
+// buildxBackend builds with docker buildx, loading the image into the local
+// library so that it can be pushed with docker push
+type buildxBackend struct {
+	dockerBackend
+}
+
+func (buildxBackend) Build(ctx context.Context, build BuildContext) error {
+	command, args := getDockerBuildxLoadCommand(build.dockerBuild())
+
+	if err := runCommand(ctx, build.FunctionName, build.Path, build.Quiet, nil, command, args); err != nil {
+		return err
+	}
+
+	fmt.Printf("Image: %s built.\n", build.Image)
+	return nil
+}
+
+func getDockerBuildxLoadCommand(build dockerBuild) (string, []string) {
+	flagSlice := buildFlagSlice(build.NoCache, build.Squash, build.HTTPProxy, build.HTTPSProxy, build.BuildArgMap,
+		build.BuildOptPackages, build.BuildLabelMap)
+
+	args := []string{"buildx", "build", "--progress=plain", "--load"}
+	args = append(args, flagSlice...)
+	args = append(args, "--tag", build.Image, ".")
+
+	return "docker", args
+}
+
+// pushWithCLI pushes an image with docker push or a compatible CLI
+func pushWithCLI(ctx context.Context, command, functionName, image string, quiet bool) error {
+	args := []string{"push", image}
+	if quiet {
+		args = append(args, "--quiet")
+	}
+
+	return runCommand(ctx, functionName, "", false, nil, command, args)
+}
@@ -0,0 +1,603 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package builder
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// dockerfileInstruction is an instruction of a Dockerfile with its line
+// continuations joined
+type dockerfileInstruction struct {
+	line    int
+	command string
+	rest    string
+}
+
+// readDockerfile splits a Dockerfile into its instructions
+func readDockerfile(r io.Reader) ([]dockerfileInstruction, error) {
+	var instructions []dockerfileInstruction
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	start := 0
+	var current strings.Builder
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		// comments are removed, even in the middle of a continuation
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if current.Len() == 0 {
+			if len(line) == 0 {
+				continue
+			}
+			start = lineNumber
+		}
+
+		if strings.HasSuffix(line, "\\") {
+			current.WriteString(strings.TrimSuffix(line, "\\"))
+			current.WriteString(" ")
+			continue
+		}
+
+		current.WriteString(line)
+		instructions = append(instructions, newDockerfileInstruction(start, current.String()))
+		current.Reset()
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if current.Len() > 0 {
+		instructions = append(instructions, newDockerfileInstruction(start, current.String()))
+	}
+
+	return instructions, nil
+}
+
+func newDockerfileInstruction(line int, text string) dockerfileInstruction {
+	fields := strings.SplitN(strings.TrimSpace(text), " ", 2)
+	instruction := dockerfileInstruction{line: line, command: strings.ToUpper(fields[0])}
+	if len(fields) > 1 {
+		instruction.rest = strings.TrimSpace(fields[1])
+	}
+	return instruction
+}
+
+// nativeCopy is a COPY of files from the build context into the image
+type nativeCopy struct {
+	line    int
+	sources []string
+	dest    string
+
+	// uid and gid are -1 unless given with --chown
+	uid, gid int
+
+	// mode is 0 unless given with --chmod
+	mode os.FileMode
+}
+
+// nativeRecipe is what a layer-appendable Dockerfile does to its base image
+type nativeRecipe struct {
+	baseImage string
+	copies    []nativeCopy
+	config    v1.Config
+}
+
+// layerAppendableError is returned for a Dockerfile which can't be built by
+// appending a layer to its base image
+type layerAppendableError struct {
+	line   int
+	reason string
+}
+
+func (e *layerAppendableError) Error() string {
+	return fmt.Sprintf("the Dockerfile is not layer-appendable, %s on line %d, use --builder=docker", e.reason, e.line)
+}
+
+// parseAppendableDockerfile reads a single-stage Dockerfile which only copies
+// files from the build context and changes the image's configuration. It
+// gives the base image to pull and what to change when building for platform.
+func parseAppendableDockerfile(instructions []dockerfileInstruction, buildArgs map[string]string, platform v1.Platform) (string, []dockerfileInstruction, error) {
+	args := map[string]string{}
+	from := -1
+
+	for i, instruction := range instructions {
+		switch instruction.command {
+		case "ARG":
+			if from >= 0 {
+				continue
+			}
+			name, value := declareArg(instruction.rest, buildArgs, platform)
+			args[name] = value
+		case "FROM":
+			if from >= 0 {
+				return "", nil, &layerAppendableError{line: instruction.line, reason: "a multi-stage build"}
+			}
+			from = i
+		case "RUN", "ADD", "ONBUILD":
+			return "", nil, &layerAppendableError{line: instruction.line, reason: instruction.command}
+		}
+	}
+
+	if from < 0 {
+		return "", nil, fmt.Errorf("the Dockerfile has no FROM instruction")
+	}
+
+	words := splitDockerfileWords(instructions[from].rest)
+	var baseImage string
+	for _, word := range words {
+		if !strings.HasPrefix(word, "--") {
+			baseImage = expandDockerfileWord(word, args)
+			break
+		}
+	}
+	if len(baseImage) == 0 {
+		return "", nil, fmt.Errorf("the FROM instruction on line %d has no image", instructions[from].line)
+	}
+
+	return baseImage, instructions[from+1:], nil
+}
+
+// newNativeRecipe applies the instructions of a stage to the configuration
+// of its base image
+func newNativeRecipe(baseImage string, stage []dockerfileInstruction, base v1.Config, buildArgs map[string]string, platform v1.Platform) (*nativeRecipe, error) {
+	recipe := &nativeRecipe{baseImage: baseImage, config: *base.DeepCopy()}
+	config := &recipe.config
+
+	args := map[string]string{}
+	cmdSet := false
+	shell := []string{"/bin/sh", "-c"}
+	if len(config.Shell) > 0 {
+		shell = config.Shell
+	}
+
+	for _, instruction := range stage {
+		// variables from ENV take precedence over ARG
+		vars := make(map[string]string, len(args)+len(config.Env))
+		for k, v := range args {
+			vars[k] = v
+		}
+		for _, kv := range config.Env {
+			if i := strings.Index(kv, "="); i > 0 {
+				vars[kv[:i]] = kv[i+1:]
+			}
+		}
+
+		switch instruction.command {
+		case "ARG":
+			name, value := declareArg(instruction.rest, buildArgs, platform)
+			args[name] = value
+
+		case "ENV":
+			pairs, err := keyValuePairs(instruction, vars)
+			if err != nil {
+				return nil, err
+			}
+			for _, pair := range pairs {
+				config.Env = setEnv(config.Env, pair[0], pair[1])
+			}
+
+		case "LABEL":
+			pairs, err := keyValuePairs(instruction, vars)
+			if err != nil {
+				return nil, err
+			}
+			if config.Labels == nil {
+				config.Labels = map[string]string{}
+			}
+			for _, pair := range pairs {
+				config.Labels[pair[0]] = pair[1]
+			}
+
+		case "WORKDIR":
+			workdir := expandDockerfileWord(instruction.rest, vars)
+			if !path.IsAbs(workdir) {
+				workdir = path.Join("/", config.WorkingDir, workdir)
+			}
+			config.WorkingDir = path.Clean(workdir)
+
+		case "USER":
+			config.User = expandDockerfileWord(instruction.rest, vars)
+
+		case "EXPOSE":
+			if config.ExposedPorts == nil {
+				config.ExposedPorts = map[string]struct{}{}
+			}
+			for _, port := range splitDockerfileWords(instruction.rest) {
+				port = expandDockerfileWord(port, vars)
+				if !strings.Contains(port, "/") {
+					port += "/tcp"
+				}
+				config.ExposedPorts[port] = struct{}{}
+			}
+
+		case "VOLUME":
+			if config.Volumes == nil {
+				config.Volumes = map[string]struct{}{}
+			}
+			volumes, ok := jsonForm(instruction.rest)
+			if !ok {
+				volumes = splitDockerfileWords(instruction.rest)
+			}
+			for _, volume := range volumes {
+				config.Volumes[expandDockerfileWord(volume, vars)] = struct{}{}
+			}
+
+		case "STOPSIGNAL":
+			config.StopSignal = expandDockerfileWord(instruction.rest, vars)
+
+		case "MAINTAINER":
+			// the author is not part of the runtime configuration
+
+		case "SHELL":
+			value, ok := jsonForm(instruction.rest)
+			if !ok {
+				return nil, fmt.Errorf("SHELL on line %d must use the JSON form", instruction.line)
+			}
+			shell = value
+			config.Shell = value
+
+		case "CMD":
+			config.Cmd = commandForm(instruction.rest, shell)
+			cmdSet = true
+
+		case "ENTRYPOINT":
+			config.Entrypoint = commandForm(instruction.rest, shell)
+			// an ENTRYPOINT resets the CMD of the base image
+			if !cmdSet {
+				config.Cmd = nil
+			}
+
+		case "HEALTHCHECK":
+			healthcheck, err := parseHealthcheck(instruction)
+			if err != nil {
+				return nil, err
+			}
+			config.Healthcheck = healthcheck
+
+		case "COPY":
+			c, err := parseCopy(instruction, vars, config.WorkingDir)
+			if err != nil {
+				return nil, err
+			}
+			recipe.copies = append(recipe.copies, c)
+
+		default:
+			return nil, &layerAppendableError{line: instruction.line, reason: instruction.command}
+		}
+	}
+
+	return recipe, nil
+}
+
+// declareArg gives the value of an ARG, a build-arg takes precedence over
+// its default, and the TARGET and BUILD platform args are predefined
+func declareArg(rest string, buildArgs map[string]string, platform v1.Platform) (string, string) {
+	name, value := rest, ""
+	if i := strings.Index(rest, "="); i > 0 {
+		name, value = rest[:i], unquote(rest[i+1:])
+	}
+
+	if v, ok := buildArgs[name]; ok {
+		return name, v
+	}
+	if v, ok := platformArgs(platform)[name]; ok && len(value) == 0 {
+		return name, v
+	}
+	return name, value
+}
+
+func platformArgs(platform v1.Platform) map[string]string {
+	targetPlatform := platform.OS + "/" + platform.Architecture
+	if len(platform.Variant) > 0 {
+		targetPlatform += "/" + platform.Variant
+	}
+
+	return map[string]string{
+		"TARGETPLATFORM": targetPlatform,
+		"TARGETOS":       platform.OS,
+		"TARGETARCH":     platform.Architecture,
+		"TARGETVARIANT":  platform.Variant,
+		"BUILDPLATFORM":  "linux/" + runtime.GOARCH,
+		"BUILDOS":        "linux",
+		"BUILDARCH":      runtime.GOARCH,
+	}
+}
+
+func parseCopy(instruction dockerfileInstruction, vars map[string]string, workdir string) (nativeCopy, error) {
+	c := nativeCopy{line: instruction.line, uid: -1, gid: -1}
+
+	rest := instruction.rest
+	for strings.HasPrefix(rest, "--") {
+		fields := strings.SplitN(rest, " ", 2)
+		flag := fields[0]
+		rest = ""
+		if len(fields) > 1 {
+			rest = strings.TrimSpace(fields[1])
+		}
+
+		name, value := flag, ""
+		if i := strings.Index(flag, "="); i > 0 {
+			name, value = flag[:i], expandDockerfileWord(flag[i+1:], vars)
+		}
+
+		switch name {
+		case "--chown":
+			uid, gid, err := parseChown(value)
+			if err != nil {
+				return c, fmt.Errorf("COPY on line %d: %w", instruction.line, err)
+			}
+			c.uid, c.gid = uid, gid
+		case "--chmod":
+			mode, err := strconv.ParseUint(value, 8, 32)
+			if err != nil {
+				return c, fmt.Errorf("COPY on line %d: invalid --chmod=%s", instruction.line, value)
+			}
+			c.mode = os.FileMode(mode)
+		case "--link":
+		case "--from":
+			return c, &layerAppendableError{line: instruction.line, reason: "COPY --from"}
+		default:
+			return c, &layerAppendableError{line: instruction.line, reason: "COPY " + name}
+		}
+	}
+
+	words, ok := jsonForm(rest)
+	if !ok {
+		words = splitDockerfileWords(rest)
+	}
+	if len(words) < 2 {
+		return c, fmt.Errorf("COPY on line %d needs a source and a destination", instruction.line)
+	}
+
+	for _, source := range words[:len(words)-1] {
+		c.sources = append(c.sources, expandDockerfileWord(source, vars))
+	}
+
+	dest := expandDockerfileWord(words[len(words)-1], vars)
+	if !path.IsAbs(dest) {
+		trailingSlash := strings.HasSuffix(dest, "/") || dest == "."
+		dest = path.Join("/", workdir, dest)
+		if trailingSlash {
+			dest += "/"
+		}
+	}
+	c.dest = dest
+
+	return c, nil
+}
+
+// parseChown only accepts numeric ids, as the names of users and groups are
+// only known from the base image's /etc/passwd and /etc/group
+func parseChown(value string) (int, int, error) {
+	parts := strings.SplitN(value, ":", 2)
+
+	uid, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("--chown=%s needs a numeric user and group with --builder=native", value)
+	}
+
+	gid := uid
+	if len(parts) > 1 {
+		if gid, err = strconv.Atoi(parts[1]); err != nil {
+			return 0, 0, fmt.Errorf("--chown=%s needs a numeric user and group with --builder=native", value)
+		}
+	}
+	return uid, gid, nil
+}
+
+func parseHealthcheck(instruction dockerfileInstruction) (*v1.HealthConfig, error) {
+	healthcheck := &v1.HealthConfig{}
+
+	rest := instruction.rest
+	if strings.EqualFold(rest, "NONE") {
+		healthcheck.Test = []string{"NONE"}
+		return healthcheck, nil
+	}
+
+	for strings.HasPrefix(rest, "--") {
+		fields := strings.SplitN(rest, " ", 2)
+		rest = ""
+		if len(fields) > 1 {
+			rest = strings.TrimSpace(fields[1])
+		}
+
+		flag := strings.SplitN(fields[0], "=", 2)
+		if len(flag) != 2 {
+			return nil, fmt.Errorf("HEALTHCHECK on line %d: %s needs a value", instruction.line, flag[0])
+		}
+
+		var err error
+		switch flag[0] {
+		case "--interval":
+			healthcheck.Interval, err = time.ParseDuration(flag[1])
+		case "--timeout":
+			healthcheck.Timeout, err = time.ParseDuration(flag[1])
+		case "--start-period":
+			healthcheck.StartPeriod, err = time.ParseDuration(flag[1])
+		case "--retries":
+			healthcheck.Retries, err = strconv.Atoi(flag[1])
+		default:
+			err = fmt.Errorf("unknown flag")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("HEALTHCHECK on line %d: invalid %s: %w", instruction.line, fields[0], err)
+		}
+	}
+
+	fields := strings.SplitN(rest, " ", 2)
+	if !strings.EqualFold(fields[0], "CMD") || len(fields) < 2 {
+		return nil, fmt.Errorf("HEALTHCHECK on line %d needs NONE or CMD", instruction.line)
+	}
+
+	if value, ok := jsonForm(fields[1]); ok {
+		healthcheck.Test = append([]string{"CMD"}, value...)
+	} else {
+		healthcheck.Test = []string{"CMD-SHELL", strings.TrimSpace(fields[1])}
+	}
+	return healthcheck, nil
+}
+
+// commandForm gives the exec form of a CMD or ENTRYPOINT
+func commandForm(rest string, shell []string) []string {
+	if value, ok := jsonForm(rest); ok {
+		return value
+	}
+
+	command := make([]string, 0, len(shell)+1)
+	command = append(command, shell...)
+	return append(command, rest)
+}
+
+func jsonForm(rest string) ([]string, bool) {
+	if !strings.HasPrefix(rest, "[") {
+		return nil, false
+	}
+
+	var value []string
+	if err := json.Unmarshal([]byte(rest), &value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// keyValuePairs reads the key=value pairs of ENV and LABEL, and the legacy
+// "key value" form
+func keyValuePairs(instruction dockerfileInstruction, vars map[string]string) ([][2]string, error) {
+	words := splitDockerfileWords(instruction.rest)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("%s on line %d needs a value", instruction.command, instruction.line)
+	}
+
+	if !strings.Contains(words[0], "=") {
+		fields := strings.SplitN(instruction.rest, " ", 2)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s on line %d needs a value", instruction.command, instruction.line)
+		}
+		return [][2]string{{fields[0], expandDockerfileWord(unquote(strings.TrimSpace(fields[1])), vars)}}, nil
+	}
+
+	var pairs [][2]string
+	for _, word := range words {
+		i := strings.Index(word, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("%s on line %d: %q is not a key=value pair", instruction.command, instruction.line, word)
+		}
+		pairs = append(pairs, [2]string{expandDockerfileWord(unquote(word[:i]), vars), expandDockerfileWord(unquote(word[i+1:]), vars)})
+	}
+	return pairs, nil
+}
+
+// splitDockerfileWords splits on whitespace outside of quotes, the quotes are
+// kept so that values can be unquoted after splitting a key=value pair
+func splitDockerfileWords(s string) []string {
+	var words []string
+	var word strings.Builder
+	var quote rune
+	escaped := false
+	inWord := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			word.WriteRune(r)
+			escaped = true
+		case quote != 0:
+			word.WriteRune(r)
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			word.WriteRune(r)
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+// unquote removes the quotes and escapes of a value
+func unquote(s string) string {
+	var b strings.Builder
+	var quote rune
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// expandDockerfileWord substitutes $VAR, ${VAR}, ${VAR:-default} and
+// ${VAR:+value}
+func expandDockerfileWord(s string, vars map[string]string) string {
+	return os.Expand(s, func(key string) string {
+		if i := strings.Index(key, ":-"); i > 0 {
+			if v := vars[key[:i]]; len(v) > 0 {
+				return v
+			}
+			return key[i+2:]
+		}
+		if i := strings.Index(key, ":+"); i > 0 {
+			if len(vars[key[:i]]) > 0 {
+				return key[i+2:]
+			}
+			return ""
+		}
+		return vars[key]
+	})
+}
+
+func setEnv(env []string, key, value string) []string {
+	for i, kv := range env {
+		if strings.HasPrefix(kv, key+"=") {
+			env[i] = key + "=" + value
+			return env
+		}
+	}
+	return append(env, key+"="+value)
+}
@@ -0,0 +1,122 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+// kanikoExecutor is where the executor is found in kaniko's image
+const kanikoExecutor = "/kaniko/executor"
+
+// kanikoBackend builds with the kaniko executor, which runs in a container
+// without a Docker daemon. Build writes a tarball which Push reads from.
+type kanikoBackend struct {
+	executor string
+}
+
+// KanikoTarPath is the image tarball which forge-cli build --builder=kaniko
+// writes the image of a function to
+func KanikoTarPath(functionName string) string {
+	return fmt.Sprintf("./build/%s.tar", functionName)
+}
+
+func (k kanikoBackend) Build(ctx context.Context, build BuildContext) error {
+	tarPath, err := filepath.Abs(KanikoTarPath(build.FunctionName))
+	if err != nil {
+		return err
+	}
+
+	args, err := getKanikoArgs(build)
+	if err != nil {
+		return err
+	}
+	args = append(args, "--no-push", "--tar-path", tarPath, "--destination", build.Image)
+
+	if err := runCommand(ctx, build.FunctionName, build.Path, build.Quiet, nil, k.executor, args); err != nil {
+		return err
+	}
+
+	fmt.Printf("Image: %s built to %s.\n", build.Image, KanikoTarPath(build.FunctionName))
+	return nil
+}
+
+func (kanikoBackend) imageExists(ctx context.Context, build BuildContext) bool {
+	_, err := os.Stat(KanikoTarPath(build.FunctionName))
+	return err == nil
+}
+
+func (kanikoBackend) Push(ctx context.Context, functionName, image string, quiet bool) error {
+	tarPath := KanikoTarPath(functionName)
+
+	tag, err := name.NewTag(image)
+	if err != nil {
+		return fmt.Errorf("invalid image name %s: %w", image, err)
+	}
+
+	img, err := tarball.ImageFromPath(tarPath, &tag)
+	if err != nil {
+		return fmt.Errorf("[%s] unable to read %s, build the function first: %w", functionName, tarPath, err)
+	}
+
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return err
+	}
+	platform := v1.Platform{OS: configFile.OS, Architecture: configFile.Architecture, Variant: configFile.Variant}
+
+	return pushImages(ctx, image, []v1.Image{img}, []v1.Platform{platform}, nil)
+}
+
+// Publish builds and pushes with the executor, which only builds for one
+// platform at a time
+func (k kanikoBackend) Publish(ctx context.Context, build BuildContext) error {
+	platforms := strings.Split(build.Platforms, ",")
+	if len(platforms) > 1 {
+		return fmt.Errorf("[%s] the kaniko builder can only publish one platform at a time, not %s", build.FunctionName, build.Platforms)
+	}
+
+	args, err := getKanikoArgs(build)
+	if err != nil {
+		return err
+	}
+	if len(build.Platforms) > 0 {
+		args = append(args, "--custom-platform", build.Platforms)
+	}
+	for _, tag := range append([]string{build.Image}, extraTagNames(build.Image, build.ExtraTags)...) {
+		args = append(args, "--destination", tag)
+	}
+
+	if err := runCommand(ctx, build.FunctionName, build.Path, build.Quiet, nil, k.executor, args); err != nil {
+		return err
+	}
+
+	fmt.Printf("Image: %s built and pushed.\n", build.Image)
+	return nil
+}
+
+// getKanikoArgs gives the executor's flags for the build context, kaniko
+// supports the build-args and labels of docker build, but not --no-cache or
+// --squash
+func getKanikoArgs(build BuildContext) ([]string, error) {
+	contextPath, err := filepath.Abs(build.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	docker := build.dockerBuild()
+	flagSlice := buildFlagSlice(false, false, docker.HTTPProxy, docker.HTTPSProxy, docker.BuildArgMap,
+		docker.BuildOptPackages, docker.BuildLabelMap)
+
+	args := []string{"--context", "dir://" + contextPath, "--dockerfile", filepath.Join(contextPath, "Dockerfile")}
+	return append(args, flagSlice...), nil
+}
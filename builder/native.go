@@ -0,0 +1,612 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package builder
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// nativeBuild appends the files copied by a layer-appendable Dockerfile to
+// its base image as a single layer
+type nativeBuild struct {
+	Image         string
+	ContextPath   string
+	BuildArgMap   map[string]string
+	BuildLabelMap map[string]string
+	Platforms     []v1.Platform
+}
+
+// NativePlatform is the platform which images are built for by forge-cli
+// build --builder=native
+func NativePlatform() v1.Platform {
+	return v1.Platform{OS: "linux", Architecture: runtime.GOARCH}
+}
+
+// ParsePlatforms reads a comma-separated list of platforms such as
+// linux/amd64,linux/arm/v7
+func ParsePlatforms(value string) ([]v1.Platform, error) {
+	var platforms []v1.Platform
+	for _, p := range strings.Split(value, ",") {
+		if len(strings.TrimSpace(p)) == 0 {
+			continue
+		}
+
+		platform, err := v1.ParsePlatform(p)
+		if err != nil {
+			return nil, err
+		}
+		if len(platform.OS) == 0 || len(platform.Architecture) == 0 {
+			return nil, fmt.Errorf("platform %q must be given as os/arch[/variant]", p)
+		}
+		platforms = append(platforms, *platform)
+	}
+
+	if len(platforms) == 0 {
+		return nil, fmt.Errorf("no platforms were given")
+	}
+	return platforms, nil
+}
+
+// sourceDateEpoch is the time set on the files and configuration of the
+// image, from SOURCE_DATE_EPOCH or the Unix epoch, so that a build context
+// gives the same image every time it is built
+func sourceDateEpoch() (time.Time, error) {
+	value, ok := os.LookupEnv("SOURCE_DATE_EPOCH")
+	if !ok || len(value) == 0 {
+		return time.Unix(0, 0).UTC(), nil
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", value, err)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// images builds the image for each platform
+func (b nativeBuild) images(ctx context.Context) ([]v1.Image, error) {
+	dockerfile, err := os.Open(filepath.Join(b.ContextPath, "Dockerfile"))
+	if err != nil {
+		return nil, err
+	}
+	defer dockerfile.Close()
+
+	instructions, err := readDockerfile(dockerfile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the Dockerfile: %w", err)
+	}
+
+	created, err := sourceDateEpoch()
+	if err != nil {
+		return nil, err
+	}
+
+	ignore, err := readDockerignore(b.ContextPath)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]v1.Image, 0, len(b.Platforms))
+	for _, platform := range b.Platforms {
+		img, err := b.image(ctx, instructions, ignore, platform, created)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+func (b nativeBuild) image(ctx context.Context, instructions []dockerfileInstruction, ignore []ignorePattern, platform v1.Platform, created time.Time) (v1.Image, error) {
+	baseImage, stage, err := parseAppendableDockerfile(instructions, b.BuildArgMap, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := pullBaseImage(ctx, baseImage, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	baseConfig, err := base.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the configuration of %s: %w", baseImage, err)
+	}
+
+	recipe, err := newNativeRecipe(baseImage, stage, baseConfig.Config, b.BuildArgMap, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	layerTar, err := b.layer(recipe.copies, ignore, created)
+	if err != nil {
+		return nil, err
+	}
+
+	layerMediaType := types.DockerLayer
+	if mediaType, err := base.MediaType(); err == nil && mediaType == types.OCIManifestSchema1 {
+		layerMediaType = types.OCILayer
+	}
+
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(layerTar)), nil
+	}, tarball.WithMediaType(layerMediaType))
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := mutate.Append(base, mutate.Addendum{
+		Layer:     layer,
+		MediaType: layerMediaType,
+		History: v1.History{
+			Created:   v1.Time{Time: created},
+			CreatedBy: "forge-cli build --builder=native",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	configFile = configFile.DeepCopy()
+	configFile.Config = recipe.config
+	configFile.Created = v1.Time{Time: created}
+	configFile.OS = platform.OS
+	configFile.Architecture = platform.Architecture
+	configFile.Variant = platform.Variant
+
+	if len(b.BuildLabelMap) > 0 && configFile.Config.Labels == nil {
+		configFile.Config.Labels = map[string]string{}
+	}
+	for k, v := range b.BuildLabelMap {
+		configFile.Config.Labels[k] = v
+	}
+
+	return mutate.ConfigFile(img, configFile)
+}
+
+// pullBaseImage gets the base image from its registry, with the credentials
+// of the Docker config file, "scratch" is an empty image
+func pullBaseImage(ctx context.Context, baseImage string, platform v1.Platform) (v1.Image, error) {
+	if baseImage == "scratch" {
+		return empty.Image, nil
+	}
+
+	ref, err := name.ParseReference(baseImage)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base image %s: %w", baseImage, err)
+	}
+
+	img, err := remote.Image(ref,
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		remote.WithPlatform(platform))
+	if err != nil {
+		return nil, fmt.Errorf("unable to pull base image %s: %w", baseImage, err)
+	}
+	return img, nil
+}
+
+// layerEntry is a file of the appended layer, and the file of the build
+// context it is read from
+type layerEntry struct {
+	header *tar.Header
+	source string
+}
+
+// layer writes the files copied from the build context as a tar archive. The
+// entries are sorted, and owned by root with the same time unless --chown is
+// given, so that the layer is reproducible.
+func (b nativeBuild) layer(copies []nativeCopy, ignore []ignorePattern, created time.Time) ([]byte, error) {
+	entries := map[string]layerEntry{}
+
+	for _, c := range copies {
+		var sources []string
+		for _, source := range c.sources {
+			matches, err := b.contextFiles(source, ignore)
+			if err != nil {
+				return nil, fmt.Errorf("COPY on line %d: %w", c.line, err)
+			}
+			sources = append(sources, matches...)
+		}
+		if len(sources) == 0 {
+			return nil, fmt.Errorf("COPY on line %d: no files in the build context match %s", c.line, strings.Join(c.sources, " "))
+		}
+
+		destIsDir := strings.HasSuffix(c.dest, "/") || len(sources) > 1
+		for _, source := range sources {
+			info, err := os.Lstat(source)
+			if err != nil {
+				return nil, err
+			}
+
+			if info.IsDir() {
+				if err := b.addDirectory(entries, c, source, c.dest, ignore, created); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			dest := c.dest
+			if destIsDir {
+				dest = path.Join(dest, filepath.Base(source))
+			}
+			if err := addLayerEntry(entries, c, source, dest, info, created); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range names {
+		entry := entries[name]
+		if err := tw.WriteHeader(entry.header); err != nil {
+			return nil, err
+		}
+		if entry.header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		f, err := os.Open(entry.source)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(tw, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// contextFiles gives the files of the build context which match a COPY
+// source
+func (b nativeBuild) contextFiles(source string, ignore []ignorePattern) ([]string, error) {
+	clean := path.Clean("/" + filepath.ToSlash(source))
+	pattern := filepath.Join(b.ContextPath, filepath.FromSlash(clean))
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, match := range matches {
+		rel, err := filepath.Rel(b.ContextPath, match)
+		if err != nil {
+			return nil, err
+		}
+		if rel != "." && isIgnored(ignore, filepath.ToSlash(rel)) {
+			continue
+		}
+		files = append(files, match)
+	}
+	return files, nil
+}
+
+// addDirectory copies the contents of a directory, but not the directory
+// itself, as Docker does
+func (b nativeBuild) addDirectory(entries map[string]layerEntry, c nativeCopy, source, dest string, ignore []ignorePattern, created time.Time) error {
+	return filepath.Walk(source, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if file == source {
+			return nil
+		}
+
+		rel, err := filepath.Rel(b.ContextPath, file)
+		if err != nil {
+			return err
+		}
+		if isIgnored(ignore, filepath.ToSlash(rel)) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relToSource, err := filepath.Rel(source, file)
+		if err != nil {
+			return err
+		}
+		return addLayerEntry(entries, c, file, path.Join(dest, filepath.ToSlash(relToSource)), info, created)
+	})
+}
+
+func addLayerEntry(entries map[string]layerEntry, c nativeCopy, source, dest string, info os.FileInfo, created time.Time) error {
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		var err error
+		if link, err = os.Readlink(source); err != nil {
+			return err
+		}
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+
+	header.Name = strings.TrimPrefix(path.Clean(dest), "/")
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	header.ModTime = created
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+	header.Uname = ""
+	header.Gname = ""
+	header.Uid = 0
+	header.Gid = 0
+	if c.uid >= 0 {
+		header.Uid = c.uid
+		header.Gid = c.gid
+	}
+	if c.mode != 0 && header.Typeflag != tar.TypeSymlink {
+		header.Mode = int64(c.mode)
+	}
+	header.Format = tar.FormatPAX
+
+	entries[header.Name] = layerEntry{header: header, source: source}
+	return nil
+}
+
+// ignorePattern is a line of .dockerignore, patterns are matched in order
+// and the last one to match a path decides whether it is excluded
+type ignorePattern struct {
+	pattern string
+	exclude bool
+}
+
+func readDockerignore(contextPath string) ([]ignorePattern, error) {
+	f, err := os.Open(filepath.Join(contextPath, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []ignorePattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		p := ignorePattern{exclude: true}
+		if strings.HasPrefix(line, "!") {
+			p.exclude = false
+			line = strings.TrimSpace(line[1:])
+		}
+		p.pattern = strings.Trim(path.Clean("/"+line), "/")
+		patterns = append(patterns, p)
+	}
+	return patterns, scanner.Err()
+}
+
+// isIgnored matches a path, or any of its parents, against the patterns
+func isIgnored(patterns []ignorePattern, rel string) bool {
+	ignored := false
+	for _, p := range patterns {
+		for candidate := rel; candidate != "." && candidate != "/"; candidate = path.Dir(candidate) {
+			if matched, _ := path.Match(p.pattern, candidate); matched {
+				ignored = p.exclude
+				break
+			}
+		}
+	}
+	return ignored
+}
+
+// writeLayout writes the image to an OCI image layout, replacing any image
+// written to it before
+func (b nativeBuild) writeLayout(ctx context.Context, layoutPath string) error {
+	images, err := b.images(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(layoutPath); err != nil {
+		return err
+	}
+	p, err := layout.Write(layoutPath, empty.Index)
+	if err != nil {
+		return err
+	}
+
+	for i, img := range images {
+		if err := p.AppendImage(img,
+			layout.WithPlatform(b.Platforms[i]),
+			layout.WithAnnotations(map[string]string{"org.opencontainers.image.ref.name": b.Image})); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// push pushes the image to its registry for each of the platforms
+func (b nativeBuild) push(ctx context.Context, extraTags []string) error {
+	images, err := b.images(ctx)
+	if err != nil {
+		return err
+	}
+
+	return pushImages(ctx, b.Image, images, b.Platforms, extraTags)
+}
+
+// pushImages pushes images to a registry with the credentials of the Docker
+// config file. More than one image is pushed as an image index of the
+// platforms.
+func pushImages(ctx context.Context, imageName string, images []v1.Image, platforms []v1.Platform, extraTags []string) error {
+	ref, err := name.ParseReference(imageName)
+	if err != nil {
+		return fmt.Errorf("invalid image name %s: %w", imageName, err)
+	}
+
+	options := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	}
+
+	var taggable remote.Taggable
+	if len(images) == 1 {
+		if err := remote.Write(ref, images[0], options...); err != nil {
+			return err
+		}
+		taggable = images[0]
+	} else {
+		adds := make([]mutate.IndexAddendum, 0, len(images))
+		for i, img := range images {
+			platform := platforms[i]
+			adds = append(adds, mutate.IndexAddendum{
+				Add:        img,
+				Descriptor: v1.Descriptor{Platform: &platform},
+			})
+		}
+
+		index := mutate.AppendManifests(empty.Index, adds...)
+		if err := remote.WriteIndex(ref, index, options...); err != nil {
+			return err
+		}
+		taggable = index
+	}
+
+	for _, extraTag := range extraTags {
+		tag, err := name.NewTag(extraTag)
+		if err != nil {
+			return fmt.Errorf("invalid extra tag %s: %w", extraTag, err)
+		}
+		if err := remote.Tag(tag, taggable, options...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NativeLayoutPath is the OCI image layout which forge-cli build
+// --builder=native writes the image of a function to
+func NativeLayoutPath(functionName string) string {
+	return fmt.Sprintf("./build/%s.oci", functionName)
+}
+
+// nativeBackend builds without a Docker daemon, Build writes an OCI image
+// layout which Push reads from
+type nativeBackend struct{}
+
+func (nativeBackend) Build(ctx context.Context, build BuildContext) error {
+	native := newNativeBuild(build, []v1.Platform{NativePlatform()})
+
+	layoutPath := NativeLayoutPath(build.FunctionName)
+	if err := native.writeLayout(ctx, layoutPath); err != nil {
+		return fmt.Errorf("[%s] native build failed: %w", build.FunctionName, err)
+	}
+
+	fmt.Printf("Image: %s built to OCI layout %s.\n", build.Image, layoutPath)
+	return nil
+}
+
+func (nativeBackend) imageExists(ctx context.Context, build BuildContext) bool {
+	_, err := os.Stat(NativeLayoutPath(build.FunctionName))
+	return err == nil
+}
+
+func (nativeBackend) Push(ctx context.Context, functionName, image string, quiet bool) error {
+	layoutPath := NativeLayoutPath(functionName)
+	index, err := layout.ImageIndexFromPath(layoutPath)
+	if err != nil {
+		return fmt.Errorf("[%s] unable to read %s, build the function first: %w", functionName, layoutPath, err)
+	}
+
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return err
+	}
+
+	var images []v1.Image
+	var platforms []v1.Platform
+	for _, descriptor := range manifest.Manifests {
+		img, err := index.Image(descriptor.Digest)
+		if err != nil {
+			return err
+		}
+		images = append(images, img)
+
+		platform := NativePlatform()
+		if descriptor.Platform != nil {
+			platform = *descriptor.Platform
+		}
+		platforms = append(platforms, platform)
+	}
+	if len(images) == 0 {
+		return fmt.Errorf("[%s] %s has no images, build the function first", functionName, layoutPath)
+	}
+
+	return pushImages(ctx, image, images, platforms, nil)
+}
+
+func (nativeBackend) Publish(ctx context.Context, build BuildContext) error {
+	platforms, err := ParsePlatforms(build.Platforms)
+	if err != nil {
+		return err
+	}
+
+	native := newNativeBuild(build, platforms)
+	if err := native.push(ctx, extraTagNames(build.Image, build.ExtraTags)); err != nil {
+		return fmt.Errorf("[%s] native build failed: %w", build.FunctionName, err)
+	}
+
+	fmt.Printf("Image: %s built and pushed.\n", build.Image)
+	return nil
+}
+
+func newNativeBuild(build BuildContext, platforms []v1.Platform) nativeBuild {
+	return nativeBuild{
+		Image:         build.Image,
+		ContextPath:   build.Path,
+		BuildArgMap:   build.BuildArgMap,
+		BuildLabelMap: build.BuildLabelMap,
+		Platforms:     platforms,
+	}
+}
@@ -0,0 +1,269 @@
+package builder
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+)
+
+func Test_parseAppendableDockerfile_NotAppendable(t *testing.T) {
+	cases := []struct {
+		name       string
+		dockerfile string
+	}{
+		{
+			name:       "RUN",
+			dockerfile: "FROM alpine:3.18\nRUN apk add curl\n",
+		},
+		{
+			name:       "multi-stage",
+			dockerfile: "FROM golang:1.21 AS build\nCOPY . .\nFROM alpine:3.18\n",
+		},
+		{
+			name:       "ADD",
+			dockerfile: "FROM alpine:3.18\nADD https://example.com/file /file\n",
+		},
+		{
+			name:       "COPY --from",
+			dockerfile: "FROM alpine:3.18\nCOPY --from=busybox /bin/sh /bin/sh\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			instructions, err := readDockerfile(strings.NewReader(tc.dockerfile))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			base, stage, err := parseAppendableDockerfile(instructions, nil, NativePlatform())
+			if err == nil {
+				_, err = newNativeRecipe(base, stage, v1.Config{}, nil, NativePlatform())
+			}
+
+			var appendErr *layerAppendableError
+			if !errors.As(err, &appendErr) {
+				t.Fatalf("want a layerAppendableError, got: %v", err)
+			}
+		})
+	}
+}
+
+func Test_newNativeRecipe(t *testing.T) {
+	dockerfile := `ARG BASE=alpine:3.18
+FROM --platform=${TARGETPLATFORM} ${BASE}
+ARG TARGETARCH
+ARG VERSION=dev
+ENV fprocess="node index.js" \
+    VERSION=${VERSION}-${TARGETARCH}
+LABEL org.opencontainers.image.title=echo
+WORKDIR /home/app
+WORKDIR function
+COPY --chown=1000:1000 --chmod=0755 handler.js package.json ./
+COPY index.js .
+USER app
+EXPOSE 8080
+HEALTHCHECK --interval=3s CMD [ -e /tmp/.lock ] || exit 1
+ENTRYPOINT ["fwatchdog"]
+`
+	instructions, err := readDockerfile(strings.NewReader(dockerfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	platform := v1.Platform{OS: "linux", Architecture: "arm64"}
+	buildArgs := map[string]string{"VERSION": "1.0.0"}
+
+	baseImage, stage, err := parseAppendableDockerfile(instructions, buildArgs, platform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if baseImage != "alpine:3.18" {
+		t.Fatalf("want the base image from the ARG default, got: %s", baseImage)
+	}
+
+	base := v1.Config{
+		Env: []string{"PATH=/usr/bin"},
+		Cmd: []string{"/bin/sh"},
+	}
+	recipe, err := newNativeRecipe(baseImage, stage, base, buildArgs, platform)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := recipe.config
+	wantEnv := []string{"PATH=/usr/bin", "fprocess=node index.js", "VERSION=1.0.0-arm64"}
+	if !reflect.DeepEqual(config.Env, wantEnv) {
+		t.Errorf("want env %v, got %v", wantEnv, config.Env)
+	}
+	if config.WorkingDir != "/home/app/function" {
+		t.Errorf("want the relative WORKDIR to be joined, got: %s", config.WorkingDir)
+	}
+	if config.User != "app" {
+		t.Errorf("want user app, got: %s", config.User)
+	}
+	if _, ok := config.ExposedPorts["8080/tcp"]; !ok {
+		t.Errorf("want port 8080/tcp to be exposed, got: %v", config.ExposedPorts)
+	}
+	if config.Labels["org.opencontainers.image.title"] != "echo" {
+		t.Errorf("want the label to be set, got: %v", config.Labels)
+	}
+	if !reflect.DeepEqual(config.Entrypoint, []string{"fwatchdog"}) || config.Cmd != nil {
+		t.Errorf("want the entrypoint to replace the base image's cmd, got: %v %v", config.Entrypoint, config.Cmd)
+	}
+	if config.Healthcheck == nil || config.Healthcheck.Test[0] != "CMD-SHELL" {
+		t.Errorf("want a shell healthcheck, got: %v", config.Healthcheck)
+	}
+
+	if len(recipe.copies) != 2 {
+		t.Fatalf("want 2 copies, got: %d", len(recipe.copies))
+	}
+	first := recipe.copies[0]
+	if first.dest != "/home/app/function/" || first.uid != 1000 || first.gid != 1000 || first.mode != 0755 {
+		t.Errorf("unexpected first copy: %+v", first)
+	}
+	if recipe.copies[1].dest != "/home/app/function/" {
+		t.Errorf("want . to be the WORKDIR, got: %s", recipe.copies[1].dest)
+	}
+
+	if len(base.Env) != 1 {
+		t.Errorf("the base image's config must not be changed, got: %v", base.Env)
+	}
+}
+
+func Test_nativeBuild_writeLayout(t *testing.T) {
+	contextPath := t.TempDir()
+	writeFiles(t, contextPath, map[string]string{
+		"Dockerfile":           "FROM scratch\nWORKDIR /home/app\nCOPY --chown=1000:1000 function/ ./\nCOPY index.js /\nCMD [\"./handler\"]\n",
+		".dockerignore":        "function/*.md\n",
+		"index.js":             "console.log('index')",
+		"function/handler":     "#!/bin/sh",
+		"function/README.md":   "# handler",
+		"function/lib/util.js": "module.exports = {}",
+	})
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	build := nativeBuild{
+		Image:         "ttl.sh/echo:latest",
+		ContextPath:   contextPath,
+		BuildLabelMap: map[string]string{"built-by": "forge-cli"},
+		Platforms:     []v1.Platform{{OS: "linux", Architecture: "amd64"}},
+	}
+
+	first := filepath.Join(t.TempDir(), "echo.oci")
+	second := filepath.Join(t.TempDir(), "echo.oci")
+	for _, layoutPath := range []string{first, second} {
+		if err := build.writeLayout(context.Background(), layoutPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	img := readLayoutImage(t, first)
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secondDigest, _ := readLayoutImage(t, second).Digest(); digest != secondDigest {
+		t.Fatalf("want reproducible builds, got %s and %s", digest, secondDigest)
+	}
+
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configFile.Created.Unix() != 1700000000 {
+		t.Errorf("want the image to be created at SOURCE_DATE_EPOCH, got: %s", configFile.Created)
+	}
+	if configFile.Architecture != "amd64" || configFile.Config.WorkingDir != "/home/app" {
+		t.Errorf("unexpected config: %+v", configFile)
+	}
+	if configFile.Config.Labels["built-by"] != "forge-cli" {
+		t.Errorf("want the build label, got: %v", configFile.Config.Labels)
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layers) != 1 {
+		t.Fatalf("want 1 layer, got: %d", len(layers))
+	}
+
+	rc, err := layers[0].Uncompressed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	var names []string
+	tr := tar.NewReader(rc)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names = append(names, header.Name)
+		if header.ModTime.Unix() != 1700000000 {
+			t.Errorf("want %s to be timestamped with SOURCE_DATE_EPOCH, got: %s", header.Name, header.ModTime)
+		}
+		if strings.HasPrefix(header.Name, "home/app/") && header.Uid != 1000 {
+			t.Errorf("want %s to be owned by 1000, got: %d", header.Name, header.Uid)
+		}
+	}
+
+	want := []string{"home/app/handler", "home/app/lib/", "home/app/lib/util.js", "index.js"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("want layer entries %v, got %v", want, names)
+	}
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, contents := range files {
+		target := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(target, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readLayoutImage(t *testing.T, layoutPath string) v1.Image {
+	t.Helper()
+
+	index, err := layout.ImageIndexFromPath(layoutPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Manifests) != 1 {
+		t.Fatalf("want 1 image in the layout, got: %d", len(manifest.Manifests))
+	}
+
+	img, err := index.Image(manifest.Manifests[0].Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
@@ -0,0 +1,506 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package builder
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errBuildContext is returned when the tar of the build context can't be
+// written, the upload is not retried as it would fail again
+var errBuildContext = errors.New("failed to create tar file")
+
+type builderConfig struct {
+	Image     string            `json:"image"`
+	BuildArgs map[string]string `json:"buildArgs,omitempty"`
+	Platforms []string          `json:"platforms,omitempty"`
+}
+
+type builderResult struct {
+	Log    []string `json:"log"`
+	Image  string   `json:"image"`
+	Status string   `json:"status"`
+
+	// ID is set when the builder accepted the build as a job to be polled
+	ID string `json:"id,omitempty"`
+}
+
+const BuilderConfigFilename = "com.forge4flow.docker.config"
+
+const (
+	// DefaultRemoteBuilderTimeout is how long a remote build may take,
+	// including the upload and waiting for the job to finish
+	DefaultRemoteBuilderTimeout = 30 * time.Minute
+
+	// DefaultRemoteBuilderRetries is how many times a request to the remote
+	// builder is retried when it fails to connect or the builder is busy
+	DefaultRemoteBuilderRetries = 3
+
+	// builderPollInterval is how often the status of a job is polled when
+	// the builder does not stream its logs
+	builderPollInterval = 2 * time.Second
+
+	// buildSignatureHeader is the trailer with the signature of the upload
+	buildSignatureHeader = "X-Build-Signature"
+)
+
+// remoteBackend streams the build context to a builder API, signed with the
+// payload secret, which builds and pushes the image
+type remoteBackend struct {
+	url               string
+	payloadSecretPath string
+	token             string
+	timeout           time.Duration
+	retries           int
+	client            *http.Client
+
+	// retryWait is the wait before the first retry, it doubles for each one
+	retryWait time.Duration
+
+	// pollInterval is how often a job's status is read when the builder
+	// does not stream its log
+	pollInterval time.Duration
+}
+
+func newRemoteBackend(options BackendOptions) remoteBackend {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options.TLSInsecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRemoteBuilderTimeout
+	}
+
+	return remoteBackend{
+		url:               options.RemoteBuilder,
+		payloadSecretPath: options.PayloadSecretPath,
+		token:             options.Token,
+		timeout:           timeout,
+		retries:           options.Retries,
+		client:            &http.Client{Transport: transport},
+		retryWait:         time.Second,
+		pollInterval:      builderPollInterval,
+	}
+}
+
+func (r remoteBackend) Build(ctx context.Context, build BuildContext) error {
+	return r.build(ctx, build, builderConfig{Image: build.Image, BuildArgs: build.BuildArgMap})
+}
+
+// Push does nothing as the remote builder pushes the image as it builds it
+func (remoteBackend) Push(ctx context.Context, functionName, image string, quiet bool) error {
+	fmt.Printf("%s was pushed by the remote builder.\n", image)
+	return nil
+}
+
+func (r remoteBackend) Publish(ctx context.Context, build BuildContext) error {
+	config := builderConfig{Image: build.Image, BuildArgs: build.BuildArgMap}
+	if len(build.Platforms) > 0 {
+		config.Platforms = strings.Split(build.Platforms, ",")
+	}
+	return r.build(ctx, build, config)
+}
+
+// build uploads the build context to the builder. A builder which accepts
+// the build as a job is followed until the job finishes, printing its log as
+// it is written.
+func (r remoteBackend) build(ctx context.Context, build BuildContext, config builderConfig) error {
+	functionName := build.FunctionName
+
+	// without a token the signature is the only thing which authenticates
+	// the upload to the builder
+	if len(r.payloadSecretPath) == 0 && len(r.token) == 0 {
+		return fmt.Errorf("%s the remote builder requires --payload-secret, or --remote-builder-token", functionName)
+	}
+
+	var payloadSecret []byte
+	if len(r.payloadSecretPath) > 0 {
+		secret, err := os.ReadFile(r.payloadSecretPath)
+		if err != nil {
+			return err
+		}
+		payloadSecret = bytes.TrimSpace(secret)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	res, err := r.callBuilder(ctx, config, functionName, payloadSecret)
+	if err != nil {
+		return r.timeoutError(ctx, functionName, err)
+	}
+	defer res.Body.Close()
+
+	data, _ := io.ReadAll(res.Body)
+
+	result := builderResult{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("%s unexpected response from the builder, status code %d: %s", functionName, res.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	if res.StatusCode == http.StatusAccepted && len(result.ID) > 0 {
+		statusURL, err := r.jobURL(res, result.ID)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("%s build queued as job %s", functionName, result.ID)
+		if result, err = r.followJob(ctx, statusURL, build.Quiet); err != nil {
+			return r.timeoutError(ctx, functionName, err)
+		}
+
+		if !jobSucceeded(result.Status) {
+			return fmt.Errorf("%s failure while building or pushing image %s: %s", functionName, build.Image, result.Status)
+		}
+
+		log.Printf("%s success building and pushing image: %s", functionName, result.Image)
+		return nil
+	}
+
+	if !build.Quiet {
+		for _, logMsg := range result.Log {
+			fmt.Printf("%s\n", logMsg)
+		}
+	}
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusAccepted {
+		fmt.Println(res.StatusCode)
+		return fmt.Errorf("%s failure while building or pushing image %s: %s", functionName, build.Image, result.Status)
+	}
+
+	log.Printf("%s success building and pushing image: %s", functionName, result.Image)
+	return nil
+}
+
+func (r remoteBackend) timeoutError(ctx context.Context, functionName string, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s the remote builder did not finish within %s: %w", functionName, r.timeout, err)
+	}
+	return err
+}
+
+// makeTar writes the build context and its config to w as a tar
+func makeTar(buildConfig builderConfig, base string, w io.Writer) error {
+	configBytes, _ := json.Marshal(buildConfig)
+	if err := os.WriteFile(path.Join(base, BuilderConfigFilename), configBytes, 0664); err != nil {
+		return err
+	}
+
+	bufferedWriter := bufio.NewWriter(w)
+	tarWriter := tar.NewWriter(bufferedWriter)
+
+	err := filepath.Walk(base, func(path string, f os.FileInfo, pathErr error) error {
+		if pathErr != nil {
+			return pathErr
+		}
+
+		header, err := tar.FileInfoHeader(f, f.Name())
+		if err != nil {
+			return err
+		}
+
+		header.Name = strings.TrimPrefix(path, base)
+		if header.Name != fmt.Sprintf("/%s", BuilderConfigFilename) {
+			header.Name = filepath.Join("context", header.Name)
+		}
+
+		header.Name = strings.TrimPrefix(header.Name, "/")
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if f.Mode().IsDir() {
+			return nil
+		}
+
+		targetFile, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer targetFile.Close()
+
+		_, err = io.Copy(tarWriter, targetFile)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return bufferedWriter.Flush()
+}
+
+// callBuilder streams the build context to the builder as a tar with chunked
+// transfer encoding, the tar is written as it is sent. With a payload secret
+// the HMAC-SHA256 of the body is sent in the X-Build-Signature trailer once
+// the tar is written. The request is retried, with the tar written again,
+// when the builder can't be reached or is busy.
+func (r remoteBackend) callBuilder(ctx context.Context, config builderConfig, functionName string, payloadSecret []byte) (*http.Response, error) {
+	base := path.Join("build", functionName)
+
+	log.Printf("%s invoking the API for build at %s, streaming the build context", functionName, r.url)
+
+	return r.do(ctx, func() (*http.Request, error) {
+		body, writer := io.Pipe()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, body)
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = -1
+
+		var mac hash.Hash
+		if payloadSecret != nil {
+			mac = hmac.New(sha256.New, payloadSecret)
+			req.Trailer = http.Header{buildSignatureHeader: nil}
+		}
+
+		go func() {
+			var w io.Writer = writer
+			if mac != nil {
+				w = io.MultiWriter(writer, mac)
+			}
+
+			if err := makeTar(config, base, w); err != nil {
+				writer.CloseWithError(fmt.Errorf("%w for %s, error: %w", errBuildContext, functionName, err))
+				return
+			}
+
+			// the trailer is read by the client once the body is closed
+			if mac != nil {
+				req.Trailer.Set(buildSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+			}
+			writer.Close()
+		}()
+
+		req.Header.Set("Content-Type", "application/octet-stream")
+		// a builder which supports jobs replies with 202 and a job ID
+		req.Header.Set("Prefer", "respond-async")
+		return req, nil
+	})
+}
+
+// do makes a request with bearer auth, the request is made again when it
+// fails to connect or gets a 429, 502, 503 or 504 from the builder
+func (r remoteBackend) do(ctx context.Context, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	wait := r.retryWait
+
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		if len(r.token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+r.token)
+		}
+
+		res, err := r.client.Do(req)
+		retry := err != nil && ctx.Err() == nil && !errors.Is(err, errBuildContext)
+		if err == nil {
+			switch res.StatusCode {
+			case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+				retry = true
+			}
+		}
+
+		if !retry || attempt >= r.retries {
+			return res, err
+		}
+
+		delay := wait
+		if err == nil {
+			if seconds, parseErr := strconv.Atoi(res.Header.Get("Retry-After")); parseErr == nil && seconds >= 0 {
+				delay = time.Duration(seconds) * time.Second
+			}
+			log.Printf("the builder returned %d, retrying in %s", res.StatusCode, delay)
+			res.Body.Close()
+		} else {
+			log.Printf("unable to reach the builder: %s, retrying in %s", err, delay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		wait *= 2
+	}
+}
+
+// jobURL is where the status of a job is read from, the Location of the
+// builder's response, or the job ID under the builder's URL
+func (r remoteBackend) jobURL(res *http.Response, id string) (string, error) {
+	base, err := url.Parse(r.url)
+	if err != nil {
+		return "", err
+	}
+
+	if location := res.Header.Get("Location"); len(location) > 0 {
+		ref, err := url.Parse(location)
+		if err != nil {
+			return "", fmt.Errorf("invalid Location from the builder %q: %w", location, err)
+		}
+		return base.ResolveReference(ref).String(), nil
+	}
+
+	return strings.TrimSuffix(r.url, "/") + "/" + url.PathEscape(id), nil
+}
+
+// followJob waits for a job to finish. A builder which replies with an event
+// stream has its log lines printed as they arrive, otherwise the status is
+// polled and the new lines of its log are printed.
+func (r remoteBackend) followJob(ctx context.Context, statusURL string, quiet bool) (builderResult, error) {
+	printed := 0
+	lastEventID := ""
+
+	for {
+		res, err := r.do(ctx, func() (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, statusURL, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Accept", "text/event-stream, application/json")
+			if len(lastEventID) > 0 {
+				req.Header.Set("Last-Event-ID", lastEventID)
+			}
+			return req, nil
+		})
+		if err != nil {
+			return builderResult{}, err
+		}
+
+		if res.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+			res.Body.Close()
+			return builderResult{}, fmt.Errorf("unable to get the status of the build from %s, status code %d: %s", statusURL, res.StatusCode, strings.TrimSpace(string(body)))
+		}
+
+		if strings.HasPrefix(res.Header.Get("Content-Type"), "text/event-stream") {
+			result, done, err := readBuildEvents(res.Body, quiet, &lastEventID)
+			res.Body.Close()
+			if err != nil || done {
+				return result, err
+			}
+			// the stream ended before the result, so it is opened again
+			// after the poll interval
+		} else {
+			result := builderResult{}
+			err = json.NewDecoder(res.Body).Decode(&result)
+			res.Body.Close()
+			if err != nil {
+				return builderResult{}, fmt.Errorf("unable to read the status of the build from %s: %w", statusURL, err)
+			}
+
+			if printed > len(result.Log) {
+				printed = 0
+			}
+			if !quiet {
+				for _, line := range result.Log[printed:] {
+					fmt.Printf("%s\n", line)
+				}
+			}
+			printed = len(result.Log)
+
+			if jobDone(result.Status) {
+				return result, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return builderResult{}, ctx.Err()
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+// readBuildEvents prints "log" events and returns the job's "result" event,
+// done is false when the stream ends before the result
+func readBuildEvents(body io.Reader, quiet bool, lastEventID *string) (builderResult, bool, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	event := ""
+	var data []string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if len(line) == 0 {
+			payload := strings.Join(data, "\n")
+			switch event {
+			case "", "log":
+				if !quiet && len(data) > 0 {
+					fmt.Printf("%s\n", payload)
+				}
+			case "result":
+				result := builderResult{}
+				if err := json.Unmarshal([]byte(payload), &result); err != nil {
+					return result, false, fmt.Errorf("unable to read the result of the build: %w", err)
+				}
+				return result, true, nil
+			}
+			event = ""
+			data = nil
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			*lastEventID = value
+		}
+	}
+
+	return builderResult{}, false, scanner.Err()
+}
+
+// jobDone is false while the status is one used for a queued or running job,
+// any other status such as "cancelled" ends the job
+func jobDone(status string) bool {
+	switch strings.ToLower(status) {
+	case "", "queued", "pending", "accepted", "started", "running", "building", "in_progress", "in-progress":
+		return false
+	}
+	return true
+}
+
+func jobSucceeded(status string) bool {
+	switch strings.ToLower(status) {
+	case "success", "succeeded":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,284 @@
+package builder
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_remoteBackend_Build_Sync(t *testing.T) {
+	useBuildContext(t, "echo")
+
+	secretPath := filepath.Join(t.TempDir(), "payload.txt")
+	if err := os.WriteFile(secretPath, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("want bearer auth, got: %q", got)
+		}
+
+		if len(r.TransferEncoding) == 0 || r.TransferEncoding[0] != "chunked" {
+			t.Errorf("want a chunked upload, got: %v", r.TransferEncoding)
+		}
+		if _, ok := r.Trailer["X-Build-Signature"]; !ok {
+			t.Errorf("want the signature to be announced as a trailer, got: %v", r.Header.Get("Trailer"))
+		}
+
+		// the trailer is only read once the body is read
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(body)
+		if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); r.Trailer.Get("X-Build-Signature") != want {
+			t.Errorf("want signature %s, got: %s", want, r.Trailer.Get("X-Build-Signature"))
+		}
+
+		tr := tar.NewReader(strings.NewReader(string(body)))
+		for {
+			header, err := tr.Next()
+			if err != nil {
+				break
+			}
+			names = append(names, header.Name)
+		}
+
+		json.NewEncoder(w).Encode(builderResult{Log: []string{"step 1"}, Image: "ttl.sh/echo:latest", Status: "success"})
+	}))
+	defer server.Close()
+
+	backend := newRemoteBackend(BackendOptions{RemoteBuilder: server.URL, PayloadSecretPath: secretPath, Token: "token"})
+	if err := backend.Build(context.Background(), BuildContext{FunctionName: "echo", Image: "ttl.sh/echo:latest"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{BuilderConfigFilename: true, "context/Dockerfile": true}
+	for _, name := range names {
+		delete(want, name)
+	}
+	if len(want) > 0 {
+		t.Fatalf("want the config and Dockerfile in the tar, got: %v", names)
+	}
+}
+
+func Test_remoteBackend_Build_RequiresSecretOrToken(t *testing.T) {
+	useBuildContext(t, "echo")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("want no unsigned upload, got: %s %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	backend := newRemoteBackend(BackendOptions{RemoteBuilder: server.URL})
+	err := backend.Build(context.Background(), BuildContext{FunctionName: "echo", Image: "ttl.sh/echo:latest"})
+	if err == nil || !strings.Contains(err.Error(), "--payload-secret") {
+		t.Fatalf("want an error asking for the payload secret, got: %v", err)
+	}
+}
+
+func Test_remoteBackend_Build_TarErrorIsNotRetried(t *testing.T) {
+	useBuildContext(t, "echo")
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	backend := newRemoteBackend(BackendOptions{RemoteBuilder: server.URL, Token: "token", Retries: 3})
+	backend.retryWait = time.Millisecond
+
+	// there is no build context for the function
+	err := backend.Build(context.Background(), BuildContext{FunctionName: "missing", Image: "ttl.sh/missing:latest"})
+	if !errors.Is(err, errBuildContext) {
+		t.Fatalf("want an error creating the tar, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&requests); got > 1 {
+		t.Fatalf("want the upload not to be retried, got %d requests", got)
+	}
+}
+
+func Test_remoteBackend_Build_PollsJob(t *testing.T) {
+	useBuildContext(t, "echo")
+
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			if r.Header.Get("Prefer") != "respond-async" {
+				t.Errorf("want the build to be requested as a job, got: %q", r.Header.Get("Prefer"))
+			}
+			w.Header().Set("Location", "/jobs/job-1")
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(builderResult{ID: "job-1", Status: "queued"})
+		case r.URL.Path == "/jobs/job-1":
+			result := builderResult{Log: []string{"step 1"}, Status: "running"}
+			if atomic.AddInt32(&polls, 1) > 1 {
+				result = builderResult{Log: []string{"step 1", "step 2"}, Image: "ttl.sh/echo:latest", Status: "success"}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(result)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	backend := newRemoteBackend(BackendOptions{RemoteBuilder: server.URL + "/build", Token: "token"})
+	backend.pollInterval = time.Millisecond
+	if err := backend.Build(context.Background(), BuildContext{FunctionName: "echo", Image: "ttl.sh/echo:latest"}); err != nil {
+		t.Fatal(err)
+	}
+	if polls != 2 {
+		t.Fatalf("want the job to be polled until it succeeded, got %d polls", polls)
+	}
+}
+
+func Test_remoteBackend_Build_FollowsEventsAndRetries(t *testing.T) {
+	useBuildContext(t, "echo")
+
+	var posts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if atomic.AddInt32(&posts, 1) == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(builderResult{ID: "job-1"})
+			return
+		}
+
+		if r.URL.Path != "/build/job-1" {
+			t.Errorf("want the job under the builder's URL, got: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "id: 1\nevent: log\ndata: step 1\n\n")
+		fmt.Fprint(w, "event: result\ndata: {\"status\":\"failed\",\"image\":\"ttl.sh/echo:latest\"}\n\n")
+	}))
+	defer server.Close()
+
+	backend := newRemoteBackend(BackendOptions{RemoteBuilder: server.URL + "/build", Token: "token", Retries: 1})
+	backend.retryWait = time.Millisecond
+
+	err := backend.Build(context.Background(), BuildContext{FunctionName: "echo", Image: "ttl.sh/echo:latest"})
+	if err == nil || !strings.Contains(err.Error(), "failed") {
+		t.Fatalf("want the failed result of the job, got: %v", err)
+	}
+	if posts != 2 {
+		t.Fatalf("want the upload to be retried once, got %d uploads", posts)
+	}
+}
+
+func Test_remoteBackend_Build_UnknownStatusEndsJob(t *testing.T) {
+	useBuildContext(t, "echo")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(builderResult{ID: "job-1"})
+			return
+		}
+		json.NewEncoder(w).Encode(builderResult{Status: "cancelled"})
+	}))
+	defer server.Close()
+
+	backend := newRemoteBackend(BackendOptions{RemoteBuilder: server.URL, Token: "token", Timeout: time.Second})
+	backend.pollInterval = time.Millisecond
+
+	err := backend.Build(context.Background(), BuildContext{FunctionName: "echo", Image: "ttl.sh/echo:latest"})
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Fatalf("want the cancelled job to fail the build, got: %v", err)
+	}
+}
+
+func Test_remoteBackend_Build_WaitsBeforeReconnecting(t *testing.T) {
+	useBuildContext(t, "echo")
+
+	var streams int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(builderResult{ID: "job-1"})
+			return
+		}
+
+		// the stream ends before the result until the job has finished
+		w.Header().Set("Content-Type", "text/event-stream")
+		if atomic.AddInt32(&streams, 1) > 3 {
+			fmt.Fprint(w, "event: result\ndata: {\"status\":\"success\"}\n\n")
+		}
+	}))
+	defer server.Close()
+
+	backend := newRemoteBackend(BackendOptions{RemoteBuilder: server.URL, Token: "token"})
+	backend.pollInterval = 20 * time.Millisecond
+
+	start := time.Now()
+	if err := backend.Build(context.Background(), BuildContext{FunctionName: "echo", Image: "ttl.sh/echo:latest"}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 3*backend.pollInterval {
+		t.Fatalf("want the poll interval between reconnects, finished in %s", elapsed)
+	}
+}
+
+func Test_remoteBackend_Build_Timeout(t *testing.T) {
+	useBuildContext(t, "echo")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(builderResult{ID: "job-1"})
+			return
+		}
+		json.NewEncoder(w).Encode(builderResult{Status: "running"})
+	}))
+	defer server.Close()
+
+	backend := newRemoteBackend(BackendOptions{RemoteBuilder: server.URL, Token: "token", Timeout: 100 * time.Millisecond})
+	backend.pollInterval = 10 * time.Millisecond
+
+	err := backend.Build(context.Background(), BuildContext{FunctionName: "echo", Image: "ttl.sh/echo:latest"})
+	if err == nil || !strings.Contains(err.Error(), "did not finish within 100ms") {
+		t.Fatalf("want a timeout error, got: %v", err)
+	}
+}
+
+// useBuildContext changes to a temporary folder with a build context for the
+// function under ./build/
+func useBuildContext(t *testing.T, functionName string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeFiles(t, filepath.Join(dir, "build", functionName), map[string]string{
+		"Dockerfile": "FROM scratch\n",
+	})
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
@@ -0,0 +1,51 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	abortCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	abortCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
+	abortCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(abortCmd.Flags())
+	abortCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
+	abortCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
+
+	forgeCmd.AddCommand(abortCmd)
+}
+
+var abortCmd = &cobra.Command{
+	Use:   `abort FUNCTION_NAME`,
+	Short: "Remove the canary of a function",
+	Long: `Removes the canary created by "forge-cli deploy --canary", leaving the stable
+function as it is.`,
+	Example: `  forge-cli abort figlet
+  forge-cli abort figlet --namespace staging`,
+	RunE: runAbort,
+}
+
+func runAbort(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("please provide a name for the function")
+	}
+	functionName := args[0]
+
+	client, err := newHistoryClient()
+	if err != nil {
+		return err
+	}
+
+	if err := abortCanary(context.Background(), client, functionName, getNamespace(functionNamespace, "")); err != nil {
+		return err
+	}
+
+	fmt.Printf("Aborted the canary of %s.\n", functionName)
+	return nil
+}
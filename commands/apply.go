@@ -0,0 +1,432 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/stack"
+	"github.com/openfaas/faas-provider/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyPrune  bool
+	applyDryRun bool
+)
+
+func init() {
+	applyCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	applyCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(applyCmd.Flags())
+	applyCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
+	applyCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
+	applyCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
+	applyCmd.Flags().Var(&tagFormat, "tag", "Override latest tag on function Docker image, accepts 'latest', 'sha', 'branch', or 'describe'")
+	applyCmd.Flags().DurationVar(&timeoutOverride, "timeout", commandTimeout, "Timeout for any HTTP calls made to the Forge4Flow API.")
+
+	applyCmd.Flags().BoolVar(&applyPrune, "prune", false, "Remove functions from the stack's namespaces which are not in the YAML file")
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "Print the plan without making any changes")
+
+	forgeCmd.AddCommand(applyCmd)
+}
+
+// applyCmd makes the functions on the gateway match the stack file
+var applyCmd = &cobra.Command{
+	Use:   `apply -f YAML_FILE [--prune] [--dry-run]`,
+	Short: "Apply a stack file to the gateway",
+	Long: `Compares the functions in the supplied YAML config with the functions deployed
+to the gateway, prints a plan of the functions to create, update and delete, and
+then executes it. Functions which are already up to date are left alone. Each
+function which is created or updated gets a new revision, as with deploy, so
+that "forge-cli history" and "forge-cli rollback" work for it.
+
+With --prune, functions in the namespaces used by the stack file which are not
+defined in the YAML file are removed, making the stack file the single source of
+truth for those namespaces. Canaries are kept, remove them with "forge-cli
+promote" or "forge-cli abort".`,
+	Example: `  forge-cli apply -f ./functions.yml
+  forge-cli apply -f ./functions.yml --dry-run
+  forge-cli apply -f ./functions.yml --prune
+  forge-cli apply -f ./functions.yml --prune --namespace staging --tag sha`,
+	PreRunE: preRunApply,
+	RunE:    runApply,
+}
+
+func preRunApply(cmd *cobra.Command, args []string) error {
+	if len(yamlFile) == 0 {
+		return fmt.Errorf("give a stack file with --yaml/-f to apply")
+	}
+
+	if applyPrune && (len(regex) > 0 || len(filter) > 0) {
+		return fmt.Errorf("--prune cannot be combined with --filter or --regex, as filtered out functions would be removed")
+	}
+
+	return nil
+}
+
+// functionState is the part of a function's configuration which is compared
+// between the stack file and the gateway
+type functionState struct {
+	Image                  string                   `yaml:"image"`
+	Environment            map[string]string        `yaml:"environment,omitempty"`
+	Labels                 map[string]string        `yaml:"labels,omitempty"`
+	Annotations            map[string]string        `yaml:"annotations,omitempty"`
+	Secrets                []string                 `yaml:"secrets,omitempty"`
+	Limits                 *stack.FunctionResources `yaml:"limits,omitempty"`
+	Requests               *stack.FunctionResources `yaml:"requests,omitempty"`
+	ReadOnlyRootFilesystem bool                     `yaml:"readonly_root_filesystem,omitempty"`
+}
+
+// systemLabels are set by the provider on every function, or by forge-cli on
+// a canary, and are not expected to appear in the stack file
+var systemLabels = []string{"faas_function", "uid", canaryLabel}
+
+// systemAnnotationPrefixes are set by the provider, or by forge-cli when it
+// stamps the revision of a deployment or deploys a canary, and are not
+// expected to appear in the stack file
+var systemAnnotationPrefixes = []string{"prometheus.io.", revisionAnnotation, canaryLabel + "."}
+
+func stateFromSpec(spec *proxy.DeployFunctionSpec) functionState {
+	return functionState{
+		Image:                  spec.Image,
+		Environment:            nonEmptyMap(spec.EnvVars),
+		Labels:                 nonEmptyMap(spec.Labels),
+		Annotations:            nonEmptyMap(spec.Annotations),
+		Secrets:                sortedSlice(spec.Secrets),
+		Limits:                 nonEmptyResources(spec.FunctionResourceRequest.Limits),
+		Requests:               nonEmptyResources(spec.FunctionResourceRequest.Requests),
+		ReadOnlyRootFilesystem: spec.ReadOnlyRootFilesystem,
+	}
+}
+
+func stateFromStatus(status types.FunctionStatus) functionState {
+	state := functionState{
+		Image:                  status.Image,
+		Environment:            nonEmptyMap(status.EnvVars),
+		Secrets:                sortedSlice(status.Secrets),
+		ReadOnlyRootFilesystem: status.ReadOnlyRootFilesystem,
+	}
+
+	if status.Labels != nil {
+		labels := map[string]string{}
+		for k, v := range *status.Labels {
+			if !contains(systemLabels, k) {
+				labels[k] = v
+			}
+		}
+		state.Labels = nonEmptyMap(labels)
+	}
+
+	if status.Annotations != nil {
+		annotations := map[string]string{}
+		for k, v := range *status.Annotations {
+			if !hasAnyPrefix(k, systemAnnotationPrefixes) {
+				annotations[k] = v
+			}
+		}
+		state.Annotations = nonEmptyMap(annotations)
+	}
+
+	if status.Limits != nil {
+		state.Limits = nonEmptyResources(&stack.FunctionResources{CPU: status.Limits.CPU, Memory: status.Limits.Memory})
+	}
+	if status.Requests != nil {
+		state.Requests = nonEmptyResources(&stack.FunctionResources{CPU: status.Requests.CPU, Memory: status.Requests.Memory})
+	}
+
+	return state
+}
+
+// changedFields returns the names of the fields which differ between two states
+func (s functionState) changedFields(other functionState) []string {
+	var changed []string
+
+	if s.Image != other.Image {
+		changed = append(changed, "image")
+	}
+	if !reflect.DeepEqual(s.Environment, other.Environment) {
+		changed = append(changed, "environment")
+	}
+	if !reflect.DeepEqual(s.Labels, other.Labels) {
+		changed = append(changed, "labels")
+	}
+	if !reflect.DeepEqual(s.Annotations, other.Annotations) {
+		changed = append(changed, "annotations")
+	}
+	if !reflect.DeepEqual(s.Secrets, other.Secrets) {
+		changed = append(changed, "secrets")
+	}
+	if !reflect.DeepEqual(s.Limits, other.Limits) {
+		changed = append(changed, "limits")
+	}
+	if !reflect.DeepEqual(s.Requests, other.Requests) {
+		changed = append(changed, "requests")
+	}
+	if s.ReadOnlyRootFilesystem != other.ReadOnlyRootFilesystem {
+		changed = append(changed, "readonly_root_filesystem")
+	}
+
+	return changed
+}
+
+// applyAction is a single step of an applyPlan
+type applyAction struct {
+	Name      string
+	Namespace string
+	Spec      *proxy.DeployFunctionSpec
+	Changes   []string
+}
+
+// applyPlan is the set of changes needed to make the gateway match the stack file
+type applyPlan struct {
+	Create    []applyAction
+	Update    []applyAction
+	Delete    []applyAction
+	Unchanged []applyAction
+}
+
+// HasChanges returns true when executing the plan would change the gateway
+func (p applyPlan) HasChanges() bool {
+	return len(p.Create)+len(p.Update)+len(p.Delete) > 0
+}
+
+// computeApplyPlan compares the desired specs with the deployed functions.
+// deployed holds the functions for each namespace used by the specs, keyed by
+// the namespace as given in the stack file.
+func computeApplyPlan(desired []*proxy.DeployFunctionSpec, deployed map[string][]types.FunctionStatus, prune bool) applyPlan {
+	var plan applyPlan
+
+	wanted := map[string]map[string]bool{}
+	for _, spec := range desired {
+		if wanted[spec.Namespace] == nil {
+			wanted[spec.Namespace] = map[string]bool{}
+		}
+		wanted[spec.Namespace][spec.FunctionName] = true
+
+		action := applyAction{
+			Name:      spec.FunctionName,
+			Namespace: spec.Namespace,
+			Spec:      spec,
+		}
+
+		status, found := findFunctionStatus(deployed[spec.Namespace], spec.FunctionName)
+		if !found {
+			plan.Create = append(plan.Create, action)
+			continue
+		}
+
+		action.Changes = stateFromStatus(status).changedFields(stateFromSpec(spec))
+		if len(action.Changes) > 0 {
+			plan.Update = append(plan.Update, action)
+		} else {
+			plan.Unchanged = append(plan.Unchanged, action)
+		}
+	}
+
+	if prune {
+		for namespace, functions := range deployed {
+			for _, function := range functions {
+				// a canary is removed by promote or abort, not by apply
+				if isCanary(function) {
+					continue
+				}
+				if !wanted[namespace][function.Name] {
+					plan.Delete = append(plan.Delete, applyAction{
+						Name:      function.Name,
+						Namespace: namespace,
+					})
+				}
+			}
+		}
+	}
+
+	for _, actions := range [][]applyAction{plan.Create, plan.Update, plan.Delete, plan.Unchanged} {
+		sort.Slice(actions, func(i, j int) bool {
+			return formatFunctionRef(actions[i].Name, actions[i].Namespace) < formatFunctionRef(actions[j].Name, actions[j].Namespace)
+		})
+	}
+
+	return plan
+}
+
+func printApplyPlan(w io.Writer, gatewayURL string, plan applyPlan) {
+	fmt.Fprintf(w, "Plan for %s:\n", gatewayURL)
+
+	for _, action := range plan.Create {
+		fmt.Fprintf(w, "  + %s (create)\n", formatFunctionRef(action.Name, action.Namespace))
+	}
+	for _, action := range plan.Update {
+		fmt.Fprintf(w, "  ~ %s (update: %s)\n", formatFunctionRef(action.Name, action.Namespace), strings.Join(action.Changes, ", "))
+	}
+	for _, action := range plan.Delete {
+		fmt.Fprintf(w, "  - %s (delete)\n", formatFunctionRef(action.Name, action.Namespace))
+	}
+
+	fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to delete, %d unchanged.\n",
+		len(plan.Create), len(plan.Update), len(plan.Delete), len(plan.Unchanged))
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	services, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
+	if err != nil {
+		return err
+	}
+
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, services.Provider.GatewayURL, os.Getenv(openFaaSURLEnvironment))
+
+	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
+	if err != nil {
+		return err
+	}
+	transport := GetDefaultCLITransport(tlsInsecure, &timeoutOverride)
+	proxyClient, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &timeoutOverride)
+	if err != nil {
+		return err
+	}
+	proxyClient.Retry = newRetryPolicy()
+
+	ctx := context.Background()
+
+	applyFlags := DeployFlags{update: true}
+
+	var desired []*proxy.DeployFunctionSpec
+	for k, function := range services.Functions {
+		function.Name = k
+		spec, err := buildDeploySpec(function, applyFlags, tagFormat)
+		if err != nil {
+			return err
+		}
+		desired = append(desired, spec)
+	}
+
+	deployed, err := listDeployedFunctions(ctx, proxyClient, desired)
+	if err != nil {
+		return err
+	}
+
+	plan := computeApplyPlan(desired, deployed, applyPrune)
+	printApplyPlan(os.Stdout, gatewayAddress, plan)
+
+	if applyDryRun || !plan.HasChanges() {
+		return nil
+	}
+
+	if msg := checkTLSInsecure(gatewayAddress, tlsInsecure); len(msg) > 0 {
+		fmt.Println(msg)
+	}
+
+	failedDeploys := map[string]error{}
+	for _, action := range append(plan.Create, plan.Update...) {
+		fmt.Printf("Deploying: %s.\n", action.Name)
+		deployed := stampRevision(ctx, proxyClient, action.Spec)
+		if err := proxyClient.DeployFunction(ctx, action.Spec); err != nil {
+			failedDeploys[action.Name] = err
+			continue
+		}
+
+		if err := recordRevision(proxyClient.GatewayURL, deployed, *action.Spec); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to record revision %d of %s: %s\n", deployed.Revision, action.Name, err)
+		}
+	}
+
+	var applyErrors []error
+	if err := deployFailed(failedDeploys); err != nil {
+		applyErrors = append(applyErrors, err)
+	}
+
+	for _, action := range plan.Delete {
+		fmt.Printf("Deleting: %s.\n", formatFunctionRef(action.Name, action.Namespace))
+		if err := proxyClient.DeleteFunction(ctx, action.Name, action.Namespace); err != nil {
+			applyErrors = append(applyErrors, fmt.Errorf("function '%s' failed to delete: %w", action.Name, err))
+		}
+	}
+
+	return errors.Join(applyErrors...)
+}
+
+// listDeployedFunctions returns the deployed functions in each namespace
+// used by the specs, with the full details of the functions in the specs
+func listDeployedFunctions(ctx context.Context, client *proxy.Client, specs []*proxy.DeployFunctionSpec) (map[string][]types.FunctionStatus, error) {
+	deployed := map[string][]types.FunctionStatus{}
+
+	for _, spec := range specs {
+		if _, ok := deployed[spec.Namespace]; ok {
+			continue
+		}
+
+		functions, err := client.ListFunctions(ctx, spec.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		deployed[spec.Namespace] = functions
+	}
+
+	for _, spec := range specs {
+		functions := deployed[spec.Namespace]
+		for i, function := range functions {
+			if function.Name != spec.FunctionName {
+				continue
+			}
+
+			status, err := client.GetFunctionInfo(ctx, function.Name, spec.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			functions[i] = status
+		}
+	}
+
+	return deployed, nil
+}
+
+func findFunctionStatus(functions []types.FunctionStatus, name string) (types.FunctionStatus, bool) {
+	for _, function := range functions {
+		if function.Name == name {
+			return function, true
+		}
+	}
+	return types.FunctionStatus{}, false
+}
+
+func nonEmptyMap(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+func sortedSlice(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func nonEmptyResources(resources *stack.FunctionResources) *stack.FunctionResources {
+	if resources == nil || (len(resources.CPU) == 0 && len(resources.Memory) == 0) {
+		return nil
+	}
+	return resources
+}
+
+func hasAnyPrefix(value string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/stack"
+	"github.com/forge4flow/forge-cli/test"
+	"github.com/openfaas/faas-provider/types"
+)
+
+func Test_computeApplyPlan(t *testing.T) {
+	desired := []*proxy.DeployFunctionSpec{
+		{FunctionName: "new-fn", Image: "alexellis/new:0.1"},
+		{FunctionName: "same-fn", Image: "alexellis/same:0.1", EnvVars: map[string]string{"A": "1"}},
+		{
+			FunctionName: "changed-fn",
+			Image:        "alexellis/changed:0.2",
+			Secrets:      []string{"b", "a"},
+			FunctionResourceRequest: proxy.FunctionResourceRequest{
+				Limits: &stack.FunctionResources{Memory: "128Mi"},
+			},
+		},
+	}
+
+	deployed := map[string][]types.FunctionStatus{
+		"": {
+			{
+				Name:    "same-fn",
+				Image:   "alexellis/same:0.1",
+				EnvVars: map[string]string{"A": "1"},
+				Labels:  &map[string]string{"faas_function": "same-fn"},
+			},
+			{
+				Name:    "changed-fn",
+				Image:   "alexellis/changed:0.1",
+				Secrets: []string{"a", "b"},
+				Limits:  &types.FunctionResources{Memory: "256Mi"},
+			},
+			{Name: "stale-fn", Image: "alexellis/stale:0.1"},
+			{
+				Name:        "same-fn-canary",
+				Image:       "alexellis/same:0.2",
+				Labels:      &map[string]string{canaryLabel: "true"},
+				Annotations: &map[string]string{canaryOfAnnotation: "same-fn", canaryWeightAnnotation: "10"},
+			},
+		},
+	}
+
+	t.Run("without prune", func(t *testing.T) {
+		plan := computeApplyPlan(desired, deployed, false)
+
+		if got := actionNames(plan.Create); !reflect.DeepEqual(got, []string{"new-fn"}) {
+			t.Errorf("want create [new-fn], got %v", got)
+		}
+		if got := actionNames(plan.Unchanged); !reflect.DeepEqual(got, []string{"same-fn"}) {
+			t.Errorf("want unchanged [same-fn], got %v", got)
+		}
+		if got := actionNames(plan.Update); !reflect.DeepEqual(got, []string{"changed-fn"}) {
+			t.Fatalf("want update [changed-fn], got %v", got)
+		}
+		if got := plan.Update[0].Changes; !reflect.DeepEqual(got, []string{"image", "limits"}) {
+			t.Errorf("want changes [image limits], got %v", got)
+		}
+		if len(plan.Delete) != 0 {
+			t.Errorf("want no deletes without --prune, got %v", actionNames(plan.Delete))
+		}
+	})
+
+	t.Run("with prune", func(t *testing.T) {
+		plan := computeApplyPlan(desired, deployed, true)
+
+		if got := actionNames(plan.Delete); !reflect.DeepEqual(got, []string{"stale-fn"}) {
+			t.Errorf("want delete [stale-fn] without the canary, got %v", got)
+		}
+	})
+}
+
+func Test_stateFromStatus_IgnoresSystemMetadata(t *testing.T) {
+	status := types.FunctionStatus{
+		Name:  "figlet",
+		Image: "alexellis/figlet:0.1",
+		Labels: &map[string]string{
+			"faas_function": "figlet",
+			canaryLabel:     "true",
+			"team":          "a",
+		},
+		Annotations: &map[string]string{
+			"prometheus.io.scrape":    "false",
+			revisionAnnotation:        "3",
+			revisionHistoryAnnotation: "[]",
+			canaryOfAnnotation:        "figlet",
+			canaryWeightAnnotation:    "10",
+			"com.example.owner":       "a",
+		},
+	}
+
+	want := functionState{
+		Image:       "alexellis/figlet:0.1",
+		Labels:      map[string]string{"team": "a"},
+		Annotations: map[string]string{"com.example.owner": "a"},
+	}
+	if got := stateFromStatus(status); !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %+v, got %+v", want, got)
+	}
+}
+
+func Test_printApplyPlan(t *testing.T) {
+	plan := applyPlan{
+		Create:    []applyAction{{Name: "new-fn"}},
+		Update:    []applyAction{{Name: "changed-fn", Namespace: "dev", Changes: []string{"image"}}},
+		Delete:    []applyAction{{Name: "stale-fn"}},
+		Unchanged: []applyAction{{Name: "same-fn"}},
+	}
+
+	var b bytes.Buffer
+	printApplyPlan(&b, "http://127.0.0.1:8080", plan)
+
+	want := `Plan for http://127.0.0.1:8080:
+  + new-fn (create)
+  ~ changed-fn.dev (update: image)
+  - stale-fn (delete)
+
+Plan: 1 to create, 1 to update, 1 to delete, 1 unchanged.
+`
+	if b.String() != want {
+		t.Fatalf("want:\n%s\ngot:\n%s", want, b.String())
+	}
+}
+
+func Test_preRunApply_PruneWithFilter(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	yamlFile = "functions.yml"
+	filter = "fn*"
+	applyPrune = true
+	defer func() { applyPrune = false }()
+
+	err := preRunApply(nil, nil)
+	if err == nil {
+		t.Fatal("want error when combining --prune and --filter")
+	}
+}
+
+func actionNames(actions []applyAction) []string {
+	names := []string{}
+	for _, action := range actions {
+		names = append(names, action.Name)
+	}
+	return names
+}
+
+func Test_runApply_RecordsRevision(t *testing.T) {
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       []types.FunctionStatus{},
+		},
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/function/figlet?usage=1",
+			ResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			Method:             http.MethodPut,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			Method:             http.MethodPost,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusAccepted,
+		},
+	})
+	defer s.Close()
+
+	stackFile := filepath.Join(t.TempDir(), "stack.yml")
+	stackYAML := `version: 1.0
+provider:
+  name: functions4flow
+functions:
+  figlet:
+    lang: dockerfile
+    handler: ./figlet
+    image: alexellis/figlet:0.1
+`
+	if err := os.WriteFile(stackFile, []byte(stackYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	resetForTest()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"apply",
+			"--yaml=" + stackFile,
+			"--gateway=" + s.URL,
+		})
+		err = forgeCmd.Execute()
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s\n%s", err, stdOut)
+	}
+
+	gatewayURL, _ := url.Parse(s.URL)
+	history, err := readLocalHistory(gatewayURL, "", "figlet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Revision != 1 || history[0].Image != "alexellis/figlet:0.1" {
+		t.Fatalf("want apply to record revision 1, got: %+v", history)
+	}
+}
@@ -0,0 +1,560 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/stack"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// defaultBenchRequests is used when neither --requests nor --duration
+	// are given
+	defaultBenchRequests = 200
+
+	// benchHistogramBuckets is the number of bars in the latency histogram
+	benchHistogramBuckets = 10
+
+	// maxBenchRate is the highest --rps, one request per nanosecond
+	maxBenchRate = float64(time.Second)
+)
+
+var (
+	benchRequests    int
+	benchConcurrency int
+	benchRate        float64
+	benchDuration    time.Duration
+	benchTimeout     time.Duration
+	benchData        string
+	benchDataFiles   []string
+	benchTemplate    bool
+	benchAuth        bool
+)
+
+func init() {
+	benchCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	benchCmd.Flags().StringVarP(&functionInvokeNamespace, "namespace", "n", "", "Namespace of the deployed function")
+	benchCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	benchCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
+	benchCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
+	benchCmd.Flags().BoolVar(&benchAuth, "auth", false, "Send the gateway's credentials with each request, for gateways which authenticate invocations")
+
+	benchCmd.Flags().StringVar(&contentType, "content-type", "text/plain", "The content-type HTTP header such as application/json")
+	benchCmd.Flags().StringArrayVar(&query, "query", []string{}, "pass query-string options")
+	benchCmd.Flags().StringArrayVarP(&headers, "header", "H", []string{}, "pass HTTP request header")
+	benchCmd.Flags().StringVarP(&httpMethod, "method", "m", "POST", "pass HTTP request method")
+
+	benchCmd.Flags().IntVarP(&benchRequests, "requests", "r", 0, "Number of requests to send, defaults to 200 without --duration")
+	benchCmd.Flags().IntVarP(&benchConcurrency, "concurrency", "c", 10, "Number of requests to run at the same time")
+	benchCmd.Flags().Float64Var(&benchRate, "rps", 0, "Requests per second across all workers, 0 sends as fast as possible")
+	benchCmd.Flags().DurationVarP(&benchDuration, "duration", "d", 0, "Send requests for this long, e.g. 30s")
+	benchCmd.Flags().DurationVar(&benchTimeout, "timeout", 30*time.Second, "Timeout of each request")
+	benchCmd.Flags().StringVar(&benchData, "data", "", "Body of each request")
+	benchCmd.Flags().StringArrayVar(&benchDataFiles, "data-file", []string{}, "Read the body from a file, give more than once to send the files in turn")
+	benchCmd.Flags().BoolVar(&benchTemplate, "template", false, "Render each body as a Go template with {{.Index}}, {{.Time}} and {{randInt MIN MAX}}")
+	addOutputFlag(benchCmd.Flags())
+
+	forgeCmd.AddCommand(benchCmd)
+}
+
+var benchCmd = &cobra.Command{
+	Use:   `bench FUNCTION_NAME [--requests N | --duration D] [--concurrency C] [--rps R] [--data BODY | --data-file FILE]`,
+	Short: "Load test a function",
+	Long: `Invokes a function many times through the gateway and reports the latency,
+throughput, status codes and errors of the requests.
+
+Requests are routed to the function's namespace, and with --auth they carry the
+credentials saved by "forge-cli login" or given with --token. With --template
+each body is rendered as a Go template, for instance to send unique payloads.`,
+	Example: `  forge-cli bench figlet --data "Hello"
+  forge-cli bench figlet --requests 1000 --concurrency 50
+  forge-cli bench figlet --duration 30s --rps 100 --namespace staging
+  forge-cli bench resize --data-file small.png --data-file large.png
+  forge-cli bench echo --template --data '{"id": {{.Index}}, "n": {{randInt 1 100}}}'
+  forge-cli bench figlet --requests 500 --output json`,
+	RunE: runBench,
+}
+
+// benchOptions describe the requests made by a load test
+type benchOptions struct {
+	requests    int
+	concurrency int
+	rate        float64
+	duration    time.Duration
+	timeout     time.Duration
+	bodies      []benchBody
+	newRequest  func(body []byte) (*http.Request, error)
+}
+
+// benchBody is a payload, which is rendered for each request when it is a
+// template
+type benchBody struct {
+	data     []byte
+	template *template.Template
+}
+
+// benchTemplateData is given to templated bodies
+type benchTemplateData struct {
+	Index int
+	Time  time.Time
+}
+
+// benchReport is the outcome of a load test, latencies are in milliseconds
+type benchReport struct {
+	Function    string         `json:"function"`
+	Requests    int            `json:"requests"`
+	Succeeded   int            `json:"succeeded"`
+	Failed      int            `json:"failed"`
+	Duration    float64        `json:"durationSeconds"`
+	Throughput  float64        `json:"requestsPerSecond"`
+	Latency     benchLatency   `json:"latencyMs"`
+	StatusCodes map[string]int `json:"statusCodes"`
+	Errors      map[string]int `json:"errors,omitempty"`
+	Histogram   []benchBucket  `json:"histogram"`
+}
+
+type benchLatency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// benchBucket counts the responses with a latency up to UpperBound
+type benchBucket struct {
+	UpperBound float64 `json:"upperBoundMs"`
+	Count      int     `json:"count"`
+}
+
+// benchSample is the outcome of one request
+type benchSample struct {
+	latency    time.Duration
+	statusCode int
+	err        error
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("please provide a name for the function")
+	}
+	functionName := args[0]
+
+	if benchConcurrency < 1 {
+		return fmt.Errorf("the --concurrency flag must be greater than 0")
+	}
+	if benchRequests < 0 || benchRate < 0 || benchDuration < 0 {
+		return fmt.Errorf("--requests, --rps and --duration cannot be negative")
+	}
+	// the requests are paced by a ticker, which needs at least 1ns between them
+	if benchRate > maxBenchRate || math.IsNaN(benchRate) {
+		return fmt.Errorf("--rps must be at most %g", maxBenchRate)
+	}
+	if len(benchData) > 0 && len(benchDataFiles) > 0 {
+		return fmt.Errorf("give --data or --data-file, not both")
+	}
+
+	var yamlGateway string
+	if len(yamlFile) > 0 {
+		parsedServices, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
+		if err != nil {
+			return err
+		}
+		if parsedServices != nil {
+			yamlGateway = parsedServices.Provider.GatewayURL
+		}
+	}
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, yamlGateway, os.Getenv(openFaaSURLEnvironment))
+
+	bodies, err := loadBenchBodies(benchData, benchDataFiles, benchTemplate)
+	if err != nil {
+		return err
+	}
+
+	var auth proxy.ClientAuth
+	if benchAuth {
+		if auth, err = proxy.NewCLIAuth(token, gatewayAddress); err != nil {
+			return err
+		}
+	}
+
+	namespace := getNamespace(functionInvokeNamespace, "")
+	opts := benchOptions{
+		requests:    benchRequests,
+		concurrency: benchConcurrency,
+		rate:        benchRate,
+		duration:    benchDuration,
+		timeout:     benchTimeout,
+		bodies:      bodies,
+		newRequest: func(body []byte) (*http.Request, error) {
+			req, err := proxy.NewInvokeRequest(gatewayAddress, functionName, bytes.NewReader(body), contentType, query, headers, false, httpMethod, namespace)
+			if err != nil {
+				return nil, err
+			}
+			if auth != nil {
+				if err := auth.Set(req); err != nil {
+					return nil, err
+				}
+			}
+			return req, nil
+		},
+	}
+	if opts.requests == 0 && opts.duration == 0 {
+		opts.requests = defaultBenchRequests
+	}
+
+	// fail on invalid headers, query strings or methods before starting
+	if _, err := opts.newRequest(nil); err != nil {
+		return err
+	}
+
+	if !structuredOutput() {
+		fmt.Printf("Benchmarking %s with %s.\n", functionName, describeBench(opts))
+	}
+
+	samples, elapsed := runLoad(context.Background(), opts)
+	report := newBenchReport(functionName, samples, elapsed)
+
+	if structuredOutput() {
+		return printOutput(report)
+	}
+
+	fmt.Print(renderBenchReport(report))
+	return nil
+}
+
+func loadBenchBodies(data string, files []string, templated bool) ([]benchBody, error) {
+	payloads := [][]byte{[]byte(data)}
+	if len(files) > 0 {
+		payloads = nil
+		for _, file := range files {
+			payload, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read --data-file: %w", err)
+			}
+			payloads = append(payloads, payload)
+		}
+	}
+
+	bodies := make([]benchBody, 0, len(payloads))
+	for i, payload := range payloads {
+		body := benchBody{data: payload}
+		if templated {
+			t, err := template.New(strconv.Itoa(i)).Funcs(template.FuncMap{
+				"randInt": func(min, max int) int {
+					if max <= min {
+						return min
+					}
+					return min + rand.Intn(max-min)
+				},
+			}).Parse(string(payload))
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse the body template: %w", err)
+			}
+			body.template = t
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies, nil
+}
+
+// render returns the body of the request with the given index
+func (b benchBody) render(index int) ([]byte, error) {
+	if b.template == nil {
+		return b.data, nil
+	}
+
+	var out bytes.Buffer
+	if err := b.template.Execute(&out, benchTemplateData{Index: index, Time: time.Now()}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func describeBench(opts benchOptions) string {
+	var parts []string
+	if opts.requests > 0 {
+		parts = append(parts, fmt.Sprintf("%d requests", opts.requests))
+	}
+	if opts.duration > 0 {
+		parts = append(parts, "for "+opts.duration.String())
+	}
+	parts = append(parts, fmt.Sprintf("%d concurrent", opts.concurrency))
+	if opts.rate > 0 {
+		parts = append(parts, fmt.Sprintf("at %g requests/s", opts.rate))
+	}
+	return strings.Join(parts, " ")
+}
+
+// runLoad sends requests until the number of requests is reached or the
+// duration has elapsed, requests still running at the end of the duration
+// are not counted
+func runLoad(ctx context.Context, opts benchOptions) ([]benchSample, time.Duration) {
+	if opts.duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.duration)
+		defer cancel()
+	}
+
+	client := proxy.MakeHTTPClient(&opts.timeout, tlsInsecure)
+	if transport, ok := client.Transport.(*http.Transport); ok {
+		transport.MaxIdleConnsPerHost = opts.concurrency
+	}
+
+	jobs := make(chan int)
+	results := make(chan benchSample)
+
+	var wg sync.WaitGroup
+	wg.Add(opts.concurrency)
+	for i := 0; i < opts.concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				sample := benchRequest(ctx, &client, opts, index)
+				if ctx.Err() != nil && sample.err != nil {
+					continue
+				}
+				results <- sample
+			}
+		}()
+	}
+
+	start := time.Now()
+	go func() {
+		defer close(jobs)
+
+		var tick <-chan time.Time
+		if opts.rate > 0 {
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.rate))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for index := 0; opts.requests == 0 || index < opts.requests; index++ {
+			if tick != nil && index > 0 {
+				select {
+				case <-tick:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case jobs <- index:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var samples []benchSample
+	for sample := range results {
+		samples = append(samples, sample)
+	}
+
+	return samples, time.Since(start)
+}
+
+func benchRequest(ctx context.Context, client *http.Client, opts benchOptions, index int) benchSample {
+	body, err := opts.bodies[index%len(opts.bodies)].render(index)
+	if err != nil {
+		return benchSample{err: fmt.Errorf("unable to render the body: %w", err)}
+	}
+
+	req, err := opts.newRequest(body)
+	if err != nil {
+		return benchSample{err: err}
+	}
+
+	start := time.Now()
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return benchSample{latency: time.Since(start), err: err}
+	}
+
+	_, err = io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	return benchSample{latency: time.Since(start), statusCode: res.StatusCode, err: err}
+}
+
+func newBenchReport(functionName string, samples []benchSample, elapsed time.Duration) benchReport {
+	report := benchReport{
+		Function:    functionName,
+		Requests:    len(samples),
+		Duration:    elapsed.Seconds(),
+		StatusCodes: map[string]int{},
+		Errors:      map[string]int{},
+		Histogram:   []benchBucket{},
+	}
+
+	var latencies []time.Duration
+	for _, sample := range samples {
+		if sample.err != nil {
+			report.Failed++
+			report.Errors[sample.err.Error()]++
+			continue
+		}
+
+		report.StatusCodes[strconv.Itoa(sample.statusCode)]++
+		latencies = append(latencies, sample.latency)
+		if sample.statusCode >= 200 && sample.statusCode < 300 {
+			report.Succeeded++
+		} else {
+			report.Failed++
+		}
+	}
+
+	if elapsed > 0 {
+		report.Throughput = float64(len(samples)) / elapsed.Seconds()
+	}
+
+	if len(latencies) == 0 {
+		return report
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+
+	report.Latency = benchLatency{
+		Min:  milliseconds(latencies[0]),
+		Mean: milliseconds(total / time.Duration(len(latencies))),
+		P50:  milliseconds(percentile(latencies, 50)),
+		P90:  milliseconds(percentile(latencies, 90)),
+		P99:  milliseconds(percentile(latencies, 99)),
+		Max:  milliseconds(latencies[len(latencies)-1]),
+	}
+	report.Histogram = histogram(latencies, benchHistogramBuckets)
+
+	return report
+}
+
+// percentile uses the nearest-rank method on sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// histogram splits sorted latencies into buckets of the same width between
+// the fastest and slowest response
+func histogram(sorted []time.Duration, buckets int) []benchBucket {
+	min, max := sorted[0], sorted[len(sorted)-1]
+	if min == max {
+		return []benchBucket{{UpperBound: milliseconds(max), Count: len(sorted)}}
+	}
+
+	width := (max - min) / time.Duration(buckets)
+	histogram := make([]benchBucket, buckets)
+	for i := range histogram {
+		histogram[i].UpperBound = milliseconds(min + width*time.Duration(i+1))
+	}
+	histogram[buckets-1].UpperBound = milliseconds(max)
+
+	for _, latency := range sorted {
+		i := int((latency - min) / width)
+		if i >= buckets {
+			i = buckets - 1
+		}
+		histogram[i].Count++
+	}
+	return histogram
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*1000) / 1000
+}
+
+func renderBenchReport(report benchReport) string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 1, ' ', 0)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Summary:")
+	fmt.Fprintf(w, "  Requests:\t%d (%d succeeded, %d failed)\n", report.Requests, report.Succeeded, report.Failed)
+	fmt.Fprintf(w, "  Duration:\t%.2fs\n", report.Duration)
+	fmt.Fprintf(w, "  Throughput:\t%.2f requests/s\n", report.Throughput)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Latency:")
+	fmt.Fprintf(w, "  Min:\t%.3fms\n", report.Latency.Min)
+	fmt.Fprintf(w, "  Mean:\t%.3fms\n", report.Latency.Mean)
+	fmt.Fprintf(w, "  p50:\t%.3fms\n", report.Latency.P50)
+	fmt.Fprintf(w, "  p90:\t%.3fms\n", report.Latency.P90)
+	fmt.Fprintf(w, "  p99:\t%.3fms\n", report.Latency.P99)
+	fmt.Fprintf(w, "  Max:\t%.3fms\n", report.Latency.Max)
+	w.Flush()
+
+	if len(report.Histogram) > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "Histogram:")
+
+		largest := 0
+		for _, bucket := range report.Histogram {
+			if bucket.Count > largest {
+				largest = bucket.Count
+			}
+		}
+		for _, bucket := range report.Histogram {
+			bar := strings.Repeat("■", bucket.Count*40/largest)
+			fmt.Fprintf(&b, "  %10.3fms %-8s %s\n", bucket.UpperBound, fmt.Sprintf("[%d]", bucket.Count), bar)
+		}
+	}
+
+	if len(report.StatusCodes) > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "Status codes:")
+		for _, code := range sortedKeys(report.StatusCodes) {
+			fmt.Fprintf(&b, "  [%s] %d responses\n", code, report.StatusCodes[code])
+		}
+	}
+
+	if len(report.Errors) > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "Errors:")
+		for _, message := range sortedKeys(report.Errors) {
+			fmt.Fprintf(&b, "  [%d] %s\n", report.Errors[message], message)
+		}
+	}
+
+	return b.String()
+}
+
+func sortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
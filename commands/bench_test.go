@@ -0,0 +1,159 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/forge4flow/forge-cli/test"
+)
+
+func Test_bench_JSON(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+
+		if r.URL.Path != "/function/echo.staging" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if strings.HasSuffix(string(body), "3") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(body)
+	}))
+	defer s.Close()
+
+	resetForTest()
+	defer func() { functionInvokeNamespace = "" }()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"bench", "echo",
+			"--gateway=" + s.URL,
+			"--namespace=staging",
+			"--requests=10",
+			"--concurrency=3",
+			"--template",
+			"--data=request {{.Index}}",
+			"--output=json",
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var report benchReport
+	if err := json.Unmarshal([]byte(stdOut), &report); err != nil {
+		t.Fatalf("unable to parse the report: %s\n%s", err, stdOut)
+	}
+
+	if report.Function != "echo" || report.Requests != 10 || report.Succeeded != 9 || report.Failed != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if want := map[string]int{"200": 9, "500": 1}; !reflect.DeepEqual(report.StatusCodes, want) {
+		t.Errorf("status codes, want: %v, got: %v", want, report.StatusCodes)
+	}
+	if report.Latency.P99 < report.Latency.P50 || report.Latency.Max < report.Latency.P99 {
+		t.Errorf("percentiles are not ordered: %+v", report.Latency)
+	}
+
+	count := 0
+	for _, bucket := range report.Histogram {
+		count += bucket.Count
+	}
+	if count != 10 {
+		t.Errorf("want 10 responses in the histogram, got %d", count)
+	}
+
+	if len(bodies) != 10 || !containsString(bodies, "request 0") || !containsString(bodies, "request 9") {
+		t.Errorf("want templated bodies, got: %v", bodies)
+	}
+}
+
+func Test_bench_RateTooHigh(t *testing.T) {
+	resetForTest()
+	defer func() { benchRate = 0 }()
+
+	for _, rate := range []string{"2e9", "+Inf", "NaN"} {
+		forgeCmd.SetArgs([]string{"bench", "echo", "--rps=" + rate})
+		err := forgeCmd.Execute()
+		if err == nil || !strings.Contains(err.Error(), "--rps must be at most") {
+			t.Errorf("want --rps=%s to be rejected, got: %v", rate, err)
+		}
+	}
+}
+
+func Test_runLoad_Duration(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+
+	opts := benchOptions{
+		concurrency: 2,
+		rate:        100,
+		duration:    100 * time.Millisecond,
+		timeout:     time.Second,
+		bodies:      []benchBody{{}},
+		newRequest: func(body []byte) (*http.Request, error) {
+			return http.NewRequest(http.MethodGet, s.URL, nil)
+		},
+	}
+
+	samples, _ := runLoad(context.Background(), opts)
+	if len(samples) == 0 || len(samples) > 12 {
+		t.Fatalf("want about 10 requests at 100 requests/s for 100ms, got %d", len(samples))
+	}
+}
+
+func Test_percentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	for p, want := range map[float64]time.Duration{50: 50 * time.Millisecond, 90: 90 * time.Millisecond, 99: 99 * time.Millisecond, 100: 100 * time.Millisecond} {
+		if got := percentile(latencies, p); got != want {
+			t.Errorf("p%g, want: %s, got: %s", p, want, got)
+		}
+	}
+
+	if got := percentile(latencies[:1], 50); got != time.Millisecond {
+		t.Errorf("p50 of one latency, want: 1ms, got: %s", got)
+	}
+}
+
+func Test_histogram(t *testing.T) {
+	latencies := []time.Duration{10 * time.Millisecond, 12 * time.Millisecond, 19 * time.Millisecond, 20 * time.Millisecond}
+
+	got := histogram(latencies, 2)
+	want := []benchBucket{{UpperBound: 15, Count: 2}, {UpperBound: 20, Count: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want: %+v, got: %+v", want, got)
+	}
+
+	got = histogram(latencies[:1], 2)
+	want = []benchBucket{{UpperBound: 10, Count: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want: %+v, got: %+v", want, got)
+	}
+}
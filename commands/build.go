@@ -4,6 +4,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -37,6 +38,8 @@ var (
 	envsubst         bool
 	quietBuild       bool
 	disableStackPull bool
+	imageBuilder     string
+	forceBuild       bool
 )
 
 func init() {
@@ -56,9 +59,18 @@ func init() {
 	buildCmd.Flags().Var(&tagFormat, "tag", "Override latest tag on function Docker image, accepts 'digest', 'sha', 'branch', or 'describe', or 'latest'")
 	buildCmd.Flags().StringArrayVar(&buildLabels, "build-label", []string{}, "Add a label for Docker image (LABEL=VALUE)")
 	buildCmd.Flags().StringArrayVar(&copyExtra, "copy-extra", []string{}, "Extra paths that will be copied into the function build context")
+	buildCmd.Flags().StringVar(&changedSince, "changed-since", "", "Only include functions affected by files changed in \"git diff REF...HEAD\"")
 	buildCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
 	buildCmd.Flags().BoolVar(&quietBuild, "quiet", false, "Perform a quiet build, without showing output from Docker")
 	buildCmd.Flags().BoolVar(&disableStackPull, "disable-stack-pull", false, "Disables the template configuration in the functions.yml")
+	buildCmd.Flags().StringVar(&remoteBuilder, "remote-builder", "", "URL to the builder")
+	buildCmd.Flags().StringVar(&payloadSecretPath, "payload-secret", "", "Path to payload secret file")
+	buildCmd.Flags().StringVar(&remoteBuilderToken, "remote-builder-token", "", "Bearer token for the builder, defaults to $"+builderTokenEnvironment)
+	buildCmd.Flags().DurationVar(&remoteBuilderTimeout, "remote-builder-timeout", builder.DefaultRemoteBuilderTimeout, "Time allowed for the remote builder to build and push an image")
+	buildCmd.Flags().IntVar(&remoteBuilderRetries, "remote-builder-retries", builder.DefaultRemoteBuilderRetries, "Retries for requests to the remote builder when it can't be reached or is busy")
+	buildCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	buildCmd.Flags().BoolVar(&forceBuild, "force", false, "Build functions which are unchanged since they were last built")
+	buildCmd.Flags().StringVar(&imageBuilder, "builder", "", "Builder backend: "+strings.Join(builder.BackendNames, ", ")+", defaults to build.backend in the YAML file or docker")
 
 	// Set bash-completion.
 	_ = buildCmd.Flags().SetAnnotation("handler", cobra.BashCompSubdirsInDir, []string{})
@@ -80,11 +92,37 @@ var buildCmd = &cobra.Command{
                  [--build-arg KEY=VALUE]
                  [--build-option VALUE]
                  [--copy-extra PATH]
-                 [--tag <sha|branch|describe>]`,
+                 [--tag <sha|branch|describe>]
+                 [--builder <docker|buildx|buildah|podman|kaniko|native|remote>]`,
 	Short: "Builds Forge4Flow function containers",
 	Long: `Builds Forge4Flow function containers either via the supplied YAML config using
 the "--yaml" flag (which may contain multiple function definitions), or directly
-via flags.`,
+via flags.
+
+Images are built with docker unless another builder is given with "--builder"
+or with "backend" under "build" in the YAML file. The buildx, buildah and podman
+builders take the same flags as docker. kaniko runs its executor and writes the
+image to ./build/FUNCTION_NAME.tar, it is meant to be run in kaniko's image. The
+remote builder sends the build to the URL given by "--remote-builder". When the
+builder replies with a job, its log is followed until the build finishes or
+"--remote-builder-timeout" passes. The build context is streamed to the builder
+as a chunked upload, signed with "--payload-secret" in an X-Build-Signature
+trailer holding the HMAC-SHA256 of the body. The secret is required unless a
+bearer token for the builder is read from "--remote-builder-token" or
+$OPENFAAS_BUILDER_TOKEN.
+
+With "--builder native" no Docker daemon is needed. The template's Dockerfile
+must have a single stage which only uses COPY, ENV, LABEL, WORKDIR, USER, EXPOSE,
+CMD, ENTRYPOINT and similar instructions, but not RUN or ADD. Its files are
+appended as a single layer to the base image, which is written as an OCI image
+layout to ./build/FUNCTION_NAME.oci. Files and the image are timestamped with
+SOURCE_DATE_EPOCH, or the Unix epoch, so the same function gives the same image.
+
+A function is only built when its build context has changed since it was last
+built, or when its image is no longer in the local image library, or under
+./build/ for the kaniko and native builders. The hash of its handler, template, extra paths, build args and build
+options is kept in ./build/.forge-cache.json, use "--force" or "--no-cache" to
+build every function.`,
 	Example: `  forge-cli build -f https://domain/path/myfunctions.yml
   forge-cli build -f ./functions.yml --no-cache --build-arg NPM_VERSION=0.2.2
   forge-cli build -f ./functions.yml --build-option dev
@@ -95,7 +133,11 @@ via flags.`,
   forge-cli build -f ./functions.yml --regex "fn[0-9]_.*"
   forge-cli build --image=my_image --lang=python --handler=/path/to/fn/
                  --name=my_fn --squash
-  forge-cli build -f ./functions.yml --build-label org.label-schema.label-name="value"`,
+  forge-cli build -f ./functions.yml --build-label org.label-schema.label-name="value"
+  forge-cli build -f ./functions.yml --builder native
+  forge-cli build -f ./functions.yml --builder podman
+  forge-cli build -f ./functions.yml --force
+  forge-cli build -f ./functions.yml --changed-since origin/main`,
 	PreRunE: preRunBuild,
 	RunE:    runBuild,
 }
@@ -116,9 +158,72 @@ func preRunBuild(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("the --parallel flag must be great than 0")
 	}
 
+	if builderErr := validateImageBuilder(imageBuilder); builderErr != nil {
+		return builderErr
+	}
+
 	return err
 }
 
+// validateImageBuilder checks the --builder flag
+func validateImageBuilder(name string) error {
+	if len(name) == 0 {
+		return nil
+	}
+	for _, backend := range builder.BackendNames {
+		if name == backend {
+			return nil
+		}
+	}
+	return fmt.Errorf("--builder must be one of: %s, not %q", strings.Join(builder.BackendNames, ", "), name)
+}
+
+// imageBackend picks the builder backend from the --builder flag, then
+// --remote-builder, then build.backend in the stack file, or docker
+func imageBackend(services *stack.Services) (string, builder.Backend, error) {
+	name := imageBuilder
+	if len(name) == 0 && len(remoteBuilder) > 0 {
+		name = builder.RemoteBuilder
+	}
+	if len(name) == 0 && services != nil {
+		name = services.Build.Backend
+	}
+	if len(name) == 0 {
+		name = builder.DockerBuilder
+	}
+
+	if len(remoteBuilder) > 0 && name != builder.RemoteBuilder {
+		return "", nil, fmt.Errorf("--remote-builder can not be used with the %s builder", name)
+	}
+
+	backend, err := builder.NewBackend(name, builder.BackendOptions{
+		RemoteBuilder:     remoteBuilder,
+		PayloadSecretPath: payloadSecretPath,
+		Token:             remoteBuilderTokenOrEnv(),
+		Timeout:           remoteBuilderTimeout,
+		Retries:           remoteBuilderRetries,
+		TLSInsecure:       tlsInsecure,
+	})
+	return name, backend, err
+}
+
+// remoteBuilderTokenOrEnv gives the --remote-builder-token flag, or the
+// token from the environment
+func remoteBuilderTokenOrEnv() string {
+	if len(remoteBuilderToken) > 0 {
+		return remoteBuilderToken
+	}
+	return os.Getenv(builderTokenEnvironment)
+}
+
+// saveBuildCache writes the build cache, a cache which can't be written only
+// means that the functions are built again next time
+func saveBuildCache(cache *builder.BuildCache) {
+	if err := cache.Save(builder.BuildCachePath); err != nil {
+		fmt.Printf("Unable to save the build cache to %s: %s\n", builder.BuildCachePath, err)
+	}
+}
+
 func parseBuildArgs(args []string) (map[string]string, error) {
 	mapped := make(map[string]string)
 
@@ -154,7 +259,7 @@ func runBuild(cmd *cobra.Command, args []string) error {
 
 	var services stack.Services
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
 		if err != nil {
 			return err
 		}
@@ -164,6 +269,21 @@ func runBuild(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if done, err := filterChangedSince(&services); err != nil || done {
+		return err
+	}
+
+	backendName, backend, err := imageBackend(&services)
+	if err != nil {
+		return err
+	}
+
+	var cache *builder.BuildCache
+	if !shrinkwrap {
+		cache = builder.LoadBuildCache(builder.BuildCachePath, backendName)
+		defer saveBuildCache(cache)
+	}
+
 	if len(services.StackConfiguration.TemplateConfigs) > 0 && !disableStackPull {
 		newTemplateInfos, err := filterExistingTemplates(services.StackConfiguration.TemplateConfigs, "./template")
 		if err != nil {
@@ -192,29 +312,30 @@ func runBuild(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("please provide the deployed --name of your function")
 		}
 
-		if err := builder.BuildImage(image,
-			handler,
-			functionName,
-			language,
-			nocache,
-			squash,
-			shrinkwrap,
-			buildArgMap,
-			buildOptions,
-			tagFormat,
-			buildLabelMap,
-			quietBuild,
-			copyExtra,
-			remoteBuilder,
-			payloadSecretPath,
-		); err != nil {
+		if err := builder.BuildImage(context.Background(), backend, builder.BuildOptions{
+			Image:          image,
+			Handler:        handler,
+			FunctionName:   functionName,
+			Language:       language,
+			NoCache:        nocache,
+			Squash:         squash,
+			Shrinkwrap:     shrinkwrap,
+			BuildArgMap:    buildArgMap,
+			BuildOptions:   buildOptions,
+			TagFormat:      tagFormat,
+			BuildLabelMap:  buildLabelMap,
+			Quiet:          quietBuild,
+			CopyExtraPaths: copyExtra,
+			Cache:          cache,
+			Force:          forceBuild,
+		}); err != nil {
 			return err
 		}
 
 		return nil
 	}
 
-	errors := build(&services, parallel, shrinkwrap, quietBuild)
+	errors := build(&services, backend, cache, parallel, shrinkwrap, quietBuild)
 	if len(errors) > 0 {
 		errorSummary := "Errors received during build:\n"
 		for _, err := range errors {
@@ -226,9 +347,15 @@ func runBuild(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func build(services *stack.Services, queueDepth int, shrinkwrap, quietBuild bool) []error {
+// build builds the functions of the stack, the cache may be nil to build
+// every function
+func build(services *stack.Services, backend builder.Backend, cache *builder.BuildCache, queueDepth int, shrinkwrap, quietBuild bool) []error {
 	startOuter := time.Now()
 
+	// hooks are given the gateway the functions are deployed to
+	gatewayURL := resolveGatewayURL("", defaultGateway, services.Provider.GatewayURL, os.Getenv(openFaaSURLEnvironment))
+
+	var errorsMu sync.Mutex
 	errors := []error{}
 
 	wg := sync.WaitGroup{}
@@ -248,25 +375,30 @@ func build(services *stack.Services, queueDepth int, shrinkwrap, quietBuild bool
 					combinedBuildOptions := combineBuildOpts(function.BuildOptions, buildOptions)
 					combinedBuildArgMap := util.MergeMap(function.BuildArgs, buildArgMap)
 					combinedExtraPaths := util.MergeSlice(services.StackConfiguration.CopyExtraPaths, copyExtra)
-					err := builder.BuildImage(function.Image,
-						function.Handler,
-						function.Name,
-						function.Language,
-						nocache,
-						squash,
-						shrinkwrap,
-						combinedBuildArgMap,
-						combinedBuildOptions,
-						tagFormat,
-						buildLabelMap,
-						quietBuild,
-						combinedExtraPaths,
-						remoteBuilder,
-						payloadSecretPath,
-					)
+					err := buildWithHooks(function, gatewayURL, func() error {
+						return builder.BuildImage(context.Background(), backend, builder.BuildOptions{
+							Image:          function.Image,
+							Handler:        function.Handler,
+							FunctionName:   function.Name,
+							Language:       function.Language,
+							NoCache:        nocache,
+							Squash:         squash,
+							Shrinkwrap:     shrinkwrap,
+							BuildArgMap:    combinedBuildArgMap,
+							BuildOptions:   combinedBuildOptions,
+							TagFormat:      tagFormat,
+							BuildLabelMap:  buildLabelMap,
+							Quiet:          quietBuild,
+							CopyExtraPaths: combinedExtraPaths,
+							Cache:          cache,
+							Force:          forceBuild,
+						})
+					})
 
 					if err != nil {
+						errorsMu.Lock()
 						errors = append(errors, err)
+						errorsMu.Unlock()
 					}
 				}
 
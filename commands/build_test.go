@@ -5,6 +5,8 @@ package commands
 
 import (
 	"testing"
+
+	"github.com/forge4flow/forge-cli/stack"
 )
 
 func Test_build(t *testing.T) {
@@ -91,3 +93,62 @@ func Test_parseBuildArgs_MultipleSeparators(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_validateImageBuilder(t *testing.T) {
+	for _, name := range []string{"", "docker", "buildx", "buildah", "podman", "kaniko", "native", "remote"} {
+		if err := validateImageBuilder(name); err != nil {
+			t.Errorf("want %q to be valid, got: %s", name, err)
+		}
+	}
+
+	want := `--builder must be one of: docker, buildx, buildah, podman, kaniko, native, remote, not "bazel"`
+	if err := validateImageBuilder("bazel"); err == nil || err.Error() != want {
+		t.Errorf("want error %q, got: %v", want, err)
+	}
+}
+
+func Test_imageBackend(t *testing.T) {
+	defer func() {
+		imageBuilder = ""
+		remoteBuilder = ""
+	}()
+
+	services := &stack.Services{Build: stack.BuildConfiguration{Backend: "podman"}}
+
+	cases := []struct {
+		title         string
+		builder       string
+		remoteBuilder string
+		services      *stack.Services
+		want          string
+		wantErr       string
+	}{
+		{title: "default", want: "docker"},
+		{title: "from the stack file", services: services, want: "podman"},
+		{title: "flag over the stack file", builder: "native", services: services, want: "native"},
+		{title: "remote builder URL", remoteBuilder: "http://127.0.0.1:8081/build", services: services, want: "remote"},
+		{title: "remote builder URL with another builder", builder: "buildah", remoteBuilder: "http://127.0.0.1:8081/build", wantErr: "--remote-builder can not be used with the buildah builder"},
+		{title: "remote builder without a URL", builder: "remote", wantErr: "the remote builder needs the URL of the builder, give --remote-builder"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			imageBuilder = tc.builder
+			remoteBuilder = tc.remoteBuilder
+
+			name, backend, err := imageBackend(tc.services)
+			if len(tc.wantErr) > 0 {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("want error %q, got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name != tc.want || backend == nil {
+				t.Fatalf("want the %s builder, got: %s", tc.want, name)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// maxCallbackBody is the largest callback body which is accepted
+const maxCallbackBody = 64 * 1024 * 1024
+
+// callbackResult is the result of an async invocation, as posted by the
+// queue-worker to the X-Callback-Url
+type callbackResult struct {
+	callID     string
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// callbackReceiver is an ephemeral HTTP server which receives the result of
+// async invocations
+type callbackReceiver struct {
+	listener net.Listener
+	server   *http.Server
+	results  chan callbackResult
+
+	// url is given to the function as its X-Callback-Url
+	url string
+}
+
+// newCallbackReceiver listens on listenAddress. The callback URL is
+// publicURL when given, such as a tunnel to the listener, otherwise it uses
+// the address the gateway is reached from.
+func newCallbackReceiver(listenAddress, publicURL, gatewayAddress string) (*callbackReceiver, error) {
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("unable to listen for the callback: %w", err)
+	}
+
+	callbackURL := publicURL
+	if len(callbackURL) == 0 {
+		host, err := callbackHost(listener.Addr().(*net.TCPAddr), gatewayAddress)
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+		port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+		callbackURL = "http://" + net.JoinHostPort(host, port) + "/"
+	}
+
+	r := &callbackReceiver{
+		listener: listener,
+		results:  make(chan callbackResult, 16),
+		url:      callbackURL,
+	}
+	r.server = &http.Server{Handler: http.HandlerFunc(r.receive)}
+	go r.server.Serve(listener)
+
+	return r, nil
+}
+
+func (r *callbackReceiver) receive(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxCallbackBody))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	statusCode, _ := strconv.Atoi(req.Header.Get("X-Function-Status"))
+	result := callbackResult{
+		callID:     req.Header.Get("X-Call-Id"),
+		statusCode: statusCode,
+		header:     req.Header,
+		body:       body,
+	}
+
+	select {
+	case r.results <- result:
+	default:
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// wait returns the callback for callID, or the first callback when callID is
+// empty
+func (r *callbackReceiver) wait(callID string, timeout time.Duration) (callbackResult, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case result := <-r.results:
+			if len(callID) == 0 || result.callID == callID {
+				return result, nil
+			}
+		case <-timer.C:
+			return callbackResult{}, fmt.Errorf("no callback received on %s within %s", r.url, timeout)
+		}
+	}
+}
+
+func (r *callbackReceiver) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return r.server.Shutdown(ctx)
+}
+
+// callbackHost picks the host of the callback URL. A listener on a specific
+// address uses it, a listener on all addresses uses the local address which
+// routes to the gateway.
+func callbackHost(listenAddr *net.TCPAddr, gatewayAddress string) (string, error) {
+	if !listenAddr.IP.IsUnspecified() {
+		return listenAddr.IP.String(), nil
+	}
+
+	gatewayURL, err := url.Parse(gatewayAddress)
+	if err != nil {
+		return "", fmt.Errorf("invalid gateway URL %s: %w", gatewayAddress, err)
+	}
+
+	port := gatewayURL.Port()
+	if len(port) == 0 {
+		port = "80"
+		if gatewayURL.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	// no packets are sent by dialing UDP, it only picks the local address
+	conn, err := net.Dial("udp", net.JoinHostPort(gatewayURL.Hostname(), port))
+	if err != nil {
+		return "", fmt.Errorf("unable to find the address the gateway can call back on, give --callback-url: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
+}
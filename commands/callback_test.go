@@ -0,0 +1,111 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forge4flow/forge-cli/test"
+)
+
+func Test_invoke_callbackLocal(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/async-function/resize" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		callbackURL := r.Header.Get("X-Callback-Url")
+		go func() {
+			// a callback for another call is ignored
+			for _, callID := range []string{"other", "call-1"} {
+				req, _ := http.NewRequest(http.MethodPost, callbackURL, bytes.NewBufferString("result of "+callID))
+				req.Header.Set("X-Call-Id", callID)
+				req.Header.Set("X-Function-Status", "200")
+				if res, err := http.DefaultClient.Do(req); err == nil {
+					res.Body.Close()
+				}
+			}
+		}()
+
+		w.Header().Set("X-Call-Id", "call-1")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer s.Close()
+
+	os.Stdin, _ = ioutil.TempFile("", "stdin")
+	defer func() {
+		os.Remove(os.Stdin.Name())
+	}()
+
+	defer func() {
+		invokeAsync = false
+		invokeCallbackLocal = false
+		invokeCallbackListen = "0.0.0.0:0"
+	}()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"invoke",
+			"--gateway=" + s.URL,
+			"--async",
+			"--callback-local",
+			"--callback-listen=127.0.0.1:0",
+			"--callback-timeout=5s",
+			"resize",
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stdOut != "result of call-1" {
+		t.Fatalf("want the body of the callback, got: %q", stdOut)
+	}
+}
+
+func Test_callbackReceiver_Timeout(t *testing.T) {
+	receiver, err := newCallbackReceiver("127.0.0.1:0", "", "http://127.0.0.1:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer receiver.Close()
+
+	if !strings.HasPrefix(receiver.url, "http://127.0.0.1:") {
+		t.Fatalf("want a callback URL on the listener's address, got: %s", receiver.url)
+	}
+
+	_, err = receiver.wait("call-1", 10*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "no callback received") {
+		t.Fatalf("want a timeout, got: %v", err)
+	}
+}
+
+func Test_callbackHost(t *testing.T) {
+	host, err := callbackHost(&net.TCPAddr{IP: net.IPv4zero}, "http://127.0.0.1:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != "127.0.0.1" {
+		t.Fatalf("want the address which routes to the gateway, got: %s", host)
+	}
+
+	host, err = callbackHost(&net.TCPAddr{IP: net.ParseIP("10.0.0.5")}, "http://127.0.0.1:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != "10.0.0.5" {
+		t.Fatalf("want the listener's address, got: %s", host)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/openfaas/faas-provider/types"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// canarySuffix is appended to the name of the function for its canary
+	canarySuffix = "-canary"
+
+	// canaryLabel marks a function as the canary of another function
+	canaryLabel = "com.forge4flow.canary"
+
+	// canaryOfAnnotation names the stable function of a canary
+	canaryOfAnnotation = "com.forge4flow.canary.of"
+
+	// canaryWeightAnnotation is the percentage of traffic a router should
+	// send to the canary rather than to the stable function
+	canaryWeightAnnotation = "com.forge4flow.canary.weight"
+)
+
+var (
+	canarySamples      int
+	canaryMaxErrorRate float64
+)
+
+// addCanaryGateFlags adds the flags of the automatic promotion gate
+func addCanaryGateFlags(flagset *pflag.FlagSet) {
+	flagset.IntVar(&canarySamples, "samples", 0, "Invoke the canary this many times before promoting it, 0 promotes without a smoke test")
+	flagset.Float64Var(&canaryMaxErrorRate, "max-error-rate", 5, "Highest percentage of failed smoke test invocations which allows the canary to be promoted")
+}
+
+// parseCanaryWeight parses a percentage such as "10%" or "10", 0% deploys a
+// blue/green candidate which receives no traffic until it is promoted
+func parseCanaryWeight(value string) (int, error) {
+	weight, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+	if err != nil || weight < 0 || weight > 100 {
+		return 0, fmt.Errorf("invalid --canary weight %q, give a percentage between 0%% and 100%%", value)
+	}
+	return weight, nil
+}
+
+func canaryName(functionName string) string {
+	return functionName + canarySuffix
+}
+
+// isCanary is true for a function deployed as the canary of another function
+func isCanary(status types.FunctionStatus) bool {
+	return status.Labels != nil && (*status.Labels)[canaryLabel] == "true"
+}
+
+// applyCanary turns the spec of a function into the spec of its canary
+func applyCanary(spec *proxy.DeployFunctionSpec, weight int) {
+	stable := spec.FunctionName
+	spec.FunctionName = canaryName(stable)
+
+	if spec.Labels == nil {
+		spec.Labels = map[string]string{}
+	}
+	spec.Labels[canaryLabel] = "true"
+
+	if spec.Annotations == nil {
+		spec.Annotations = map[string]string{}
+	}
+	spec.Annotations[canaryOfAnnotation] = stable
+	spec.Annotations[canaryWeightAnnotation] = strconv.Itoa(weight)
+}
+
+// promoteCanary deploys the canary's configuration as the stable function,
+// then removes the canary. The canary's spec is read from the local history
+// when its image matches, otherwise it is re-created from the canary's status.
+func promoteCanary(ctx context.Context, client *proxy.Client, functionName, namespace string) error {
+	canary := canaryName(functionName)
+
+	status, err := client.GetFunctionInfo(ctx, canary, namespace)
+	if errors.Is(err, proxy.ErrNotFound) {
+		return fmt.Errorf("no canary found for %s", functionName)
+	} else if err != nil {
+		return err
+	}
+
+	spec := specFromStatus(status, namespace, status.Image)
+	if history, err := readLocalHistory(client.GatewayURL, namespace, canary); err == nil && len(history) > 0 {
+		latest := history[len(history)-1]
+		if latest.Spec != nil && sameImage(latest.Image, status.Image) {
+			spec = latest.Spec
+		}
+	}
+
+	spec.FunctionName = functionName
+	spec.Namespace = getNamespace(namespace, status.Namespace)
+	spec.Token = token
+	spec.TLSInsecure = tlsInsecure
+	spec.Update = true
+	spec.Replace = false
+	delete(spec.Labels, canaryLabel)
+	delete(spec.Annotations, canaryOfAnnotation)
+	delete(spec.Annotations, canaryWeightAnnotation)
+
+	fmt.Printf("Promoting %s (%s) to %s.\n", canary, status.Image, functionName)
+
+	deployed := stampRevision(ctx, client, spec)
+	if err := client.DeployFunction(ctx, spec); err != nil {
+		return fmt.Errorf("function '%s' failed to deploy: %w", functionName, err)
+	}
+
+	if err := recordRevision(client.GatewayURL, deployed, *spec); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to record revision %d of %s: %s\n", deployed.Revision, functionName, err)
+	}
+
+	return client.DeleteFunction(ctx, canary, namespace)
+}
+
+// abortCanary removes the canary of a function, the stable function is left
+// as it is
+func abortCanary(ctx context.Context, client *proxy.Client, functionName, namespace string) error {
+	canary := canaryName(functionName)
+
+	err := client.DeleteFunction(ctx, canary, namespace)
+	if errors.Is(err, proxy.ErrNotFound) {
+		return fmt.Errorf("no canary found for %s", functionName)
+	}
+	return err
+}
+
+// canaryGate invokes the canary the given number of times and fails when the
+// percentage of failed invocations is above maxErrorRate
+func canaryGate(gatewayAddress, functionName, namespace string, samples int, maxErrorRate float64) error {
+	if samples <= 0 {
+		return nil
+	}
+
+	canary := canaryName(functionName)
+	fmt.Printf("Smoke testing %s with %d invocations.\n", canary, samples)
+
+	var failed int
+	var lastErr error
+	for i := 0; i < samples; i++ {
+		if _, err := proxy.InvokeFunction(gatewayAddress, canary, nil, io.Discard, "text/plain", nil, nil, false, http.MethodPost, tlsInsecure, namespace); err != nil {
+			failed++
+			lastErr = err
+		}
+	}
+
+	errorRate := float64(failed) * 100 / float64(samples)
+	fmt.Printf("%s: %d/%d invocations failed, error rate %.1f%% (max %.1f%%).\n", canary, failed, samples, errorRate, maxErrorRate)
+
+	if errorRate > maxErrorRate {
+		return fmt.Errorf("canary %s failed the promotion gate with an error rate of %.1f%%, last error: %w", canary, errorRate, lastErr)
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/test"
+	"github.com/openfaas/faas-provider/types"
+)
+
+func Test_parseCanaryWeight(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "10%", want: 10},
+		{value: "25", want: 25},
+		{value: "0%", want: 0},
+		{value: "100%", want: 100},
+		{value: "101%", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "ten", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := parseCanaryWeight(tc.value)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: want an error", tc.value)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("%s: want %d, got %d, error: %v", tc.value, tc.want, got, err)
+		}
+	}
+}
+
+func Test_applyCanary(t *testing.T) {
+	spec := &proxy.DeployFunctionSpec{
+		FunctionName: "indexer",
+		Labels:       map[string]string{"app": "indexer"},
+	}
+
+	applyCanary(spec, 10)
+
+	if spec.FunctionName != "indexer-canary" {
+		t.Errorf("want name indexer-canary, got %s", spec.FunctionName)
+	}
+	if spec.Labels[canaryLabel] != "true" || spec.Labels["app"] != "indexer" {
+		t.Errorf("want the canary label added, got: %v", spec.Labels)
+	}
+	if spec.Annotations[canaryOfAnnotation] != "indexer" || spec.Annotations[canaryWeightAnnotation] != "10" {
+		t.Errorf("want the routing annotations, got: %v", spec.Annotations)
+	}
+}
+
+func Test_deploy_canary(t *testing.T) {
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+	defer func() { deployFlags.canary = "" }()
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/function/indexer-canary?usage=1",
+			ResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			Method:             http.MethodPut,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			Method:             http.MethodPost,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusAccepted,
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"deploy",
+			"--gateway=" + s.URL,
+			"--image=indexer:0.2",
+			"--name=indexer",
+			"--canary=10%",
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := "Deploying canary: indexer-canary for 10% of traffic."
+	if !strings.Contains(stdOut, want) {
+		t.Fatalf("want %q in output:\n%s", want, stdOut)
+	}
+}
+
+func Test_promote(t *testing.T) {
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+
+	labels := map[string]string{canaryLabel: "true"}
+	annotations := map[string]string{canaryOfAnnotation: "indexer", canaryWeightAnnotation: "10"}
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:       http.MethodGet,
+			Uri:          "/system/function/indexer-canary?usage=1",
+			ResponseBody: types.FunctionStatus{Name: "indexer-canary", Image: "indexer:0.2", Labels: &labels, Annotations: &annotations},
+		},
+		{
+			Method:       http.MethodGet,
+			Uri:          "/system/function/indexer?usage=1",
+			ResponseBody: types.FunctionStatus{Name: "indexer", Image: "indexer:0.1"},
+		},
+		{
+			Method:             http.MethodPut,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusAccepted,
+		},
+		{
+			Method:             http.MethodDelete,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusAccepted,
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"promote", "indexer",
+			"--gateway=" + s.URL,
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(stdOut, "Promoted indexer.") {
+		t.Fatalf("want the function to be promoted:\n%s", stdOut)
+	}
+}
+
+func Test_promote_gateFails(t *testing.T) {
+	defer func() { canarySamples = 0 }()
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodPost,
+			Uri:                "/function/indexer-canary",
+			ResponseStatusCode: http.StatusOK,
+		},
+		{
+			Method:             http.MethodPost,
+			Uri:                "/function/indexer-canary",
+			ResponseStatusCode: http.StatusInternalServerError,
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+
+	var err error
+	test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"promote", "indexer",
+			"--gateway=" + s.URL,
+			"--samples=2",
+			"--max-error-rate=10",
+		})
+		err = forgeCmd.Execute()
+	})
+
+	want := "canary indexer-canary failed the promotion gate with an error rate of 50.0%"
+	if err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Fatalf("want error starting with %q, got %v", want, err)
+	}
+}
+
+func Test_abort(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodDelete,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusNotFound,
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+
+	var err error
+	test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"abort", "indexer",
+			"--gateway=" + s.URL,
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err == nil || err.Error() != "no canary found for indexer" {
+		t.Fatalf("want no canary found error, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/forge4flow/forge-cli/stack"
+	"github.com/forge4flow/forge-cli/util"
+	"github.com/forge4flow/forge-cli/versioncontrol"
+)
+
+// changedSince is the Git reference given to --changed-since
+var changedSince string
+
+// gitChangedFiles lists the files changed since a Git reference, it is a
+// variable so that tests do not need a Git repository
+var gitChangedFiles = versioncontrol.GetGitChangedFiles
+
+// filterChangedSince keeps the functions of the stack which are affected by
+// the files changed since --changed-since. done is true when no function was
+// affected, so there is nothing for the command to do.
+func filterChangedSince(services *stack.Services) (done bool, err error) {
+	if len(changedSince) == 0 || len(services.Functions) == 0 {
+		return false, nil
+	}
+
+	changed, err := gitChangedFiles(changedSince)
+	if err != nil {
+		return false, fmt.Errorf("unable to find the files changed since %s: %w", changedSince, err)
+	}
+
+	// the copy paths and the YAML files are used by every function
+	shared := util.MergeSlice(services.StackConfiguration.CopyExtraPaths, copyExtra)
+	for _, file := range yamlFiles() {
+		if len(file) > 0 && !strings.HasPrefix(file, "http://") && !strings.HasPrefix(file, "https://") {
+			shared = append(shared, file)
+		}
+	}
+
+	if err := stack.FilterChanged(services, changed, shared); err != nil {
+		return false, err
+	}
+
+	if len(services.Functions) == 0 {
+		fmt.Printf("No functions have changed since %s.\n", changedSince)
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/forge4flow/forge-cli/stack"
+)
+
+func Test_filterChangedSince(t *testing.T) {
+	defer func(files func(string) ([]string, error)) {
+		gitChangedFiles = files
+		changedSince = ""
+		yamlFile = ""
+	}(gitChangedFiles)
+
+	changed := []string{}
+	gitChangedFiles = func(ref string) ([]string, error) {
+		if ref != "origin/main" {
+			return nil, fmt.Errorf("unexpected ref: %s", ref)
+		}
+		return changed, nil
+	}
+
+	newServices := func() *stack.Services {
+		return &stack.Services{
+			StackConfiguration: stack.StackConfiguration{CopyExtraPaths: []string{"common"}},
+			Functions: map[string]stack.Function{
+				"echo":   {Handler: "./echo", Language: "go"},
+				"resize": {Handler: "./resize", Language: "go"},
+			},
+		}
+	}
+
+	services := newServices()
+	if done, err := filterChangedSince(services); err != nil || done || len(services.Functions) != 2 {
+		t.Fatalf("want every function without --changed-since, got: %v %v %v", done, err, services.Functions)
+	}
+
+	changedSince = "origin/main"
+	yamlFile = "stack.yml"
+
+	changed = []string{"echo/handler.go"}
+	services = newServices()
+	if done, err := filterChangedSince(services); err != nil || done {
+		t.Fatalf("want functions to be left, got: %v %v", done, err)
+	}
+	if _, ok := services.Functions["echo"]; !ok || len(services.Functions) != 1 {
+		t.Fatalf("want only echo, got: %v", services.Functions)
+	}
+
+	changed = []string{"common/util.go"}
+	services = newServices()
+	if _, err := filterChangedSince(services); err != nil || len(services.Functions) != 2 {
+		t.Fatalf("want every function for a change to a copy path, got: %v %v", err, services.Functions)
+	}
+
+	changed = []string{"stack.yml"}
+	services = newServices()
+	if _, err := filterChangedSince(services); err != nil || len(services.Functions) != 2 {
+		t.Fatalf("want every function for a change to the YAML file, got: %v %v", err, services.Functions)
+	}
+
+	changed = []string{"README.md"}
+	services = newServices()
+	if done, err := filterChangedSince(services); err != nil || !done {
+		t.Fatalf("want nothing to do when no function changed, got: %v %v", done, err)
+	}
+}
@@ -5,6 +5,8 @@ package commands
 
 import (
 	"testing"
+
+	"github.com/forge4flow/forge-cli/config"
 )
 
 // Test_getGatewayURL tests for priority of URL for gateway over several sources
@@ -84,3 +86,75 @@ func Test_getGatewayURL(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_getGatewayURL_CurrentContext(t *testing.T) {
+	defaultValue := "http://127.0.0.1:8080"
+
+	lookup := lookupCurrentContext
+	defer func() { lookupCurrentContext = lookup }()
+	lookupCurrentContext = func() *config.Context {
+		return &config.Context{Name: "staging", Gateway: "https://staging.example.com"}
+	}
+
+	if got := getGatewayURL(defaultValue, defaultValue, "", ""); got != "https://staging.example.com" {
+		t.Errorf("want context gateway when nothing else is set, got %s", got)
+	}
+
+	if got := getGatewayURL(defaultValue, defaultValue, "", "http://env:8080"); got != "http://env:8080" {
+		t.Errorf("want environment gateway over context, got %s", got)
+	}
+
+	if got := getGatewayURL(defaultValue, defaultValue, "http://remote-yml:8080", ""); got != "http://remote-yml:8080" {
+		t.Errorf("want YAML gateway over context, got %s", got)
+	}
+
+	if got := getGatewayURL("http://remote-arg:8080", defaultValue, "", ""); got != "http://remote-arg:8080" {
+		t.Errorf("want argument gateway over context, got %s", got)
+	}
+}
+
+func Test_getGatewayURL_ContextTLS(t *testing.T) {
+	defaultValue := "http://127.0.0.1:8080"
+
+	lookup := lookupCurrentContext
+	defer func() { lookupCurrentContext = lookup }()
+	lookupCurrentContext = func() *config.Context {
+		return &config.Context{Name: "dev", Gateway: "https://dev.example.com/", TLSInsecure: true}
+	}
+
+	defer func() {
+		contextTLS = false
+		tlsInsecure = false
+	}()
+
+	cases := []struct {
+		name        string
+		yamlURL     string
+		envURL      string
+		tlsInsecure bool
+	}{
+		{name: "context gateway", tlsInsecure: true},
+		{name: "YAML gateway differs", yamlURL: "https://prod.example.com"},
+		{name: "environment gateway differs", envURL: "https://prod.example.com"},
+		{name: "YAML gateway is the context's", yamlURL: "https://DEV.example.com", tlsInsecure: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			contextTLS = true
+			tlsInsecure = false
+
+			getGatewayURL(defaultValue, defaultValue, c.yamlURL, c.envURL)
+			if tlsInsecure != c.tlsInsecure {
+				t.Errorf("want tlsInsecure %v, got %v", c.tlsInsecure, tlsInsecure)
+			}
+		})
+	}
+
+	contextTLS = false
+	tlsInsecure = false
+	getGatewayURL(defaultValue, defaultValue, "", "")
+	if tlsInsecure {
+		t.Errorf("want the context's TLS setting ignored when the command was given --tls-no-verify")
+	}
+}
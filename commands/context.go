@@ -0,0 +1,49 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	forgeCmd.AddCommand(contextCmd)
+}
+
+var contextCmd = &cobra.Command{
+	Use:     `context`,
+	Aliases: []string{"ctx"},
+	Short:   "Manage named gateway contexts",
+	Long: `Manage named contexts, each holding a gateway URL, a default namespace, the
+credentials to use and TLS settings.
+
+The gateway and namespace of the current context are used when none is given via
+flags, the stack file or the OPENFAAS_URL environment variable. The TLS setting
+of the current context only applies to the context's own gateway.`,
+}
+
+// contextTLS is set when the command has a --tls-no-verify flag which was
+// not given, the TLS setting of the current context may then apply
+var contextTLS bool
+
+// applyContextTLS lets the TLS setting of the current context apply, unless
+// the command was given a --tls-no-verify flag of its own
+func applyContextTLS(cmd *cobra.Command, args []string) {
+	tlsFlag := cmd.Flags().Lookup("tls-no-verify")
+	contextTLS = tlsFlag != nil && !tlsFlag.Changed
+}
+
+// useContextTLS turns off TLS verification when the context was set with
+// --tls-no-verify and gatewayURL is the context's gateway. A gateway from a
+// flag, the stack file or OPENFAAS_URL which differs is still verified.
+func useContextTLS(gatewayURL string) {
+	if !contextTLS {
+		return
+	}
+
+	current := lookupCurrentContext()
+	if current != nil && current.TLSInsecure && gatewayURL == normalizeGatewayURL(current.Gateway) {
+		tlsInsecure = true
+	}
+}
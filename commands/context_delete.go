@@ -0,0 +1,34 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/spf13/cobra"
+)
+
+var contextDeleteCmd = &cobra.Command{
+	Use:     `delete NAME`,
+	Aliases: []string{"rm", "remove"},
+	Short:   "Delete a context",
+	Long:    "Delete a context, if it is the current context no context will be in use afterwards",
+	Example: `  forge-cli context delete staging`,
+	PreRunE: preRunContextName,
+	RunE:    runContextDelete,
+}
+
+func init() {
+	contextCmd.AddCommand(contextDeleteCmd)
+}
+
+func runContextDelete(cmd *cobra.Command, args []string) error {
+	if err := config.RemoveContext(args[0]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Context %q deleted.\n", args[0])
+	return nil
+}
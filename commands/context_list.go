@@ -0,0 +1,55 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/spf13/cobra"
+)
+
+var contextListCmd = &cobra.Command{
+	Use:     `list`,
+	Aliases: []string{"ls"},
+	Short:   "List contexts",
+	Long:    "List the saved contexts, the current context is marked with *",
+	Example: `  forge-cli context list`,
+	RunE:    runContextList,
+}
+
+func init() {
+	contextCmd.AddCommand(contextListCmd)
+}
+
+func runContextList(cmd *cobra.Command, args []string) error {
+	contexts, current, err := config.ListContexts()
+	if err != nil {
+		return err
+	}
+
+	if len(contexts) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No contexts found, create one with \"forge-cli context set\".")
+		return nil
+	}
+
+	printContexts(cmd.OutOrStdout(), contexts, current)
+	return nil
+}
+
+func printContexts(dst io.Writer, contexts []config.Context, current string) {
+	w := tabwriter.NewWriter(dst, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "CURRENT\tNAME\tGATEWAY\tNAMESPACE")
+	for _, c := range contexts {
+		marker := ""
+		if c.Name == current {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", marker, c.Name, c.Gateway, c.Namespace)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/forge4flow/forge-cli/config"
+)
+
+func Test_printContexts(t *testing.T) {
+	contexts := []config.Context{
+		{Name: "dev", Gateway: "http://127.0.0.1:8080"},
+		{Name: "prod", Gateway: "https://forge.example.com", Namespace: "prod-fn"},
+	}
+
+	var b bytes.Buffer
+	printContexts(&b, contexts, "prod")
+
+	want := `CURRENT  NAME  GATEWAY                    NAMESPACE
+         dev   http://127.0.0.1:8080      
+*        prod  https://forge.example.com  prod-fn
+`
+	if b.String() != want {
+		t.Fatalf("want:\n%q\ngot:\n%q", want, b.String())
+	}
+}
+
+func Test_preRunContextName(t *testing.T) {
+	if err := preRunContextName(nil, []string{}); err == nil || err.Error() != "context name required" {
+		t.Errorf("want context name required error, got %v", err)
+	}
+
+	if err := preRunContextName(nil, []string{"a", "b"}); err == nil || err.Error() != "too many values for context name" {
+		t.Errorf("want too many values error, got %v", err)
+	}
+
+	if err := preRunContextName(nil, []string{"dev"}); err != nil {
+		t.Errorf("want no error, got %s", err)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	contextGateway     string
+	contextNamespace   string
+	contextAuthGateway string
+	contextTLSInsecure bool
+	contextUse         bool
+)
+
+var contextSetCmd = &cobra.Command{
+	Use:   `set NAME --gateway GATEWAY_URL [--namespace NAMESPACE] [--auth GATEWAY_URL] [--tls-no-verify] [--use]`,
+	Short: "Create or update a context",
+	Long: `Create or update a named context.
+
+Credentials are looked up by gateway URL as saved by "forge-cli login", use --auth
+when the credentials were saved for a different URL to the one in --gateway.`,
+	Example: `  forge-cli context set dev --gateway http://127.0.0.1:8080 --use
+  forge-cli context set staging --gateway https://staging.example.com --namespace staging-fn
+  forge-cli context set prod --gateway https://forge.example.com --namespace prod-fn`,
+	PreRunE: preRunContextSet,
+	RunE:    runContextSet,
+}
+
+func init() {
+	contextSetCmd.Flags().StringVarP(&contextGateway, "gateway", "g", "", "Gateway URL starting with http(s)://")
+	contextSetCmd.Flags().StringVarP(&contextNamespace, "namespace", "n", "", "Default namespace for functions")
+	contextSetCmd.Flags().StringVar(&contextAuthGateway, "auth", "", "Gateway URL of the saved credentials to use")
+	contextSetCmd.Flags().BoolVar(&contextTLSInsecure, "tls-no-verify", false, "Disable TLS validation")
+	contextSetCmd.Flags().BoolVar(&contextUse, "use", false, "Switch to the context after saving it")
+
+	contextCmd.AddCommand(contextSetCmd)
+}
+
+func preRunContextSet(cmd *cobra.Command, args []string) error {
+	if err := preRunContextName(cmd, args); err != nil {
+		return err
+	}
+
+	if len(contextGateway) == 0 {
+		return fmt.Errorf("must provide --gateway")
+	}
+
+	return nil
+}
+
+func runContextSet(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	gatewayURL := strings.ToLower(strings.TrimRight(contextGateway, "/"))
+	if !strings.HasPrefix(gatewayURL, "http") {
+		gatewayURL = fmt.Sprintf("http://%s", gatewayURL)
+	}
+
+	authGateway := strings.ToLower(strings.TrimRight(contextAuthGateway, "/"))
+
+	c := config.Context{
+		Name:        name,
+		Gateway:     gatewayURL,
+		Namespace:   contextNamespace,
+		Auth:        authGateway,
+		TLSInsecure: contextTLSInsecure,
+	}
+
+	if err := config.UpdateContext(c); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Context %q saved.\n", name)
+
+	if contextUse {
+		if err := config.UseContext(name); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Switched to context %q.\n", name)
+	}
+
+	return nil
+}
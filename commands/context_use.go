@@ -0,0 +1,45 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/spf13/cobra"
+)
+
+var contextUseCmd = &cobra.Command{
+	Use:     `use NAME`,
+	Short:   "Switch the current context",
+	Long:    "Switch the current context used for the default gateway and namespace",
+	Example: `  forge-cli context use staging`,
+	PreRunE: preRunContextName,
+	RunE:    runContextUse,
+}
+
+func init() {
+	contextCmd.AddCommand(contextUseCmd)
+}
+
+func preRunContextName(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("context name required")
+	}
+
+	if len(args) > 1 {
+		return fmt.Errorf("too many values for context name")
+	}
+
+	return nil
+}
+
+func runContextUse(cmd *cobra.Command, args []string) error {
+	if err := config.UseContext(args[0]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Switched to context %q.\n", args[0])
+	return nil
+}
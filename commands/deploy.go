@@ -5,8 +5,8 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -37,6 +37,12 @@ type DeployFlags struct {
 	secrets                []string
 	labelOpts              []string
 	annotationOpts         []string
+	wait                   bool
+	waitTimeout            time.Duration
+	canary                 string
+	autoPromote            bool
+	rollbackOnFailure      bool
+	skipUnchanged          bool
 }
 
 var deployFlags DeployFlags
@@ -69,6 +75,15 @@ func init() {
 	deployCmd.Flags().Var(&tagFormat, "tag", "Override latest tag on function Docker image, accepts 'latest', 'sha', 'branch', or 'describe'")
 
 	deployCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(deployCmd.Flags())
+	addWaitFlags(deployCmd.Flags(), &deployFlags)
+	deployCmd.Flags().StringVar(&deployFlags.canary, "canary", "", "Deploy FUNCTION-canary next to the function for this percentage of traffic, e.g. 10%")
+	deployCmd.Flags().BoolVar(&deployFlags.autoPromote, "auto-promote", false, "Wait for the canary, smoke test it with --samples and promote it, or remove it when it fails")
+	addCanaryGateFlags(deployCmd.Flags())
+	deployCmd.Flags().BoolVar(&deployFlags.rollbackOnFailure, "rollback-on-failure", false, "Roll a function back to its previous revision when it does not become ready, or its post_deploy hook or smoke test fails")
+	deployCmd.Flags().BoolVar(&deployFlags.skipUnchanged, "skip-unchanged", false, "Skip functions deployed with the same image and settings by the last deploy from this folder")
+	deployCmd.Flags().IntVar(&parallel, "parallel", 1, "Deploy in parallel to depth specified, a function waits for the functions in its depends_on")
+	deployCmd.Flags().StringVar(&changedSince, "changed-since", "", "Only include functions affected by files changed in \"git diff REF...HEAD\"")
 	deployCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
 	deployCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
 	// Set bash-completion.
@@ -106,12 +121,35 @@ var deployCmd = &cobra.Command{
 				  [--secret "SECRET_NAME"]
 				  [--tag <sha|branch|describe>]
 				  [--readonly=false]
+				  [--wait]
+				  [--parallel PARALLEL_DEPTH]
+				  [--rollback-on-failure]
+				  [--skip-unchanged]
+				  [--canary PERCENT [--auto-promote]]
 				  [--tls-no-verify]`,
 
 	Short: "Deploy Forge4Flow functions",
 	Long: `Deploys Forge4Flow function containers either via the supplied YAML config using
 the "--yaml" flag (which may contain multiple function definitions), or directly
-via flags. Note: --replace and --update are mutually exclusive.`,
+via flags. Note: --replace and --update are mutually exclusive.
+
+Functions in a YAML file are deployed after the functions listed in their
+"depends_on", functions which don't depend on each other are deployed up to
+--parallel at a time. With --wait, a function must be ready before the
+functions which depend on it are deployed. When more than one function is
+deployed at a time, each line of output starts with the function's name.
+
+The "hooks" of a function in the YAML file are run with its environment before
+and after it is deployed, followed by its "smoke_test". The hooks also get
+FUNCTION_NAME, FUNCTION_IMAGE and FUNCTION_NAMESPACE as deployed, and the gateway
+as OPENFAAS_URL. A failing hook or smoke test fails the function, and with
+--rollback-on-failure the function is rolled back to its previous revision.
+
+With --skip-unchanged, a function of the YAML file is skipped when the last
+deploy from this folder gave the gateway the same image and settings, as
+recorded in ./build/.forge-cache.json. A build of the image since then deploys
+it again, an image rebuilt elsewhere with the same tag needs a deploy without
+--skip-unchanged.`,
 	Example: `  forge-cli deploy -f https://domain/path/myfunctions.yml
   forge-cli deploy -f ./functions.yml
   forge-cli deploy -f ./functions.yml --label canary=true
@@ -123,6 +161,12 @@ via flags. Note: --replace and --update are mutually exclusive.`,
   forge-cli deploy -f ./functions.yml --tag sha
   forge-cli deploy -f ./functions.yml --tag branch
   forge-cli deploy -f ./functions.yml --tag describe
+  forge-cli deploy -f ./functions.yml --wait --wait-timeout 5m
+  forge-cli deploy -f ./functions.yml --parallel 4 --wait
+  forge-cli deploy -f ./functions.yml --rollback-on-failure
+  forge-cli deploy -f ./functions.yml --skip-unchanged
+  forge-cli deploy -f ./functions.yml --canary 10%
+  forge-cli deploy -f ./functions.yml --canary 10% --auto-promote --samples 20
   forge-cli deploy --image=alexellis/faas-url-ping --name=url-ping
   forge-cli deploy --image=my_image --name=my_fn --handler=/path/to/fn/
                   --gateway=http://remote-site.com:8080 --lang=python
@@ -150,9 +194,20 @@ func runDeployCommand(args []string, image string, fprocess string, functionName
 		return fmt.Errorf("cannot specify --update and --replace at the same time")
 	}
 
+	var canaryWeight int
+	if len(deployFlags.canary) > 0 {
+		weight, err := parseCanaryWeight(deployFlags.canary)
+		if err != nil {
+			return err
+		}
+		canaryWeight = weight
+	} else if deployFlags.autoPromote {
+		return fmt.Errorf("--auto-promote can only be used with --canary")
+	}
+
 	var services stack.Services
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
 		if err != nil {
 			return err
 		}
@@ -163,10 +218,18 @@ func runDeployCommand(args []string, image string, fprocess string, functionName
 		}
 	}
 
+	if done, err := filterChangedSince(&services); err != nil || done {
+		return err
+	}
+
 	transport := GetDefaultCLITransport(tlsInsecure, &timeoutOverride)
 	ctx := context.Background()
 
-	var failedStatusCodes = make(map[string]int)
+	var failedDeploys = make(map[string]error)
+	var proxyClient *proxy.Client
+	var rollouts []rolloutTarget
+	var stackErrors []error
+	var waited bool
 	if len(services.Functions) > 0 {
 
 		cliAuth, err := proxy.NewCLIAuth(token, services.Provider.GatewayURL)
@@ -174,123 +237,50 @@ func runDeployCommand(args []string, image string, fprocess string, functionName
 			return err
 		}
 
-		proxyClient, err := proxy.NewClient(cliAuth, services.Provider.GatewayURL, transport, &timeoutOverride)
+		proxyClient, err = proxy.NewClient(cliAuth, services.Provider.GatewayURL, transport, &timeoutOverride)
 		if err != nil {
 			return err
 		}
+		proxyClient.Retry = newRetryPolicy()
 
-		for k, function := range services.Functions {
-
-			functionSecrets := deployFlags.secrets
-
-			function.Name = k
-			fmt.Printf("Deploying: %s.\n", function.Name)
-
-			var functionConstraints []string
-			if function.Constraints != nil {
-				functionConstraints = *function.Constraints
-			} else if len(deployFlags.constraints) > 0 {
-				functionConstraints = deployFlags.constraints
-			}
-
-			if len(function.Secrets) > 0 {
-				functionSecrets = util.MergeSlice(function.Secrets, functionSecrets)
-			}
-
-			// Check if there is a functionNamespace flag passed, if so, override the namespace value
-			// defined in the stack.yaml
-			function.Namespace = getNamespace(functionNamespace, function.Namespace)
-
-			fileEnvironment, err := readFiles(function.EnvironmentFile)
-			if err != nil {
-				return err
-			}
-
-			labelMap := map[string]string{}
-			if function.Labels != nil {
-				labelMap = *function.Labels
-			}
-
-			labelArgumentMap, labelErr := util.ParseMap(deployFlags.labelOpts, "label")
-			if labelErr != nil {
-				return fmt.Errorf("error parsing labels: %v", labelErr)
-			}
-
-			allLabels := util.MergeMap(labelMap, labelArgumentMap)
-
-			allEnvironment, envErr := compileEnvironment(deployFlags.envvarOpts, function.Environment, fileEnvironment)
-			if envErr != nil {
-				return envErr
-			}
-
-			if readTemplate {
-				// Get FProcess to use from the ./template/template.yml, if a template is being used
-				if languageExistsNotDockerfile(function.Language) {
-					var fprocessErr error
-
-					function.FProcess, fprocessErr = deriveFprocess(function)
-					if fprocessErr != nil {
-						return fmt.Errorf(`template directory may be missing or invalid, please run "forge-cli template pull"
-Error: %s`, fprocessErr.Error())
-					}
-				}
-			}
-
-			functionResourceRequest := proxy.FunctionResourceRequest{
-				Limits:   function.Limits,
-				Requests: function.Requests,
-			}
-
-			var annotations map[string]string
-			if function.Annotations != nil {
-				annotations = *function.Annotations
-			}
-
-			annotationArgs, annotationErr := util.ParseMap(deployFlags.annotationOpts, "annotation")
-			if annotationErr != nil {
-				return fmt.Errorf("error parsing annotations: %v", annotationErr)
-			}
-
-			allAnnotations := util.MergeMap(annotations, annotationArgs)
-
-			branch, sha, err := builder.GetImageTagValues(tagMode, function.Handler)
-			if err != nil {
-				return err
-			}
-
-			function.Image = schema.BuildImageName(tagMode, function.Image, sha, branch)
-
-			if deployFlags.readOnlyRootFilesystem {
-				function.ReadOnlyRootFilesystem = deployFlags.readOnlyRootFilesystem
-			}
-
-			deploySpec := &proxy.DeployFunctionSpec{
-				FProcess:                function.FProcess,
-				FunctionName:            function.Name,
-				Image:                   function.Image,
-				Language:                function.Language,
-				Replace:                 deployFlags.replace,
-				EnvVars:                 allEnvironment,
-				Constraints:             functionConstraints,
-				Update:                  deployFlags.update,
-				Secrets:                 functionSecrets,
-				Labels:                  allLabels,
-				Annotations:             allAnnotations,
-				FunctionResourceRequest: functionResourceRequest,
-				ReadOnlyRootFilesystem:  function.ReadOnlyRootFilesystem,
-				TLSInsecure:             tlsInsecure,
-				Token:                   token,
-				Namespace:               function.Namespace,
-			}
+		if parallel < 1 {
+			return fmt.Errorf("the --parallel flag must be great than 0")
+		}
 
-			if msg := checkTLSInsecure(services.Provider.GatewayURL, deploySpec.TLSInsecure); len(msg) > 0 {
-				fmt.Println(msg)
-			}
-			statusCode := proxyClient.DeployFunction(ctx, deploySpec)
-			if badStatusCode(statusCode) {
-				failedStatusCodes[k] = statusCode
+		deployer := stackDeployer{
+			client:       proxyClient,
+			services:     services,
+			deployFlags:  deployFlags,
+			tagMode:      tagMode,
+			canaryWeight: canaryWeight,
+		}
+		if deployFlags.skipUnchanged {
+			deployer.cache = builder.ReadBuildCache(builder.BuildCachePath)
+		}
+		results, err := deployer.deploy(ctx, parallel)
+		if deployer.cache != nil {
+			saveBuildCache(deployer.cache)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Print(renderDeployResults(results))
+
+		for _, result := range results {
+			switch {
+			case len(result.skippedFor) > 0:
+				stackErrors = append(stackErrors, fmt.Errorf("function '%s' was not deployed because '%s' was not deployed", result.name, result.skippedFor))
+			case result.err != nil:
+				stackErrors = append(stackErrors, result.err)
+			case result.unchanged:
+				continue
+			default:
+				rollouts = append(rollouts, result.target)
 			}
 		}
+
+		// each function was waited for before its dependents were deployed
+		waited = true
 	} else {
 		if len(image) == 0 || len(functionName) == 0 {
 			return fmt.Errorf("to deploy a function give --yaml/-f or a --image and --name flag")
@@ -301,25 +291,26 @@ Error: %s`, fprocessErr.Error())
 		if err != nil {
 			return err
 		}
-		proxyClient, err := proxy.NewClient(cliAuth, gateway, transport, &commandTimeout)
+		proxyClient, err = proxy.NewClient(cliAuth, gateway, transport, &commandTimeout)
 		if err != nil {
 			return err
 		}
+		proxyClient.Retry = newRetryPolicy()
+
+		namespace := getNamespace(functionNamespace, "")
 
 		// default to a readable filesystem until we get more input about the expected behavior
 		// and if we want to add another flag for this case
 		defaultReadOnlyRFS := false
-		statusCode, err := deployImage(ctx,
-			proxyClient,
+		deploySpec, err := buildImageDeploySpec(
 			image,
 			fprocess,
 			functionName,
-			"",
 			deployFlags,
 			tlsInsecure,
 			defaultReadOnlyRFS,
 			token,
-			functionNamespace,
+			namespace,
 			cpuRequest,
 			cpuLimit,
 			memoryRequest,
@@ -328,16 +319,168 @@ Error: %s`, fprocessErr.Error())
 			return err
 		}
 
-		if badStatusCode(statusCode) {
-			failedStatusCodes[functionName] = statusCode
+		if msg := checkTLSInsecure(gateway, deploySpec.TLSInsecure); len(msg) > 0 {
+			fmt.Println(msg)
+		}
+
+		if len(deployFlags.canary) > 0 {
+			applyCanary(deploySpec, canaryWeight)
+			fmt.Printf("Deploying canary: %s for %d%% of traffic.\n", deploySpec.FunctionName, canaryWeight)
+		}
+
+		deployed := stampRevision(ctx, proxyClient, deploySpec)
+		if err := proxyClient.DeployFunction(ctx, deploySpec); err != nil {
+			failedDeploys[functionName] = err
+		} else {
+			if err := recordRevision(proxyClient.GatewayURL, deployed, *deploySpec); err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to record revision %d of %s: %s\n", deployed.Revision, functionName, err)
+			}
+			rollouts = append(rollouts, rolloutTarget{
+				name:      deploySpec.FunctionName,
+				namespace: namespace,
+				image:     image,
+			})
 		}
 	}
 
-	if err := deployFailed(failedStatusCodes); err != nil {
-		return err
+	var waitErr error
+	if (deployFlags.wait || deployFlags.autoPromote) && !waited && len(rollouts) > 0 {
+		waitErr = waitForRollouts(ctx, proxyClient, rollouts, deployFlags.waitTimeout, os.Stdout)
 	}
 
-	return nil
+	var promoteErr error
+	if deployFlags.autoPromote && waitErr == nil {
+		promoteErr = autoPromoteCanaries(ctx, proxyClient, rollouts)
+	}
+
+	return errors.Join(deployFailed(failedDeploys), errors.Join(stackErrors...), waitErr, promoteErr)
+}
+
+// autoPromoteCanaries promotes each canary which passes the promotion gate
+// and removes the ones which fail it
+func autoPromoteCanaries(ctx context.Context, client *proxy.Client, canaries []rolloutTarget) error {
+	var allErrors []error
+	for _, canary := range canaries {
+		functionName := strings.TrimSuffix(canary.name, canarySuffix)
+
+		if err := canaryGate(client.GatewayURL.String(), functionName, canary.namespace, canarySamples, canaryMaxErrorRate); err != nil {
+			allErrors = append(allErrors, err)
+			if abortErr := abortCanary(ctx, client, functionName, canary.namespace); abortErr != nil {
+				allErrors = append(allErrors, abortErr)
+			}
+			continue
+		}
+
+		if err := promoteCanary(ctx, client, functionName, canary.namespace); err != nil {
+			allErrors = append(allErrors, err)
+		}
+	}
+	return errors.Join(allErrors...)
+}
+
+// buildDeploySpec resolves a function from the stack file, its environment
+// files, template and the deploy flags into the spec sent to the gateway
+func buildDeploySpec(function stack.Function, deployFlags DeployFlags, tagMode schema.BuildFormat) (*proxy.DeployFunctionSpec, error) {
+	functionSecrets := deployFlags.secrets
+
+	var functionConstraints []string
+	if function.Constraints != nil {
+		functionConstraints = *function.Constraints
+	} else if len(deployFlags.constraints) > 0 {
+		functionConstraints = deployFlags.constraints
+	}
+
+	if len(function.Secrets) > 0 {
+		functionSecrets = util.MergeSlice(function.Secrets, functionSecrets)
+	}
+
+	// Check if there is a functionNamespace flag passed, if so, override the namespace value
+	// defined in the stack.yaml
+	function.Namespace = getNamespace(functionNamespace, function.Namespace)
+
+	fileEnvironment, err := readFiles(function.EnvironmentFile)
+	if err != nil {
+		return nil, err
+	}
+
+	labelMap := map[string]string{}
+	if function.Labels != nil {
+		labelMap = *function.Labels
+	}
+
+	labelArgumentMap, labelErr := util.ParseMap(deployFlags.labelOpts, "label")
+	if labelErr != nil {
+		return nil, fmt.Errorf("error parsing labels: %v", labelErr)
+	}
+
+	allLabels := util.MergeMap(labelMap, labelArgumentMap)
+
+	allEnvironment, envErr := compileEnvironment(deployFlags.envvarOpts, function.Environment, fileEnvironment)
+	if envErr != nil {
+		return nil, envErr
+	}
+
+	if readTemplate {
+		// Get FProcess to use from the ./template/template.yml, if a template is being used
+		if languageExistsNotDockerfile(function.Language) {
+			var fprocessErr error
+
+			function.FProcess, fprocessErr = deriveFprocess(function)
+			if fprocessErr != nil {
+				return nil, fmt.Errorf(`template directory may be missing or invalid, please run "forge-cli template pull"
+Error: %s`, fprocessErr.Error())
+			}
+		}
+	}
+
+	functionResourceRequest := proxy.FunctionResourceRequest{
+		Limits:   function.Limits,
+		Requests: function.Requests,
+	}
+
+	var annotations map[string]string
+	if function.Annotations != nil {
+		annotations = *function.Annotations
+	}
+
+	annotationArgs, annotationErr := util.ParseMap(deployFlags.annotationOpts, "annotation")
+	if annotationErr != nil {
+		return nil, fmt.Errorf("error parsing annotations: %v", annotationErr)
+	}
+
+	allAnnotations := util.MergeMap(annotations, annotationArgs)
+
+	branch, sha, err := builder.GetImageTagValues(tagMode, function.Handler)
+	if err != nil {
+		return nil, err
+	}
+
+	function.Image = schema.BuildImageName(tagMode, function.Image, sha, branch)
+
+	if deployFlags.readOnlyRootFilesystem {
+		function.ReadOnlyRootFilesystem = deployFlags.readOnlyRootFilesystem
+	}
+
+	deploySpec := &proxy.DeployFunctionSpec{
+		FProcess:                function.FProcess,
+		FunctionName:            function.Name,
+		Image:                   function.Image,
+		Language:                function.Language,
+		Replace:                 deployFlags.replace,
+		EnvVars:                 allEnvironment,
+		Constraints:             functionConstraints,
+		Update:                  deployFlags.update,
+		Secrets:                 functionSecrets,
+		Labels:                  allLabels,
+		Annotations:             allAnnotations,
+		FunctionResourceRequest: functionResourceRequest,
+		ReadOnlyRootFilesystem:  function.ReadOnlyRootFilesystem,
+		TLSInsecure:             tlsInsecure,
+		Token:                   token,
+		Namespace:               function.Namespace,
+	}
+
+	return deploySpec, nil
 }
 
 // deployImage deploys a function with the given image
@@ -357,25 +500,64 @@ func deployImage(
 	cpuLimit string,
 	memoryRequest string,
 	memoryLimit string,
-) (int, error) {
+) error {
+
+	deploySpec, err := buildImageDeploySpec(
+		image,
+		fprocess,
+		functionName,
+		deployFlags,
+		tlsInsecure,
+		readOnlyRootFilesystem,
+		token,
+		namespace,
+		cpuRequest,
+		cpuLimit,
+		memoryRequest,
+		memoryLimit)
+	if err != nil {
+		return err
+	}
+
+	if msg := checkTLSInsecure(gateway, deploySpec.TLSInsecure); len(msg) > 0 {
+		fmt.Println(msg)
+	}
+
+	return client.DeployFunction(ctx, deploySpec)
+}
+
+// buildImageDeploySpec creates the spec for deploying an image given by flags
+func buildImageDeploySpec(
+	image string,
+	fprocess string,
+	functionName string,
+	deployFlags DeployFlags,
+	tlsInsecure bool,
+	readOnlyRootFilesystem bool,
+	token string,
+	namespace string,
+	cpuRequest string,
+	cpuLimit string,
+	memoryRequest string,
+	memoryLimit string,
+) (*proxy.DeployFunctionSpec, error) {
 
-	var statusCode int
 	readOnlyRFS := deployFlags.readOnlyRootFilesystem || readOnlyRootFilesystem
 	envvars, err := util.ParseMap(deployFlags.envvarOpts, "env")
 	if err != nil {
-		return statusCode, fmt.Errorf("error parsing envvars: %v", err)
+		return nil, fmt.Errorf("error parsing envvars: %v", err)
 	}
 
 	labelMap, labelErr := util.ParseMap(deployFlags.labelOpts, "label")
 
 	if labelErr != nil {
-		return statusCode, fmt.Errorf("error parsing labels: %v", labelErr)
+		return nil, fmt.Errorf("error parsing labels: %v", labelErr)
 	}
 
 	annotationMap, annotationErr := util.ParseMap(deployFlags.annotationOpts, "annotation")
 
 	if annotationErr != nil {
-		return statusCode, fmt.Errorf("error parsing annotations: %v", annotationErr)
+		return nil, fmt.Errorf("error parsing annotations: %v", annotationErr)
 	}
 
 	deploySpec := &proxy.DeployFunctionSpec{
@@ -411,13 +593,7 @@ func deployImage(
 		}
 	}
 
-	if msg := checkTLSInsecure(gateway, deploySpec.TLSInsecure); len(msg) > 0 {
-		fmt.Println(msg)
-	}
-
-	statusCode = client.DeployFunction(ctx, deploySpec)
-
-	return statusCode, nil
+	return deploySpec, nil
 }
 
 func readFiles(files []string) (map[string]string, error) {
@@ -482,19 +658,14 @@ func languageExistsNotDockerfile(language string) bool {
 	return len(language) > 0 && strings.ToLower(language) != "dockerfile"
 }
 
-func deployFailed(status map[string]int) error {
-	if len(status) == 0 {
+func deployFailed(failed map[string]error) error {
+	if len(failed) == 0 {
 		return nil
 	}
 
-	var allErrors []string
-	for funcName, funcStatus := range status {
-		err := fmt.Errorf("function '%s' failed to deploy with status code: %d", funcName, funcStatus)
-		allErrors = append(allErrors, err.Error())
+	var allErrors []error
+	for funcName, err := range failed {
+		allErrors = append(allErrors, fmt.Errorf("function '%s' failed to deploy: %w", funcName, err))
 	}
-	return fmt.Errorf(strings.Join(allErrors, "\n"))
-}
-
-func badStatusCode(statusCode int) bool {
-	return statusCode != http.StatusAccepted && statusCode != http.StatusOK
+	return errors.Join(allErrors...)
 }
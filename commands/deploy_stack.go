@@ -0,0 +1,362 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/forge4flow/forge-cli/builder"
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/schema"
+	"github.com/forge4flow/forge-cli/stack"
+)
+
+// deployResult is the outcome of deploying a function of a stack
+type deployResult struct {
+	name     string
+	target   rolloutTarget
+	duration time.Duration
+	err      error
+
+	// skippedFor is the dependency which was not deployed, when set the
+	// function was not deployed either
+	skippedFor string
+
+	// unchanged is set when --skip-unchanged found the function deployed
+	// with the same spec
+	unchanged bool
+}
+
+func (r deployResult) deployed() bool {
+	return r.err == nil && len(r.skippedFor) == 0
+}
+
+// stackDeployer deploys the functions of a stack file
+type stackDeployer struct {
+	client       *proxy.Client
+	services     stack.Services
+	deployFlags  DeployFlags
+	tagMode      schema.BuildFormat
+	canaryWeight int
+
+	// cache records the spec each function was deployed with, it is only
+	// set with --skip-unchanged
+	cache *builder.BuildCache
+
+	// outputMu keeps the lines of functions deployed in parallel apart
+	outputMu sync.Mutex
+}
+
+// deploy deploys up to parallel functions at a time, a function is started
+// once all of its depends_on are deployed, and skipped when one of them
+// fails. The results are returned in deploy order.
+func (d *stackDeployer) deploy(ctx context.Context, parallel int) ([]deployResult, error) {
+	order, err := stack.DeployOrder(d.services.Functions)
+	if err != nil {
+		return nil, err
+	}
+
+	position := make(map[string]int, len(order))
+	remaining := make(map[string]int, len(order))
+	dependents := map[string][]string{}
+	for i, name := range order {
+		position[name] = i
+	}
+	for _, name := range order {
+		for _, dependency := range d.services.Functions[name].DependsOn {
+			if _, ok := position[dependency]; ok && !containsString(dependents[dependency], name) {
+				remaining[name]++
+				dependents[dependency] = append(dependents[dependency], name)
+			}
+		}
+	}
+
+	var ready []string
+	for _, name := range order {
+		if remaining[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	results := make(map[string]deployResult, len(order))
+	done := make(chan deployResult)
+	running := 0
+
+	var skip func(name, dependency string)
+	skip = func(name, dependency string) {
+		if _, ok := results[name]; ok {
+			return
+		}
+		results[name] = deployResult{name: name, skippedFor: dependency}
+		for _, dependent := range dependents[name] {
+			skip(dependent, name)
+		}
+	}
+
+	for len(results) < len(order) {
+		for running < parallel && len(ready) > 0 {
+			name := ready[0]
+			ready = ready[1:]
+			running++
+
+			go func(name string) {
+				if parallel == 1 {
+					done <- d.deployFunction(ctx, name, os.Stdout, os.Stderr)
+					return
+				}
+
+				stdout := d.prefixWriter(name, os.Stdout)
+				stderr := d.prefixWriter(name, os.Stderr)
+				result := d.deployFunction(ctx, name, stdout, stderr)
+				stdout.Flush()
+				stderr.Flush()
+				done <- result
+			}(name)
+		}
+
+		result := <-done
+		running--
+		results[result.name] = result
+
+		for _, dependent := range dependents[result.name] {
+			if !result.deployed() {
+				skip(dependent, result.name)
+				continue
+			}
+
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+		sort.Slice(ready, func(i, j int) bool {
+			return position[ready[i]] < position[ready[j]]
+		})
+	}
+
+	ordered := make([]deployResult, 0, len(order))
+	for _, name := range order {
+		ordered = append(ordered, results[name])
+	}
+	return ordered, nil
+}
+
+// deployFunction deploys a function of the stack between its pre_deploy and
+// post_deploy hooks. With --wait or a smoke test it waits for the function to
+// become ready, so that the functions which depend on it start after it.
+// Progress is printed to stdout and stderr.
+func (d *stackDeployer) deployFunction(ctx context.Context, name string, stdout, stderr io.Writer) deployResult {
+	start := time.Now()
+	result := deployResult{name: name}
+
+	client := *d.client
+	client.Output = stdout
+
+	function := d.services.Functions[name]
+	function.Name = name
+	fmt.Fprintf(stdout, "Deploying: %s.\n", function.Name)
+
+	deploySpec, err := buildDeploySpec(function, d.deployFlags, d.tagMode)
+	if err != nil {
+		result.err = err
+		result.duration = time.Since(start)
+		return result
+	}
+
+	if msg := checkTLSInsecure(d.services.Provider.GatewayURL, deploySpec.TLSInsecure); len(msg) > 0 {
+		fmt.Fprintln(stdout, msg)
+	}
+
+	if len(d.deployFlags.canary) > 0 {
+		applyCanary(deploySpec, d.canaryWeight)
+		fmt.Fprintf(stdout, "Deploying canary: %s for %d%% of traffic.\n", deploySpec.FunctionName, d.canaryWeight)
+	}
+
+	var specHash, target string
+	if d.cache != nil {
+		specHash = deploySpecHash(*deploySpec)
+		target = d.client.GatewayURL.String() + " " + formatFunctionRef(deploySpec.FunctionName, deploySpec.Namespace)
+
+		if d.cache.Deployed(name, deploySpec.Image, target, specHash) {
+			fmt.Fprintf(stdout, "%s is unchanged since it was last deployed, skipping it.\n", name)
+			result.unchanged = true
+			result.duration = time.Since(start)
+			return result
+		}
+	}
+
+	var environment map[string]string
+	if function.Hooks != nil {
+		environment = hookEnvironment(deploySpec.FunctionName, deploySpec.Image, deploySpec.Namespace, d.services.Provider.GatewayURL, deploySpec.EnvVars)
+		if err := runHook(name, preDeployHook, function.Hooks.PreDeploy, environment, stdout, stderr); err != nil {
+			result.err = err
+			result.duration = time.Since(start)
+			return result
+		}
+	}
+
+	deployed := stampRevision(ctx, &client, deploySpec)
+	if err := client.DeployFunction(ctx, deploySpec); err != nil {
+		result.err = fmt.Errorf("function '%s' failed to deploy: %w", name, err)
+		result.duration = time.Since(start)
+		return result
+	}
+
+	if err := recordRevision(client.GatewayURL, deployed, *deploySpec); err != nil {
+		fmt.Fprintf(stderr, "Unable to record revision %d of %s: %s\n", deployed.Revision, name, err)
+	}
+
+	result.target = rolloutTarget{
+		name:      deploySpec.FunctionName,
+		namespace: deploySpec.Namespace,
+		image:     deploySpec.Image,
+	}
+
+	// the smoke test needs the function to be ready
+	if d.deployFlags.wait || d.deployFlags.autoPromote || function.SmokeTest != nil {
+		result.err = waitForRollouts(ctx, &client, []rolloutTarget{result.target}, d.deployFlags.waitTimeout, stdout)
+	}
+
+	if result.err == nil && function.Hooks != nil {
+		result.err = runHook(name, postDeployHook, function.Hooks.PostDeploy, environment, stdout, stderr)
+	}
+	if result.err == nil && function.SmokeTest != nil {
+		result.err = runSmokeTest(d.services.Provider.GatewayURL, deploySpec.FunctionName, deploySpec.Namespace, *function.SmokeTest, stdout)
+	}
+
+	if result.err != nil && d.deployFlags.rollbackOnFailure {
+		result.err = d.rollback(ctx, &client, deploySpec, result.err)
+	}
+
+	if result.err == nil && d.cache != nil {
+		d.cache.MarkDeployed(name, deploySpec.Image, target, specHash)
+	}
+
+	result.duration = time.Since(start)
+	return result
+}
+
+// deploySpecHash is a hash of what is deployed for a function, the token is
+// left out so that a new token does not redeploy every function
+func deploySpecHash(spec proxy.DeployFunctionSpec) string {
+	spec.Token = ""
+	data, _ := json.Marshal(spec)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// rollback undoes a deployment which did not become ready, or failed its
+// post_deploy hook or smoke test, a canary is removed and any other function is rolled back to its
+// previous revision
+func (d *stackDeployer) rollback(ctx context.Context, client *proxy.Client, spec *proxy.DeployFunctionSpec, cause error) error {
+	if len(d.deployFlags.canary) > 0 {
+		fmt.Fprintf(client.Output, "Removing %s as it failed.\n", spec.FunctionName)
+		if err := client.DeleteFunction(ctx, spec.FunctionName, spec.Namespace); err != nil {
+			return fmt.Errorf("%w, and the canary could not be removed: %s", cause, err)
+		}
+		return fmt.Errorf("%w, the canary was removed", cause)
+	}
+
+	target, _, err := rollbackFunction(ctx, client, spec.FunctionName, spec.Namespace, 0, client.Output)
+	if err != nil {
+		return fmt.Errorf("%w, and the rollback failed: %s", cause, err)
+	}
+	return fmt.Errorf("%w, rolled back to revision %d", cause, target.Revision)
+}
+
+// renderDeployResults prints a table with a row for each function of a stack
+func renderDeployResults(results []deployResult) string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 1, ' ', 0)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "FUNCTION\tRESULT\tDURATION\tDETAILS")
+
+	for _, result := range results {
+		switch {
+		case len(result.skippedFor) > 0:
+			fmt.Fprintf(w, "%s\tskipped\t\t%s was not deployed\n", result.name, result.skippedFor)
+		case result.err != nil:
+			fmt.Fprintf(w, "%s\tfailed\t%.2fs\t%s\n", result.name, result.duration.Seconds(), firstLine(result.err.Error()))
+		case result.unchanged:
+			fmt.Fprintf(w, "%s\tunchanged\t\t\n", result.name)
+		default:
+			fmt.Fprintf(w, "%s\tdeployed\t%.2fs\t%s\n", result.name, result.duration.Seconds(), formatFunctionRef(result.target.name, result.target.namespace))
+		}
+	}
+
+	fmt.Fprintln(w)
+	w.Flush()
+	return b.String()
+}
+
+// linePrefixer writes each line to w with the name of a function in front,
+// whole lines are written under mu so that they don't mix with the lines of
+// other functions
+type linePrefixer struct {
+	prefix string
+	w      io.Writer
+	mu     *sync.Mutex
+	line   []byte
+}
+
+// prefixWriter prefixes the lines of a function deployed in parallel
+func (d *stackDeployer) prefixWriter(name string, w io.Writer) *linePrefixer {
+	return &linePrefixer{prefix: "[" + name + "] ", w: w, mu: &d.outputMu}
+}
+
+func (p *linePrefixer) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.line = append(p.line, b...)
+	for {
+		i := bytes.IndexByte(p.line, '\n')
+		if i < 0 {
+			break
+		}
+		if _, err := io.WriteString(p.w, p.prefix+string(p.line[:i+1])); err != nil {
+			return 0, err
+		}
+		p.line = p.line[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush writes what is left of an unterminated line
+func (p *linePrefixer) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.line) > 0 {
+		io.WriteString(p.w, p.prefix+string(p.line)+"\n")
+		p.line = nil
+	}
+}
+
+func firstLine(message string) string {
+	if i := bytes.IndexByte([]byte(message), '\n'); i >= 0 {
+		return message[:i]
+	}
+	return message
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
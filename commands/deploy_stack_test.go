@@ -0,0 +1,217 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/forge4flow/forge-cli/test"
+)
+
+const dependentStack = `provider:
+  name: functions4flow
+
+functions:
+  api:
+    lang: dockerfile
+    image: api:0.1
+    depends_on:
+      - db
+      - cache
+  cache:
+    lang: dockerfile
+    image: cache:0.1
+  db:
+    lang: dockerfile
+    image: db:0.1
+`
+
+func writeDependentStack(t *testing.T) string {
+	t.Helper()
+
+	stackFile := filepath.Join(t.TempDir(), "stack.yml")
+	if err := os.WriteFile(stackFile, []byte(dependentStack), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return stackFile
+}
+
+func deployRequests(name string, statusCode int) []test.Request {
+	return []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/function/" + name + "?usage=1",
+			ResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			Method:             http.MethodPut,
+			Uri:                "/system/functions",
+			ResponseStatusCode: statusCode,
+		},
+	}
+}
+
+func Test_deployStack_DependencyOrder(t *testing.T) {
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+
+	var requests []test.Request
+	requests = append(requests, deployRequests("cache", http.StatusOK)...)
+	requests = append(requests, deployRequests("db", http.StatusOK)...)
+	requests = append(requests, deployRequests("api", http.StatusOK)...)
+
+	s := test.MockHttpServer(t, requests)
+	defer s.Close()
+
+	resetForTest()
+	defer resetForTest()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"deploy",
+			"--gateway=" + s.URL,
+			"--yaml=" + writeDependentStack(t),
+			"--parallel=1",
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cache := strings.Index(stdOut, "Deploying: cache.")
+	db := strings.Index(stdOut, "Deploying: db.")
+	api := strings.Index(stdOut, "Deploying: api.")
+	if cache < 0 || db < cache || api < db {
+		t.Fatalf("want cache, db then api to be deployed, got:\n%s", stdOut)
+	}
+
+	for _, row := range []string{`(?m)^cache +deployed +[0-9.]+s +cache$`, `(?m)^db +deployed `, `(?m)^api +deployed `} {
+		if found, _ := regexp.MatchString(row, stdOut); !found {
+			t.Fatalf("want a row matching %q in the results, got:\n%s", row, stdOut)
+		}
+	}
+}
+
+func Test_deployStack_SkipsDependents(t *testing.T) {
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+
+	var requests []test.Request
+	requests = append(requests, deployRequests("cache", http.StatusOK)...)
+	requests = append(requests, deployRequests("db", http.StatusInternalServerError)...)
+
+	s := test.MockHttpServer(t, requests)
+	defer s.Close()
+
+	resetForTest()
+	defer resetForTest()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"deploy",
+			"--gateway=" + s.URL,
+			"--yaml=" + writeDependentStack(t),
+			"--parallel=1",
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err == nil {
+		t.Fatalf("want an error as db failed to deploy")
+	}
+	if !strings.Contains(err.Error(), "function 'api' was not deployed because 'db' was not deployed") {
+		t.Fatalf("want api to be reported as skipped, got: %s", err)
+	}
+
+	if strings.Contains(stdOut, "Deploying: api.") {
+		t.Fatalf("want api not to be deployed, got:\n%s", stdOut)
+	}
+
+	for _, row := range []string{`(?m)^db +failed +[0-9.]+s +function 'db' failed to deploy: Unexpected status: 500`, `(?m)^api +skipped +db was not deployed$`} {
+		if found, _ := regexp.MatchString(row, stdOut); !found {
+			t.Fatalf("want a row matching %q in the results, got:\n%s", row, stdOut)
+		}
+	}
+}
+
+func Test_deployStack_SkipUnchanged(t *testing.T) {
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+	stackFile := writeDependentStack(t)
+
+	// the deployments are recorded under ./build/
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	var requests []test.Request
+	requests = append(requests, deployRequests("cache", http.StatusOK)...)
+	requests = append(requests, deployRequests("db", http.StatusOK)...)
+	requests = append(requests, deployRequests("api", http.StatusOK)...)
+
+	// only the first deploy reaches the gateway
+	s := test.MockHttpServer(t, requests)
+	defer s.Close()
+
+	resetForTest()
+	defer resetForTest()
+	defer func() { deployFlags.skipUnchanged = false }()
+
+	for i := 0; i < 2; i++ {
+		stdOut := test.CaptureStdout(func() {
+			forgeCmd.SetArgs([]string{
+				"deploy",
+				"--gateway=" + s.URL,
+				"--yaml=" + stackFile,
+				"--skip-unchanged",
+			})
+			err = forgeCmd.Execute()
+		})
+		if err != nil {
+			t.Fatalf("deploy %d: unexpected error: %s", i+1, err)
+		}
+
+		if i == 1 {
+			for _, row := range []string{`(?m)^cache +unchanged`, `(?m)^db +unchanged`, `(?m)^api +unchanged`} {
+				if found, _ := regexp.MatchString(row, stdOut); !found {
+					t.Fatalf("want a row matching %q in the results, got:\n%s", row, stdOut)
+				}
+			}
+		}
+	}
+}
+
+func Test_stackDeployer_prefixWriter(t *testing.T) {
+	var d stackDeployer
+	var out bytes.Buffer
+
+	api := d.prefixWriter("api", &out)
+	db := d.prefixWriter("db", &out)
+
+	fmt.Fprint(api, "Deploying: ")
+	fmt.Fprintln(db, "Deploying: db.")
+	fmt.Fprintln(api, "api.")
+	fmt.Fprint(db, "Ready db")
+	api.Flush()
+	db.Flush()
+
+	want := "[db] Deploying: db.\n[api] Deploying: api.\n[db] Ready db\n"
+	if out.String() != want {
+		t.Fatalf("want:\n%s\ngot:\n%s", want, out.String())
+	}
+}
@@ -10,11 +10,20 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/forge4flow/forge-cli/proxy"
 	"github.com/forge4flow/forge-cli/test"
 )
 
 func Test_deploy(t *testing.T) {
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+
 	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/function/test-function?usage=1",
+			ResponseStatusCode: http.StatusNotFound,
+		},
 		{
 			Method:             http.MethodPut,
 			Uri:                "/system/functions",
@@ -44,19 +53,19 @@ func Test_deploy(t *testing.T) {
 
 func Test_deployFailed(t *testing.T) {
 
-	var failedDeploy = make(map[string]int)
+	var failedDeploy = make(map[string]error)
 	var containedErrorsCount int
-	failedDeploy["example1"] = 100
-	failedDeploy["example2"] = 300
-	failedDeploy["example3"] = 400
-	failedDeploy["example4"] = 500
+	failedDeploy["example1"] = &proxy.APIError{StatusCode: 100}
+	failedDeploy["example2"] = &proxy.APIError{StatusCode: 300}
+	failedDeploy["example3"] = &proxy.APIError{StatusCode: 400}
+	failedDeploy["example4"] = &proxy.APIError{StatusCode: 500}
 	err := deployFailed(failedDeploy)
 	if err == nil {
 		t.Errorf("\nHad to exit with errors!")
 		t.Fail()
 	}
-	for _, theErrorCode := range failedDeploy {
-		if strings.Contains(err.Error(), strconv.Itoa(theErrorCode)) {
+	for _, theError := range failedDeploy {
+		if strings.Contains(err.Error(), strconv.Itoa(theError.(*proxy.APIError).StatusCode)) {
 			containedErrorsCount++
 		}
 	}
@@ -67,26 +76,9 @@ func Test_deployFailed(t *testing.T) {
 }
 
 func Test_deploySucceeded(t *testing.T) {
-	var succededDeploy = make(map[string]int)
+	var succededDeploy = make(map[string]error)
 	if err := deployFailed(succededDeploy); err != nil {
 		t.Errorf("\nHad to exit with no errors!")
 		t.Fail()
 	}
 }
-func Test_badStatusCOde(t *testing.T) {
-	okStatusCode := 200
-	if badStatusCode(okStatusCode) {
-		t.Errorf("\nUnexpected status code - wanted:%d OK!", okStatusCode)
-		t.Fail()
-	}
-	acceptedStatusCode := 202
-	if badStatusCode(acceptedStatusCode) {
-		t.Errorf("\nUnexpected status code - wanted:%d Accepted!", acceptedStatusCode)
-		t.Fail()
-	}
-	badStatusC := 300
-	if !(badStatusCode(badStatusC)) {
-		t.Errorf("\nUnexpected status code - wanted: %d but got %d or %d", badStatusC, acceptedStatusCode, okStatusCode)
-		t.Fail()
-	}
-}
@@ -26,10 +26,12 @@ func init() {
 	describeCmd.Flags().StringVar(&functionName, "name", "", "Name of the function")
 	describeCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
 	describeCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(describeCmd.Flags())
 	describeCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
 	describeCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
 	describeCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
 	describeCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+	addOutputFlag(describeCmd.Flags())
 
 	forgeCmd.AddCommand(describeCmd)
 }
@@ -40,7 +42,9 @@ var describeCmd = &cobra.Command{
 	Long:  `Display details of an Forge4Flow function`,
 	Example: `forge-cli describe figlet
 forge-cli describe env --gateway http://127.0.0.1:8080
-forge-cli describe echo -g http://127.0.0.1.8080`,
+forge-cli describe echo -g http://127.0.0.1.8080
+forge-cli describe figlet --output json
+forge-cli describe figlet -o jsonpath='{.image}'`,
 	PreRunE: preRunDescribe,
 	RunE:    runDescribe,
 }
@@ -58,7 +62,7 @@ func runDescribe(cmd *cobra.Command, args []string) error {
 	functionName = args[0]
 
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
 		if err != nil {
 			return err
 		}
@@ -78,16 +82,18 @@ func runDescribe(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	cliClient.Retry = newRetryPolicy()
 
 	ctx := context.Background()
+	namespace := getNamespace(functionNamespace, "")
 
-	function, err := cliClient.GetFunctionInfo(ctx, functionName, functionNamespace)
+	function, err := cliClient.GetFunctionInfo(ctx, functionName, namespace)
 	if err != nil {
 		return err
 	}
 
 	//To get correct value for invocation count from /system/functions endpoint
-	functionList, err := cliClient.ListFunctions(ctx, functionNamespace)
+	functionList, err := cliClient.ListFunctions(ctx, namespace)
 	if err != nil {
 		return err
 	}
@@ -105,7 +111,7 @@ func runDescribe(cmd *cobra.Command, args []string) error {
 		status = "Ready"
 	}
 
-	url, asyncURL := getFunctionURLs(gatewayAddress, functionName, functionNamespace)
+	url, asyncURL := getFunctionURLs(gatewayAddress, functionName, namespace)
 
 	funcDesc := schema.FunctionDescription{
 		FunctionStatus:  function,
@@ -115,6 +121,10 @@ func runDescribe(cmd *cobra.Command, args []string) error {
 		AsyncURL:        asyncURL,
 	}
 
+	if structuredOutput() {
+		return printOutput(funcDesc)
+	}
+
 	printFunctionDescription(cmd.OutOrStdout(), funcDesc, verbose)
 
 	return nil
@@ -0,0 +1,310 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/stack"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change
+const diffContextLines = 3
+
+func init() {
+	diffCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	diffCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(diffCmd.Flags())
+	diffCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
+	diffCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
+	diffCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
+	diffCmd.Flags().Var(&tagFormat, "tag", "Override latest tag on function Docker image, accepts 'latest', 'sha', 'branch', or 'describe'")
+	diffCmd.Flags().DurationVar(&timeoutOverride, "timeout", commandTimeout, "Timeout for any HTTP calls made to the Forge4Flow API.")
+
+	forgeCmd.AddCommand(diffCmd)
+}
+
+// diffCmd shows the differences between the stack file and the deployed functions
+var diffCmd = &cobra.Command{
+	Use:   `diff -f YAML_FILE [--regex "REGEX"] [--filter "WILDCARD"] [--tag <sha|branch|describe>]`,
+	Short: "Show differences between the stack file and deployed functions",
+	Long: `Compares each function in the supplied YAML config with the function deployed to
+the gateway, and prints a unified diff of the fields which would change on deploy.
+
+The stack file is resolved as it would be by "forge-cli deploy", including
+environment substitution, environment files and the --tag image format.
+
+The command exits with a non-zero status when any function has drifted, so that
+it can be used to gate CI pipelines.`,
+	Example: `  forge-cli diff -f ./functions.yml
+  forge-cli diff -f ./functions.yml --filter "*gif*"
+  forge-cli diff -f ./functions.yml --tag sha --namespace staging`,
+	PreRunE: preRunDiff,
+	RunE:    runDiff,
+}
+
+func preRunDiff(cmd *cobra.Command, args []string) error {
+	if len(yamlFile) == 0 {
+		return fmt.Errorf("give a stack file with --yaml/-f to diff")
+	}
+	return nil
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	services, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
+	if err != nil {
+		return err
+	}
+
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, services.Provider.GatewayURL, os.Getenv(openFaaSURLEnvironment))
+
+	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
+	if err != nil {
+		return err
+	}
+	transport := GetDefaultCLITransport(tlsInsecure, &timeoutOverride)
+	proxyClient, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &timeoutOverride)
+	if err != nil {
+		return err
+	}
+	proxyClient.Retry = newRetryPolicy()
+
+	ctx := context.Background()
+
+	var desired []*proxy.DeployFunctionSpec
+	for k, function := range services.Functions {
+		function.Name = k
+		spec, err := buildDeploySpec(function, DeployFlags{update: true}, tagFormat)
+		if err != nil {
+			return err
+		}
+		desired = append(desired, spec)
+	}
+
+	sort.Slice(desired, func(i, j int) bool {
+		return formatFunctionRef(desired[i].FunctionName, desired[i].Namespace) < formatFunctionRef(desired[j].FunctionName, desired[j].Namespace)
+	})
+
+	deployed, err := listDeployedFunctions(ctx, proxyClient, desired)
+	if err != nil {
+		return err
+	}
+
+	drifted := 0
+	for _, spec := range desired {
+		var live *functionState
+		if status, found := findFunctionStatus(deployed[spec.Namespace], spec.FunctionName); found {
+			state := stateFromStatus(status)
+			live = &state
+		}
+
+		want := stateFromSpec(spec)
+		changed, err := writeFunctionDiff(cmd.OutOrStdout(), formatFunctionRef(spec.FunctionName, spec.Namespace), live, &want)
+		if err != nil {
+			return err
+		}
+		if changed {
+			drifted++
+		}
+	}
+
+	if drifted > 0 {
+		return fmt.Errorf("%d of %d function(s) differ from the gateway", drifted, len(desired))
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "No differences found for %d function(s).\n", len(desired))
+	return nil
+}
+
+// writeFunctionDiff writes a unified diff from the deployed state to the
+// wanted state, a nil deployed state means the function does not exist yet.
+// It returns true when there were differences.
+func writeFunctionDiff(w io.Writer, name string, deployed, wanted *functionState) (bool, error) {
+	before, err := marshalFunctionState(deployed)
+	if err != nil {
+		return false, err
+	}
+
+	after, err := marshalFunctionState(wanted)
+	if err != nil {
+		return false, err
+	}
+
+	hunks := unifiedDiff(before, after, diffContextLines)
+	if len(hunks) == 0 {
+		return false, nil
+	}
+
+	fmt.Fprintf(w, "--- gateway/%s\n", name)
+	fmt.Fprintf(w, "+++ stack/%s\n", name)
+	fmt.Fprint(w, hunks)
+
+	return true, nil
+}
+
+func marshalFunctionState(state *functionState) ([]string, error) {
+	if state == nil {
+		return nil, nil
+	}
+
+	var buff bytes.Buffer
+	encoder := yaml.NewEncoder(&buff)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(state); err != nil {
+		return nil, err
+	}
+
+	return strings.Split(strings.TrimSuffix(buff.String(), "\n"), "\n"), nil
+}
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff returns the hunks of a unified diff between two sets of lines,
+// or an empty string when they are equal
+func unifiedDiff(a, b []string, context int) string {
+	ops := diffLines(a, b)
+
+	changed := false
+	for _, op := range ops {
+		if op.kind != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var out strings.Builder
+
+	i := 0
+	for i < len(ops) {
+		// Find the start of the next change
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+
+		// Extend the hunk until there are more than 2*context unchanged lines
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end += context
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = run
+		}
+
+		aStart, bStart := 1, 1
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				aStart++
+			}
+			if op.kind != '-' {
+				bStart++
+			}
+		}
+
+		aLines, bLines := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				aLines++
+			}
+			if op.kind != '-' {
+				bLines++
+			}
+		}
+		if aLines == 0 {
+			aStart--
+		}
+		if bLines == 0 {
+			bStart--
+		}
+
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aLines, bStart, bLines)
+		for _, op := range ops[start:end] {
+			fmt.Fprintf(&out, "%c%s\n", op.kind, op.line)
+		}
+
+		i = end
+	}
+
+	return out.String()
+}
+
+// diffLines computes an edit script from a to b using the longest common subsequence
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+
+	return ops
+}
@@ -0,0 +1,181 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/test"
+	"github.com/openfaas/faas-provider/types"
+)
+
+func Test_unifiedDiff(t *testing.T) {
+	cases := []struct {
+		name string
+		a    []string
+		b    []string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    []string{"a", "b"},
+			b:    []string{"a", "b"},
+			want: "",
+		},
+		{
+			name: "changed line",
+			a:    []string{"image: fn:0.1", "labels:", "  app: fn"},
+			b:    []string{"image: fn:0.2", "labels:", "  app: fn"},
+			want: "@@ -1,3 +1,3 @@\n-image: fn:0.1\n+image: fn:0.2\n labels:\n   app: fn\n",
+		},
+		{
+			name: "new file",
+			a:    nil,
+			b:    []string{"image: fn:0.1"},
+			want: "@@ -0,0 +1,1 @@\n+image: fn:0.1\n",
+		},
+		{
+			name: "separate hunks",
+			a:    []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"},
+			b:    []string{"one", "2", "3", "4", "5", "6", "7", "8", "9", "ten"},
+			want: "@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := unifiedDiff(tc.a, tc.b, diffContextLines)
+			if got != tc.want {
+				t.Fatalf("want:\n%q\ngot:\n%q", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_writeFunctionDiff(t *testing.T) {
+	deployed := &functionState{
+		Image:       "alexellis/figlet:0.1",
+		Environment: map[string]string{"write_debug": "true"},
+	}
+	wanted := &functionState{
+		Image:       "alexellis/figlet:0.2",
+		Environment: map[string]string{"write_debug": "true"},
+	}
+
+	var b bytes.Buffer
+	changed, err := writeFunctionDiff(&b, "figlet", deployed, wanted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !changed {
+		t.Fatal("want changed to be true")
+	}
+
+	want := `--- gateway/figlet
++++ stack/figlet
+@@ -1,3 +1,3 @@
+-image: alexellis/figlet:0.1
++image: alexellis/figlet:0.2
+ environment:
+   write_debug: "true"
+`
+	if b.String() != want {
+		t.Fatalf("want:\n%s\ngot:\n%s", want, b.String())
+	}
+
+	b.Reset()
+	changed, err = writeFunctionDiff(&b, "figlet", wanted, wanted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changed || b.Len() > 0 {
+		t.Fatalf("want no diff, got:\n%s", b.String())
+	}
+}
+
+func Test_runDiff_IgnoresRevisionAnnotations(t *testing.T) {
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+
+	stamper := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/function/figlet?usage=1",
+			ResponseStatusCode: http.StatusNotFound,
+		},
+	})
+	defer stamper.Close()
+
+	client, err := proxy.NewClient(&proxy.BasicAuth{}, stamper.URL, nil, &commandTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &proxy.DeployFunctionSpec{FunctionName: "figlet", Image: "alexellis/figlet:0.1"}
+	stampRevision(context.Background(), client, spec)
+
+	status := types.FunctionStatus{
+		Name:        "figlet",
+		Image:       "alexellis/figlet:0.1",
+		Labels:      &map[string]string{"faas_function": "figlet"},
+		Annotations: &spec.Annotations,
+	}
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       []types.FunctionStatus{status},
+		},
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/function/figlet?usage=1",
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       status,
+		},
+	})
+	defer s.Close()
+
+	stackFile := filepath.Join(t.TempDir(), "stack.yml")
+	stackYAML := `version: 1.0
+provider:
+  name: functions4flow
+functions:
+  figlet:
+    lang: dockerfile
+    handler: ./figlet
+    image: alexellis/figlet:0.1
+`
+	if err := os.WriteFile(stackFile, []byte(stackYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	resetForTest()
+
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"diff",
+			"--yaml=" + stackFile,
+			"--gateway=" + s.URL,
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err != nil {
+		t.Fatalf("want no drift for a function deployed with revision annotations, got: %s\n%s", err, stdOut)
+	}
+	if !strings.Contains(stdOut, "No differences found for 1 function(s).") {
+		t.Fatalf("unexpected output:\n%s", stdOut)
+	}
+}
@@ -4,7 +4,10 @@
 package commands
 
 import (
+	"errors"
 	"strings"
+
+	"github.com/forge4flow/forge-cli/proxy"
 )
 
 const (
@@ -12,6 +15,36 @@ const (
 	NoTLSWarn = "WARNING! You are not using an encrypted connection to the gateway, consider using HTTPS."
 )
 
+// Exit codes returned by the CLI, scripts may rely on these values so they
+// must not change. 2 is skipped as shells use it for usage errors.
+const (
+	// ExitCodeError is returned for errors without a more specific code
+	ExitCodeError = 1
+
+	// ExitCodeUnauthorized is returned when the gateway rejected the credentials
+	ExitCodeUnauthorized = 3
+
+	// ExitCodeNotFound is returned when a function, secret or namespace does not exist
+	ExitCodeNotFound = 4
+
+	// ExitCodeConflict is returned when a resource already exists
+	ExitCodeConflict = 5
+)
+
+// exitCode maps an error returned by a command to a stable exit code, when
+// several classifications match the first one in this order is used
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, proxy.ErrUnauthorized):
+		return ExitCodeUnauthorized
+	case errors.Is(err, proxy.ErrNotFound):
+		return ExitCodeNotFound
+	case errors.Is(err, proxy.ErrConflict):
+		return ExitCodeConflict
+	}
+	return ExitCodeError
+}
+
 // checkTLSInsecure returns a warning message if the given gateway does not have https.
 // Use tsInsecure to skip validations
 func checkTLSInsecure(gateway string, tlsInsecure bool) string {
@@ -2,7 +2,13 @@
 // Licensed under the MIT license. See LICENSE file in the project root for full license information.
 package commands
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/forge4flow/forge-cli/proxy"
+)
 
 func Test_checkTLSInsecure(t *testing.T) {
 	type args struct {
@@ -44,3 +50,38 @@ func Test_checkTLSInsecure(t *testing.T) {
 		})
 	}
 }
+
+func Test_exitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "plain error",
+			err:  errors.New("something went wrong"),
+			want: ExitCodeError},
+		{name: "wrapped not found",
+			err:  fmt.Errorf("describe: %w", &proxy.APIError{StatusCode: 404, Err: proxy.ErrNotFound}),
+			want: ExitCodeNotFound},
+		{name: "unauthorized",
+			err:  &proxy.APIError{StatusCode: 401, Err: proxy.ErrUnauthorized},
+			want: ExitCodeUnauthorized},
+		{name: "conflict",
+			err:  &proxy.APIError{StatusCode: 409, Err: proxy.ErrConflict},
+			want: ExitCodeConflict},
+		{name: "failed deployments",
+			err:  deployFailed(map[string]error{"figlet": &proxy.APIError{StatusCode: 401, Err: proxy.ErrUnauthorized}}),
+			want: ExitCodeUnauthorized},
+		{name: "unclassified deployment failure",
+			err:  deployFailed(map[string]error{"figlet": &proxy.APIError{StatusCode: 500}}),
+			want: ExitCodeError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("[%s] want: %d, but got: %d", tt.name, tt.want, got)
+			}
+		})
+	}
+}
@@ -54,17 +54,19 @@ var stat = func(filename string) (os.FileInfo, error) {
 // TODO: remove this workaround once these vars are no longer global
 func resetForTest() {
 	yamlFile = ""
+	yamlFlag.reset()
 	regex = ""
 	filter = ""
 	version.Version = ""
 	shortVersion = false
+	outputFormat = ""
 }
 
 func init() {
 	// Setup terminal std
 	term.StdStreams()
 
-	forgeCmd.PersistentFlags().StringVarP(&yamlFile, "yaml", "f", "", "Path to YAML file describing function(s)")
+	forgeCmd.PersistentFlags().VarP(yamlFlag, "yaml", "f", "Path to YAML file describing function(s), give more than once to merge overlays")
 	forgeCmd.PersistentFlags().StringVarP(&regex, "regex", "", "", "Regex to match with function names in YAML file")
 	forgeCmd.PersistentFlags().StringVarP(&filter, "filter", "", "", "Wildcard to match with function names in YAML file")
 
@@ -74,6 +76,7 @@ func init() {
 }
 
 func Execute(customArgs []string) {
+	yamlFlag.reset()
 	checkAndSetDefaultYaml()
 
 	forgeCmd.SilenceUsage = true
@@ -131,7 +134,7 @@ func Execute(customArgs []string) {
 	if err := forgeCmd.Execute(); err != nil {
 		e := err.Error()
 		fmt.Println(strings.ToUpper(e[:1]) + e[1:])
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -149,7 +152,8 @@ var forgeCmd = &cobra.Command{
 	Short: "Manage your Forge4Flow instance from the command line",
 	Long: `
 Manage your Forge4Flow instance from the command line`,
-	Run: runForge,
+	PersistentPreRun: applyContextTLS,
+	Run:              runForge,
 }
 
 // runForge TODO
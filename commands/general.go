@@ -10,12 +10,27 @@ import (
 
 	"github.com/forge4flow/forge-cli/proxy"
 	"github.com/openfaas/go-sdk"
+	"github.com/spf13/pflag"
 )
 
 var (
 	commandTimeout = 60 * time.Second
+
+	retries   int
+	retryWait time.Duration
 )
 
+// addRetryFlags adds the flags used to retry requests to the gateway
+func addRetryFlags(flags *pflag.FlagSet) {
+	flags.IntVar(&retries, "retries", 0, "Number of times to retry requests while the gateway is unavailable or rate-limiting")
+	flags.DurationVar(&retryWait, "retry-wait", proxy.DefaultRetryWait, "Wait before the first retry, doubled on every retry")
+}
+
+// newRetryPolicy returns the retry policy set via --retries and --retry-wait
+func newRetryPolicy() proxy.RetryPolicy {
+	return proxy.NewRetryPolicy(retries, retryWait)
+}
+
 func GetDefaultCLITransport(tlsInsecure bool, timeout *time.Duration) *http.Transport {
 	if timeout != nil || tlsInsecure {
 		tr := &http.Transport{
@@ -164,7 +164,7 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		}
 
 	} else if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,173 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/stack"
+	"github.com/openfaas/faas-provider/types"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	historyCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	historyCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
+	historyCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(historyCmd.Flags())
+	historyCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
+	historyCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
+	addOutputFlag(historyCmd.Flags())
+
+	forgeCmd.AddCommand(historyCmd)
+}
+
+var historyCmd = &cobra.Command{
+	Use:   `history FUNCTION_NAME [--gateway GATEWAY_URL]`,
+	Short: "Show the deployment history of a function",
+	Long: `Shows the revisions of a function recorded by "forge-cli deploy". Revisions are
+read from the function's annotations and from the local history, which also
+keeps the full deployment of each revision for "forge-cli rollback".`,
+	Example: `  forge-cli history figlet
+  forge-cli history figlet --namespace staging --output json`,
+	RunE: runHistory,
+}
+
+// historyEntry is a revision printed by "history --output"
+type historyEntry struct {
+	revision
+	Current bool `json:"current"`
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("please provide a name for the function")
+	}
+	functionName := args[0]
+	namespace := getNamespace(functionNamespace, "")
+
+	client, err := newHistoryClient()
+	if err != nil {
+		return err
+	}
+
+	history, status, err := loadHistory(context.Background(), client, functionName, namespace)
+	if err != nil {
+		return err
+	}
+
+	current := currentRevision(status)
+	entries := make([]historyEntry, 0, len(history))
+	for _, r := range history {
+		r.Spec = nil
+		entries = append(entries, historyEntry{revision: r, Current: r.Revision == current})
+	}
+
+	if structuredOutput() {
+		return printOutput(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("No history found for %s.\n", functionName)
+		return nil
+	}
+
+	fmt.Print(renderHistory(entries))
+	return nil
+}
+
+func renderHistory(entries []historyEntry) string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 1, ' ', 0)
+	fmt.Fprintln(w, "REVISION\tIMAGE\tGIT SHA\tDEPLOYED AT\tDEPLOYED BY")
+
+	for _, entry := range entries {
+		number := strconv.Itoa(entry.Revision)
+		if entry.Current {
+			number += " (current)"
+		}
+
+		deployedAt := ""
+		if !entry.DeployedAt.IsZero() {
+			deployedAt = entry.DeployedAt.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", number, entry.Image, entry.GitSHA, deployedAt, entry.DeployedBy)
+	}
+
+	w.Flush()
+	return b.String()
+}
+
+// loadHistory merges the revisions in the function's annotations with the
+// local history, oldest first. Local revisions are preferred as they include
+// the deployed spec. The status is empty when the function is not deployed.
+func loadHistory(ctx context.Context, client *proxy.Client, functionName, namespace string) ([]revision, types.FunctionStatus, error) {
+	local, err := readLocalHistory(client.GatewayURL, namespace, functionName)
+	if err != nil {
+		return nil, types.FunctionStatus{}, err
+	}
+
+	status, err := client.GetFunctionInfo(ctx, functionName, namespace)
+	if err != nil && !errors.Is(err, proxy.ErrNotFound) {
+		return nil, types.FunctionStatus{}, err
+	}
+
+	byNumber := map[int]revision{}
+	for _, r := range remoteHistory(status) {
+		byNumber[r.Revision] = r
+	}
+	for _, r := range local {
+		byNumber[r.Revision] = r
+	}
+
+	history := make([]revision, 0, len(byNumber))
+	for _, r := range byNumber {
+		history = append(history, r)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Revision < history[j].Revision
+	})
+
+	return history, status, nil
+}
+
+// newHistoryClient creates a client for the gateway given by the flags or
+// the stack file
+func newHistoryClient() (*proxy.Client, error) {
+	var yamlGateway string
+	if len(yamlFile) > 0 {
+		parsedServices, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
+		if err != nil {
+			return nil, err
+		}
+
+		if parsedServices != nil {
+			yamlGateway = parsedServices.Provider.GatewayURL
+		}
+	}
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, yamlGateway, os.Getenv(openFaaSURLEnvironment))
+
+	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
+	if err != nil {
+		return nil, err
+	}
+	transport := GetDefaultCLITransport(tlsInsecure, &commandTimeout)
+	client, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &commandTimeout)
+	if err != nil {
+		return nil, err
+	}
+	client.Retry = newRetryPolicy()
+
+	return client, nil
+}
@@ -0,0 +1,150 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/test"
+	"github.com/openfaas/faas-provider/types"
+)
+
+func Test_stampRevision(t *testing.T) {
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+
+	annotations := map[string]string{
+		revisionAnnotation:           "2",
+		revisionImageAnnotation:      "figlet:0.2",
+		revisionDeployedAtAnnotation: "2024-01-02T10:00:00Z",
+		revisionHistoryAnnotation:    `[{"revision":1,"image":"figlet:0.1","deployedAt":"2024-01-01T10:00:00Z"}]`,
+	}
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:       http.MethodGet,
+			Uri:          "/system/function/figlet?usage=1",
+			ResponseBody: types.FunctionStatus{Name: "figlet", Annotations: &annotations},
+		},
+	})
+	defer s.Close()
+
+	client := newTestClient(t, s.URL)
+	spec := &proxy.DeployFunctionSpec{
+		FunctionName: "figlet",
+		Image:        "figlet:0.3",
+		Annotations:  map[string]string{"topic": "cron"},
+	}
+
+	deployed := stampRevision(context.Background(), client, spec)
+
+	if deployed.Revision != 3 {
+		t.Fatalf("want revision 3, got %d", deployed.Revision)
+	}
+	if spec.Annotations[revisionAnnotation] != "3" || spec.Annotations[revisionImageAnnotation] != "figlet:0.3" {
+		t.Fatalf("revision annotations not set: %v", spec.Annotations)
+	}
+	if spec.Annotations["topic"] != "cron" {
+		t.Fatalf("want existing annotations to be kept, got: %v", spec.Annotations)
+	}
+
+	var history []revision
+	if err := json.Unmarshal([]byte(spec.Annotations[revisionHistoryAnnotation]), &history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Image != "figlet:0.1" || history[1].Image != "figlet:0.2" {
+		t.Fatalf("want revisions 1 and 2 in the history annotation, got: %+v", history)
+	}
+}
+
+func Test_recordRevision(t *testing.T) {
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+
+	gatewayURL, _ := url.Parse("http://127.0.0.1:8080")
+	for i := 1; i <= maxLocalRevisions+2; i++ {
+		spec := proxy.DeployFunctionSpec{
+			FunctionName: "figlet",
+			Image:        "figlet",
+			Token:        "secret-token",
+			Annotations:  map[string]string{revisionAnnotation: "1", "topic": "cron"},
+		}
+		if err := recordRevision(gatewayURL, revision{Revision: i, Image: "figlet"}, spec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := readLocalHistory(gatewayURL, "", "figlet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history) != maxLocalRevisions || history[0].Revision != 3 {
+		t.Fatalf("want the last %d revisions, got %d starting at %d", maxLocalRevisions, len(history), history[0].Revision)
+	}
+
+	spec := history[0].Spec
+	if spec.Token != "" {
+		t.Errorf("want the token to be removed from the history")
+	}
+	if _, ok := spec.Annotations[revisionAnnotation]; ok || spec.Annotations["topic"] != "cron" {
+		t.Errorf("want only the revision annotations removed, got: %v", spec.Annotations)
+	}
+}
+
+func Test_history(t *testing.T) {
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+
+	annotations := map[string]string{
+		revisionAnnotation:           "2",
+		revisionImageAnnotation:      "figlet:0.2",
+		revisionGitSHAAnnotation:     "b2c3d4e",
+		revisionDeployedAtAnnotation: "2024-01-02T10:00:00Z",
+		revisionDeployedByAnnotation: "alex",
+		revisionHistoryAnnotation:    `[{"revision":1,"image":"figlet:0.1","deployedAt":"2024-01-01T10:00:00Z"}]`,
+	}
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:       http.MethodGet,
+			Uri:          "/system/function/figlet?usage=1",
+			ResponseBody: types.FunctionStatus{Name: "figlet", Annotations: &annotations},
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"history", "figlet",
+			"--gateway=" + s.URL,
+		})
+		forgeCmd.Execute()
+	})
+
+	want := `REVISION    IMAGE      GIT SHA DEPLOYED AT          DEPLOYED BY
+1           figlet:0.1         2024-01-01T10:00:00Z 
+2 (current) figlet:0.2 b2c3d4e 2024-01-02T10:00:00Z alex
+`
+	if stdOut != want {
+		t.Fatalf("want:\n%s\ngot:\n%s", want, stdOut)
+	}
+}
+
+func newTestClient(t *testing.T, gatewayURL string) *proxy.Client {
+	t.Helper()
+
+	cliAuth, err := proxy.NewCLIAuth("", gatewayURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := proxy.NewClient(cliAuth, gatewayURL, nil, &commandTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
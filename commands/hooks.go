@@ -0,0 +1,174 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/stack"
+)
+
+const (
+	preBuildHook   = "pre_build"
+	postBuildHook  = "post_build"
+	preDeployHook  = "pre_deploy"
+	postDeployHook = "post_deploy"
+
+	// maxSmokeTestBody is how much of an unexpected response is printed
+	maxSmokeTestBody = 256
+)
+
+// runHook runs a hook with "sh -c" from the current directory. The hook
+// inherits the CLI's environment, with the function's environment on top,
+// and writes to stdout and stderr.
+func runHook(functionName, hook, command string, environment map[string]string, stdout, stderr io.Writer) error {
+	if len(strings.TrimSpace(command)) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(stdout, "Running %s hook of %s: %s\n", hook, functionName, command)
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Env = os.Environ()
+
+	keys := make([]string, 0, len(environment))
+	for k := range environment {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		cmd.Env = append(cmd.Env, k+"="+environment[k])
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook of %s failed: %w", hook, functionName, err)
+	}
+	return nil
+}
+
+// hookEnvironment is the environment a hook runs with, on top of the CLI's.
+// Every hook gets the function's environment, its FUNCTION_NAME,
+// FUNCTION_IMAGE and FUNCTION_NAMESPACE, and the gateway as OPENFAAS_URL.
+// Build hooks get the values from the stack file, deploy hooks get the values
+// which were deployed, after --env, --tag, --namespace and --canary.
+func hookEnvironment(functionName, image, namespace, gatewayURL string, functionEnvironment map[string]string) map[string]string {
+	environment := make(map[string]string, len(functionEnvironment)+4)
+	for k, v := range functionEnvironment {
+		environment[k] = v
+	}
+
+	environment["FUNCTION_NAME"] = functionName
+	environment["FUNCTION_IMAGE"] = image
+	environment["FUNCTION_NAMESPACE"] = namespace
+	environment[openFaaSURLEnvironment] = gatewayURL
+	return environment
+}
+
+// buildWithHooks runs the function's pre_build hook, build and then its
+// post_build hook
+func buildWithHooks(function stack.Function, gatewayURL string, build func() error) error {
+	if function.Hooks == nil {
+		return build()
+	}
+
+	fileEnvironment, err := readFiles(function.EnvironmentFile)
+	if err != nil {
+		return err
+	}
+
+	functionEnvironment, err := compileEnvironment(nil, function.Environment, fileEnvironment)
+	if err != nil {
+		return err
+	}
+
+	environment := hookEnvironment(function.Name, function.Image, function.Namespace, gatewayURL, functionEnvironment)
+
+	if err := runHook(function.Name, preBuildHook, function.Hooks.PreBuild, environment, os.Stdout, os.Stderr); err != nil {
+		return err
+	}
+	if err := build(); err != nil {
+		return err
+	}
+	return runHook(function.Name, postBuildHook, function.Hooks.PostBuild, environment, os.Stdout, os.Stderr)
+}
+
+// runSmokeTest makes the smoke test's request to the deployed function
+func runSmokeTest(gatewayURL, functionName, namespace string, smokeTest stack.SmokeTest, out io.Writer) error {
+	method := strings.ToUpper(smokeTest.Method)
+	if len(method) == 0 {
+		method = http.MethodGet
+	}
+
+	expectedStatus := smokeTest.ExpectedStatus
+	if expectedStatus == 0 {
+		expectedStatus = http.StatusOK
+	}
+
+	var expectedBody *regexp.Regexp
+	if len(smokeTest.ExpectedBody) > 0 {
+		var err error
+		if expectedBody, err = regexp.Compile(smokeTest.ExpectedBody); err != nil {
+			return fmt.Errorf("invalid expected_body in the smoke test of %s: %w", functionName, err)
+		}
+	}
+
+	path := smokeTest.Path
+	if len(path) > 0 && !strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "?") {
+		path = "/" + path
+	}
+
+	target := strings.TrimRight(gatewayURL, "/") + "/function/" + functionName
+	if len(namespace) > 0 {
+		target += "." + namespace
+	}
+	target += path
+
+	req, err := http.NewRequest(method, target, bytes.NewBufferString(smokeTest.Body))
+	if err != nil {
+		return fmt.Errorf("smoke test of %s failed: %w", functionName, err)
+	}
+
+	client := proxy.MakeHTTPClient(&commandTimeout, tlsInsecure)
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("smoke test of %s failed: %w", functionName, err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("smoke test of %s failed: %w", functionName, err)
+	}
+
+	if res.StatusCode != expectedStatus {
+		return fmt.Errorf("smoke test of %s failed: %s %s returned %d, want %d: %s",
+			functionName, method, target, res.StatusCode, expectedStatus, truncateBody(body))
+	}
+	if expectedBody != nil && !expectedBody.Match(body) {
+		return fmt.Errorf("smoke test of %s failed: %s %s returned a body which does not match %q: %s",
+			functionName, method, target, smokeTest.ExpectedBody, truncateBody(body))
+	}
+
+	fmt.Fprintf(out, "Smoke test of %s passed: %s %s returned %d.\n", functionName, method, target, res.StatusCode)
+	return nil
+}
+
+func truncateBody(body []byte) string {
+	text := strings.TrimSpace(string(body))
+	if len(text) > maxSmokeTestBody {
+		return text[:maxSmokeTestBody] + "..."
+	}
+	return text
+}
@@ -0,0 +1,235 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/forge4flow/forge-cli/stack"
+	"github.com/forge4flow/forge-cli/test"
+	"github.com/openfaas/faas-provider/types"
+)
+
+func Test_runHook(t *testing.T) {
+	environment := map[string]string{"FUNCTION_NAME": "api", "GREETING": "hi"}
+
+	test.CaptureStdout(func() {
+		if err := runHook("api", preDeployHook, `test "$FUNCTION_NAME" = api && test "$GREETING" = hi`, environment, os.Stdout, os.Stderr); err != nil {
+			t.Errorf("want the hook to see the function's environment, got: %s", err)
+		}
+
+		err := runHook("api", preDeployHook, "exit 3", environment, os.Stdout, os.Stderr)
+		if err == nil || !strings.Contains(err.Error(), "pre_deploy hook of api failed") {
+			t.Errorf("want the hook to fail, got: %v", err)
+		}
+
+		if err := runHook("api", postDeployHook, " ", environment, os.Stdout, os.Stderr); err != nil {
+			t.Errorf("want an empty hook to be skipped, got: %s", err)
+		}
+	})
+}
+
+func Test_buildWithHooks(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "hooks")
+	function := stack.Function{
+		Name:        "api",
+		Image:       "api:0.1",
+		Environment: map[string]string{"GREETING": "hi"},
+		Hooks: &stack.FunctionHooks{
+			PreBuild:  `echo "pre $FUNCTION_IMAGE $OPENFAAS_URL $GREETING" >> ` + out,
+			PostBuild: `echo post >> ` + out,
+		},
+	}
+
+	test.CaptureStdout(func() {
+		err := buildWithHooks(function, "http://127.0.0.1:8080", func() error {
+			f, err := os.OpenFile(out, os.O_APPEND|os.O_WRONLY, 0600)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = f.WriteString("build\n")
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "pre api:0.1 http://127.0.0.1:8080 hi\nbuild\npost\n"; string(got) != want {
+		t.Fatalf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func Test_runSmokeTest(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/function/api.staging/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == http.MethodPost && string(body) == "ping" {
+			w.Write([]byte("pong"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer s.Close()
+
+	cases := []struct {
+		name      string
+		smokeTest stack.SmokeTest
+		wantErr   string
+	}{
+		{
+			name:      "default GET and status",
+			smokeTest: stack.SmokeTest{Path: "healthz"},
+		},
+		{
+			name:      "body matches",
+			smokeTest: stack.SmokeTest{Method: "post", Path: "/healthz", Body: "ping", ExpectedBody: "^po.g$"},
+		},
+		{
+			name:      "body does not match",
+			smokeTest: stack.SmokeTest{Path: "/healthz", ExpectedBody: "pong"},
+			wantErr:   `returned a body which does not match "pong": ok`,
+		},
+		{
+			name:      "unexpected status",
+			smokeTest: stack.SmokeTest{Path: "/missing"},
+			wantErr:   "returned 404, want 200",
+		},
+		{
+			name:      "invalid expression",
+			smokeTest: stack.SmokeTest{ExpectedBody: "("},
+			wantErr:   "invalid expected_body in the smoke test of api",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var err error
+			test.CaptureStdout(func() {
+				err = runSmokeTest(s.URL, "api", "staging", tc.smokeTest, os.Stdout)
+			})
+
+			if len(tc.wantErr) == 0 && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(tc.wantErr) > 0 && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("want error containing %q, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_deployStack_SmokeTestRollback(t *testing.T) {
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+	defer setRolloutPollInterval(time.Millisecond)()
+
+	stackFile := filepath.Join(t.TempDir(), "stack.yml")
+	err := os.WriteFile(stackFile, []byte(`provider:
+  name: functions4flow
+
+functions:
+  api:
+    lang: dockerfile
+    image: api:0.2
+    hooks:
+      pre_deploy: test "$FUNCTION_IMAGE" = api:0.2
+    smoke_test:
+      path: /healthz
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := map[string]string{
+		revisionAnnotation:      "1",
+		revisionImageAnnotation: "api:0.1",
+	}
+	history, _ := json.Marshal([]revision{{Revision: 1, Image: "api:0.1"}})
+	deployed := map[string]string{
+		revisionAnnotation:        "2",
+		revisionImageAnnotation:   "api:0.2",
+		revisionHistoryAnnotation: string(history),
+	}
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:       http.MethodGet,
+			Uri:          "/system/function/api?usage=1",
+			ResponseBody: types.FunctionStatus{Name: "api", Image: "api:0.1", Annotations: &previous},
+		},
+		{
+			Method: http.MethodPut,
+			Uri:    "/system/functions",
+		},
+		{
+			Method:       http.MethodGet,
+			Uri:          "/system/function/api?usage=1",
+			ResponseBody: types.FunctionStatus{Name: "api", Image: "api:0.2", Replicas: 1, AvailableReplicas: 1},
+		},
+		{
+			Method:             http.MethodGet,
+			Uri:                "/function/api/healthz",
+			ResponseStatusCode: http.StatusInternalServerError,
+		},
+		{
+			Method:       http.MethodGet,
+			Uri:          "/system/function/api?usage=1",
+			ResponseBody: types.FunctionStatus{Name: "api", Image: "api:0.2", Annotations: &deployed},
+		},
+		{
+			Method:       http.MethodGet,
+			Uri:          "/system/function/api?usage=1",
+			ResponseBody: types.FunctionStatus{Name: "api", Image: "api:0.2", Annotations: &deployed},
+		},
+		{
+			Method: http.MethodPut,
+			Uri:    "/system/functions",
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+	defer resetForTest()
+	defer func() { deployFlags.rollbackOnFailure = false }()
+
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"deploy",
+			"--gateway=" + s.URL,
+			"--yaml=" + stackFile,
+			"--rollback-on-failure",
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err == nil {
+		t.Fatalf("want an error as the smoke test failed")
+	}
+	if want := "returned 500, want 200: , rolled back to revision 1"; !strings.Contains(err.Error(), want) {
+		t.Fatalf("want error containing %q, got: %s", want, err)
+	}
+
+	for _, want := range []string{"Running pre_deploy hook of api", "Rolling back api to revision 1 (api:0.1)."} {
+		if !strings.Contains(stdOut, want) {
+			t.Fatalf("want %q in the output, got:\n%s", want, stdOut)
+		}
+	}
+}
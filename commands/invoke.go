@@ -4,10 +4,17 @@
 package commands
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/alexellis/hmac"
 	"github.com/forge4flow/forge-cli/proxy"
@@ -24,8 +31,20 @@ var (
 	sigHeader               string
 	key                     string
 	functionInvokeNamespace string
+	invokeStream            bool
+	invokeOutputFile        string
+	invokeInclude           bool
+	invokeVerbose           bool
+	invokeFail              bool
+	invokeCallbackLocal     bool
+	invokeCallbackListen    string
+	invokeCallbackURL       string
+	invokeCallbackTimeout   time.Duration
 )
 
+// invokeBufferSize is the size of the writes of a response without --stream
+const invokeBufferSize = 64 * 1024
+
 func init() {
 	// Setup flags that are used by multiple commands (variables defined in faas.go)
 	invokeCmd.Flags().StringVar(&functionName, "name", "", "Name of the deployed function")
@@ -42,15 +61,40 @@ func init() {
 	invokeCmd.Flags().StringVar(&sigHeader, "sign", "", "name of HTTP request header to hold the signature")
 	invokeCmd.Flags().StringVar(&key, "key", "", "key to be used to sign the request (must be used with --sign)")
 
+	invokeCmd.Flags().BoolVar(&invokeStream, "stream", false, "Print the response as each chunk arrives, for server-sent events and chunked responses")
+	invokeCmd.Flags().BoolVarP(&invokeInclude, "include", "i", false, "Print the response's status line and headers before its body")
+	invokeCmd.Flags().BoolVarP(&invokeVerbose, "verbose", "v", false, "Print the request, the response's headers and timing to STDERR")
+	invokeCmd.Flags().BoolVar(&invokeFail, "fail", true, "Exit with an error when the function returns a non-2xx status, use --fail=false to print the response")
+	invokeCmd.Flags().BoolVar(&invokeCallbackLocal, "callback-local", false, "Receive the result of an --async invocation on a local HTTP listener and print it")
+	invokeCmd.Flags().StringVar(&invokeCallbackListen, "callback-listen", "0.0.0.0:0", "Address of the --callback-local listener, a random port by default")
+	invokeCmd.Flags().StringVar(&invokeCallbackURL, "callback-url", "", "URL the gateway calls back on, such as a tunnel to the --callback-listen address")
+	invokeCmd.Flags().DurationVar(&invokeCallbackTimeout, "callback-timeout", 2*time.Minute, "How long --callback-local waits for the callback")
+	invokeCmd.Flags().StringVar(&invokeOutputFile, "output-file", "", "Write the response to a file, such as a binary image, rather than to STDOUT")
+
 	invokeCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
 
 	forgeCmd.AddCommand(invokeCmd)
 }
 
 var invokeCmd = &cobra.Command{
-	Use:   `invoke FUNCTION_NAME [--gateway GATEWAY_URL] [--content-type CONTENT_TYPE] [--query PARAM=VALUE] [--header PARAM=VALUE] [--method HTTP_METHOD]`,
+	Use:   `invoke FUNCTION_NAME [--gateway GATEWAY_URL] [--content-type CONTENT_TYPE] [--query PARAM=VALUE] [--header PARAM=VALUE] [--method HTTP_METHOD] [--stream] [--output-file FILE] [-i] [-v]`,
 	Short: "Invoke an Forge4Flow function",
-	Long:  `Invokes an Forge4Flow function and reads from STDIN for the body of the request`,
+	Long: `Invokes an Forge4Flow function and reads from STDIN for the body of the request.
+
+The request body is streamed from STDIN and the response is streamed to STDOUT,
+or to --output-file, so large files are not held in memory. Use --stream to
+print each chunk of a long-running response, such as server-sent events, as
+soon as it arrives, the request headers are left as they are so give
+--header Accept=text/event-stream when the function needs it. Signing the request with --sign reads all of STDIN first.
+
+Use --include to print the status and headers of the response, such as
+X-Duration-Seconds, and --verbose to also print the request and the time taken
+to resolve, connect, complete the TLS handshake and receive the first byte.
+
+With --async --callback-local, the result of the invocation is received on a
+temporary HTTP listener, given to the function as its X-Callback-Url. The gateway
+must be able to reach the listener, use --callback-url with a tunnel when it
+can't reach this machine directly.`,
 	Example: `  forge-cli invoke echo --gateway https://host:port
   forge-cli invoke echo --gateway https://host:port --content-type application/json
   forge-cli invoke env --query repo=forge-cli --query org=openfaas
@@ -58,7 +102,14 @@ var invokeCmd = &cobra.Command{
   forge-cli invoke resize-img --async -H "X-Callback-Url=http://gateway:8080/function/send2slack" < image.png
   forge-cli invoke env -H X-Ping-Url=http://request.bin/etc
   forge-cli invoke flask --method GET --namespace dev
-  forge-cli invoke env --sign X-GitHub-Event --key yoursecret`,
+  forge-cli invoke env --sign X-GitHub-Event --key yoursecret
+  forge-cli invoke resize-img --output-file thumbnail.png < image.png
+  forge-cli invoke events --method GET --stream -H Accept=text/event-stream
+  forge-cli invoke env --include
+  forge-cli invoke env --verbose --fail=false
+  forge-cli invoke resize-img --async --callback-local < image.png
+  forge-cli invoke resize-img --async --callback-local --callback-listen :9000 \
+    --callback-url https://tunnel.example.com/ < image.png`,
 	RunE: runInvoke,
 }
 
@@ -73,11 +124,18 @@ func runInvoke(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("signing requires both --sign <header-value> and --key <key-value>")
 	}
 
+	if invokeCallbackLocal && !invokeAsync {
+		return fmt.Errorf("--callback-local can only be used with --async")
+	}
+	if invokeCallbackLocal && hasHeader(headers, "X-Callback-Url") {
+		return fmt.Errorf("give --callback-local or an X-Callback-Url header, not both")
+	}
+
 	var yamlGateway string
 	functionName = args[0]
 
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
 		if err != nil {
 			return err
 		}
@@ -95,31 +153,220 @@ func runInvoke(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(os.Stderr, "Reading from STDIN - hit (Control + D) to stop.\n")
 	}
 
-	functionInput, err := io.ReadAll(os.Stdin)
-	if err != nil {
-		return fmt.Errorf("unable to read standard input: %s", err.Error())
-	}
+	requestHeaders := append([]string{}, headers...)
 
+	// the request body is streamed from STDIN, unless it has to be signed
+	var functionInput io.Reader = os.Stdin
 	if len(sigHeader) > 0 {
-		signedHeader, err := generateSignedHeader(functionInput, key, sigHeader)
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("unable to read standard input: %s", err.Error())
+		}
+
+		signedHeader, err := generateSignedHeader(input, key, sigHeader)
 		if err != nil {
 			return fmt.Errorf("unable to sign message: %s", err.Error())
 		}
-		headers = append(headers, signedHeader)
+		requestHeaders = append(requestHeaders, signedHeader)
+		functionInput = bytes.NewReader(input)
+	}
+
+	var receiver *callbackReceiver
+	if invokeCallbackLocal {
+		var err error
+		if receiver, err = newCallbackReceiver(invokeCallbackListen, invokeCallbackURL, gatewayAddress); err != nil {
+			return err
+		}
+		defer receiver.Close()
+
+		requestHeaders = append(requestHeaders, "X-Callback-Url="+receiver.url)
 	}
 
-	response, err := proxy.InvokeFunction(gatewayAddress, functionName, &functionInput, contentType, query, headers, invokeAsync, httpMethod, tlsInsecure, functionInvokeNamespace)
+	req, err := proxy.NewInvokeRequest(gatewayAddress, functionName, functionInput, contentType, query, requestHeaders, invokeAsync, httpMethod, getNamespace(functionInvokeNamespace, ""))
 	if err != nil {
 		return err
 	}
 
-	if response != nil {
-		os.Stdout.Write(*response)
+	out, closeOutput, err := invokeOutput(invokeOutputFile, invokeStream)
+	if err != nil {
+		return err
+	}
+
+	if invokeVerbose {
+		printInvokeRequest(os.Stderr, req)
+	}
+
+	result, err := proxy.Invoke(req, out, proxy.InvokeOptions{
+		TLSInsecure: tlsInsecure,
+		AnyStatus:   !invokeFail,
+		OnResponse: func(result *proxy.InvokeResult) error {
+			if invokeVerbose {
+				printInvokeResponse(os.Stderr, "< ", result)
+			}
+			if invokeInclude {
+				printInvokeResponse(out, "", result)
+			}
+			return nil
+		},
+	})
+	if err == nil && receiver != nil && result.StatusCode == http.StatusAccepted {
+		err = receiveCallback(receiver, result.CallID, out)
+	}
+	if closeErr := closeOutput(err); err == nil {
+		err = closeErr
+	}
+
+	if invokeVerbose && result != nil {
+		printInvokeTiming(os.Stderr, result.Timing)
+	}
+	return err
+}
+
+// receiveCallback waits for the result of an async invocation and writes its
+// body to out
+func receiveCallback(receiver *callbackReceiver, callID string, out io.Writer) error {
+	fmt.Fprintf(os.Stderr, "Waiting up to %s for the callback on %s\n", invokeCallbackTimeout, receiver.url)
+
+	callback, err := receiver.wait(callID, invokeCallbackTimeout)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Callback received, call ID: %s, function status: %d\n", callback.callID, callback.statusCode)
+	if invokeInclude {
+		fmt.Fprintf(out, "X-Function-Status: %d\n", callback.statusCode)
+		for _, name := range sortedHeaderNames(callback.header) {
+			if name == "X-Function-Status" {
+				continue
+			}
+			for _, value := range callback.header[name] {
+				fmt.Fprintf(out, "%s: %s\n", name, value)
+			}
+		}
+		fmt.Fprintln(out)
+	}
+
+	if _, err := out.Write(callback.body); err != nil {
+		return err
 	}
 
+	if invokeFail && (callback.statusCode < 200 || callback.statusCode >= 300) {
+		return fmt.Errorf("function %s returned status %d to the callback", functionName, callback.statusCode)
+	}
 	return nil
 }
 
+// printInvokeRequest prints the request line and headers like curl --verbose
+func printInvokeRequest(w io.Writer, req *http.Request) {
+	fmt.Fprintf(w, "> %s %s\n", req.Method, req.URL.String())
+	for _, name := range sortedHeaderNames(req.Header) {
+		for _, value := range req.Header[name] {
+			fmt.Fprintf(w, "> %s: %s\n", name, value)
+		}
+	}
+	fmt.Fprintln(w, ">")
+}
+
+// printInvokeResponse prints the status line and headers of a response, each
+// line is prefixed with prefix
+func printInvokeResponse(w io.Writer, prefix string, result *proxy.InvokeResult) {
+	fmt.Fprintf(w, "%s%s %s\n", prefix, result.Proto, result.Status)
+	for _, name := range sortedHeaderNames(result.Header) {
+		for _, value := range result.Header[name] {
+			fmt.Fprintf(w, "%s%s: %s\n", prefix, name, value)
+		}
+	}
+	fmt.Fprintln(w, strings.TrimSpace(prefix))
+}
+
+func printInvokeTiming(w io.Writer, timing proxy.InvokeTiming) {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "Timing:")
+	fmt.Fprintf(tw, "  DNS lookup:\t%s\n", timing.DNS)
+	fmt.Fprintf(tw, "  Connect:\t%s\n", timing.Connect)
+	fmt.Fprintf(tw, "  TLS handshake:\t%s\n", timing.TLS)
+	fmt.Fprintf(tw, "  First byte:\t%s\n", timing.FirstByte)
+	fmt.Fprintf(tw, "  Total:\t%s\n", timing.Total)
+	tw.Flush()
+}
+
+func sortedHeaderNames(header http.Header) []string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// invokeOutput returns where the response is written. Without --stream the
+// response is written in large blocks, with --stream each chunk is written
+// as soon as it arrives. The close function removes the output file when the
+// invocation failed.
+func invokeOutput(outputFile string, stream bool) (io.Writer, func(error) error, error) {
+	if len(outputFile) == 0 {
+		if stream {
+			return os.Stdout, func(error) error { return nil }, nil
+		}
+
+		w := bufio.NewWriterSize(os.Stdout, invokeBufferSize)
+		return w, func(error) error { return w.Flush() }, nil
+	}
+
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create --output-file: %w", err)
+	}
+
+	counter := &countingWriter{w: file}
+	var w io.Writer = counter
+	var buffered *bufio.Writer
+	if !stream {
+		buffered = bufio.NewWriterSize(counter, invokeBufferSize)
+		w = buffered
+	}
+
+	return w, func(invokeErr error) error {
+		var err error
+		if buffered != nil {
+			err = buffered.Flush()
+		}
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+
+		if invokeErr != nil || err != nil {
+			os.Remove(outputFile)
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Wrote %d bytes to %s.\n", counter.n, outputFile)
+		return nil
+	}, nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func hasHeader(headers []string, name string) bool {
+	for _, header := range headers {
+		if strings.EqualFold(strings.SplitN(header, "=", 2)[0], name) {
+			return true
+		}
+	}
+	return false
+}
+
 func generateSignedHeader(message []byte, key string, headerName string) (string, error) {
 
 	if len(headerName) == 0 {
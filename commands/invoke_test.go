@@ -4,8 +4,12 @@
 package commands
 
 import (
+	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -199,3 +203,209 @@ func Test_missingSignFlag(t *testing.T) {
 		})
 	}
 }
+
+func Test_invoke_outputFile(t *testing.T) {
+	response := string([]byte{0x89, 'P', 'N', 'G', 0x00, 0x01})
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "image-data" || r.ContentLength != int64(len(body)) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	defer s.Close()
+
+	os.Stdin, _ = ioutil.TempFile("", "stdin")
+	os.Stdin.WriteString("image-data")
+	os.Stdin.Seek(0, 0)
+	defer func() {
+		os.Remove(os.Stdin.Name())
+	}()
+
+	outputFile := filepath.Join(t.TempDir(), "out.png")
+	defer func() { invokeOutputFile = "" }()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"invoke",
+			"--gateway=" + s.URL,
+			"--output-file=" + outputFile,
+			"resize-img",
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stdOut != "" {
+		t.Fatalf("want nothing written to STDOUT, got: %q", stdOut)
+	}
+
+	got, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != response {
+		t.Fatalf("want: %q, got: %q", response, got)
+	}
+}
+
+func Test_invoke_outputFileRemovedOnError(t *testing.T) {
+	s := test.MockHttpServerStatus(t, http.StatusInternalServerError)
+	defer s.Close()
+
+	os.Stdin, _ = ioutil.TempFile("", "stdin")
+	defer func() {
+		os.Remove(os.Stdin.Name())
+	}()
+
+	outputFile := filepath.Join(t.TempDir(), "out.png")
+	defer func() { invokeOutputFile = "" }()
+
+	var err error
+	test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"invoke",
+			"--gateway=" + s.URL,
+			"--output-file=" + outputFile,
+			"resize-img",
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err == nil {
+		t.Fatalf("want an error for the 500 response")
+	}
+	if _, statErr := os.Stat(outputFile); !os.IsNotExist(statErr) {
+		t.Fatalf("want %s to be removed, got: %v", outputFile, statErr)
+	}
+}
+
+func Test_invoke_stream(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "data: %d\n\n", i)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer s.Close()
+
+	os.Stdin, _ = ioutil.TempFile("", "stdin")
+	defer func() {
+		os.Remove(os.Stdin.Name())
+	}()
+
+	defer func() {
+		invokeStream = false
+		httpMethod = http.MethodPost
+	}()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"invoke",
+			"--gateway=" + s.URL,
+			"--method=GET",
+			"--stream",
+			"events",
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := "data: 0\n\ndata: 1\n\ndata: 2\n\n"; stdOut != want {
+		t.Fatalf("want: %q, got: %q", want, stdOut)
+	}
+}
+
+func Test_invoke_include(t *testing.T) {
+	invokeAsync = false
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Duration-Seconds", "0.015")
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not here"))
+	}))
+	defer s.Close()
+
+	os.Stdin, _ = ioutil.TempFile("", "stdin")
+	defer func() {
+		os.Remove(os.Stdin.Name())
+	}()
+
+	defer func() {
+		invokeInclude = false
+		invokeFail = true
+	}()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"invoke",
+			"--gateway=" + s.URL,
+			"--include",
+			"--fail=false",
+			"env",
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err != nil {
+		t.Fatalf("want no error with --fail=false, got: %s", err)
+	}
+
+	for _, want := range []string{"HTTP/1.1 404 Not Found\n", "X-Duration-Seconds: 0.015\n", "\n\nnot here"} {
+		if !strings.Contains(stdOut, want) {
+			t.Fatalf("want %q in the output, got:\n%s", want, stdOut)
+		}
+	}
+}
+
+func Test_invoke_fail(t *testing.T) {
+	invokeAsync = false
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodPost,
+			Uri:                "/function/env",
+			ResponseStatusCode: http.StatusInternalServerError,
+			ResponseBody:       "broken",
+		},
+	})
+	defer s.Close()
+
+	os.Stdin, _ = ioutil.TempFile("", "stdin")
+	defer func() {
+		os.Remove(os.Stdin.Name())
+	}()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"invoke",
+			"--gateway=" + s.URL,
+			"env",
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("want an error with the response body, got: %v", err)
+	}
+	if stdOut != "" {
+		t.Fatalf("want nothing written to STDOUT, got: %q", stdOut)
+	}
+}
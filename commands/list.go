@@ -29,9 +29,11 @@ func init() {
 
 	listCmd.Flags().BoolVarP(&verboseList, "verbose", "v", false, "Verbose output for the function list")
 	listCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(listCmd.Flags())
 	listCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
 	listCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
 	listCmd.Flags().StringVar(&sortOrder, "sort", "name", "Sort the functions by \"name\" or \"invocations\"")
+	addOutputFlag(listCmd.Flags())
 
 	forgeCmd.AddCommand(listCmd)
 }
@@ -42,7 +44,9 @@ var listCmd = &cobra.Command{
 	Short:   "List Forge4Flow functions",
 	Long:    `Lists Forge4Flow functions either on a local or remote gateway`,
 	Example: `  forge-cli list
-  forge-cli list --gateway https://127.0.0.1:8080 --verbose`,
+  forge-cli list --gateway https://127.0.0.1:8080 --verbose
+  forge-cli list --output yaml
+  forge-cli list -o template='{{range .}}{{.name}} {{.replicas}}{{"\n"}}{{end}}'`,
 	RunE: runList,
 }
 
@@ -51,7 +55,7 @@ func runList(cmd *cobra.Command, args []string) error {
 	var gatewayAddress string
 	var yamlGateway string
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
 		if err != nil {
 			return err
 		}
@@ -72,8 +76,9 @@ func runList(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	proxyClient.Retry = newRetryPolicy()
 
-	functions, err := proxyClient.ListFunctions(context.Background(), functionNamespace)
+	functions, err := proxyClient.ListFunctions(context.Background(), getNamespace(functionNamespace, ""))
 	if err != nil {
 		return err
 	}
@@ -86,6 +91,13 @@ func runList(cmd *cobra.Command, args []string) error {
 		sort.Sort(byCreation(functions))
 	}
 
+	if structuredOutput() {
+		if functions == nil {
+			functions = []types.FunctionStatus{}
+		}
+		return printOutput(functions)
+	}
+
 	if quiet {
 		for _, function := range functions {
 			fmt.Printf("%s\n", function.Name)
@@ -78,3 +78,35 @@ func Test_list_errors(t *testing.T) {
 		t.Fatal("No error found while testing missing yaml")
 	}
 }
+
+func Test_list_output(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody: []types.FunctionStatus{
+				{Name: "function-test-2", Replicas: 3},
+				{Name: "function-test-1", Replicas: 1},
+			},
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"list",
+			"--gateway=" + s.URL,
+			"--output", "jsonpath={range [*]}{.name}={.replicas}{\"\\n\"}{end}",
+		})
+		forgeCmd.Execute()
+	})
+	resetForTest()
+
+	want := "function-test-1=1\nfunction-test-2=3\n"
+	if stdOut != want {
+		t.Fatalf("want:\n%q\ngot:\n%q", want, stdOut)
+	}
+}
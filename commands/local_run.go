@@ -153,7 +153,7 @@ func runFunction(ctx context.Context, name string, opts runOptions, args []strin
 	var services *stack.Services
 
 	if len(name) == 0 {
-		s, err := stack.ParseYAMLFile(yamlFile, "", "", true)
+		s, err := stack.ParseYAMLFiles(yamlFiles(), "", "", true)
 		if err != nil {
 			return err
 		}
@@ -181,7 +181,7 @@ func runFunction(ctx context.Context, name string, opts runOptions, args []strin
 			break
 		}
 	} else {
-		s, err := stack.ParseYAMLFile(yamlFile, "", name, true)
+		s, err := stack.ParseYAMLFiles(yamlFiles(), "", name, true)
 		if err != nil {
 			return err
 		}
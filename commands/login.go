@@ -31,6 +31,15 @@ func init() {
 	loginCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
 	loginCmd.Flags().Duration("timeout", time.Second*5, "Override the timeout for this API call")
 
+	loginCmd.Flags().BoolVar(&oauth2Login, "oauth2", false, "Log in with an OAuth2 identity provider instead of basic auth")
+	loginCmd.Flags().StringVar(&oauth2Grant, "grant", deviceCodeGrant, "OAuth2 grant to use with --oauth2, \"device-code\" or \"client-credentials\"")
+	loginCmd.Flags().StringVar(&oauth2Config.TokenURL, "token-url", "", "OAuth2 token endpoint of the identity provider")
+	loginCmd.Flags().StringVar(&oauth2Config.DeviceAuthURL, "device-auth-url", "", "OAuth2 device authorization endpoint of the identity provider")
+	loginCmd.Flags().StringVar(&oauth2Config.ClientID, "client-id", "", "OAuth2 client ID")
+	loginCmd.Flags().StringVar(&oauth2Config.ClientSecret, "client-secret", "", "OAuth2 client secret, required for client-credentials, saved to refresh tokens")
+	loginCmd.Flags().StringVar(&oauth2Config.Scope, "scope", "", "Space separated OAuth2 scopes to request")
+	loginCmd.Flags().StringVar(&oauth2Config.Audience, "audience", "", "OAuth2 audience to request with client-credentials")
+
 	forgeCmd.AddCommand(loginCmd)
 }
 
@@ -40,7 +49,12 @@ var loginCmd = &cobra.Command{
 	Long:  "Log in to Forge4Flow gateway.\nIf no gateway is specified, the default value will be used.",
 	Example: `  cat ~/forge_pass.txt | forge-cli login -u user --password-stdin
   echo $PASSWORD | forge-cli login -s  --gateway https://forge.mydomain.com
-  forge-cli login -u user -p password`,
+  forge-cli login -u user -p password
+  forge-cli login --oauth2 --client-id forge-cli \
+    --device-auth-url https://idp.example.com/oauth/device/code \
+    --token-url https://idp.example.com/oauth/token
+  echo $CLIENT_SECRET | forge-cli login --oauth2 --grant client-credentials \
+    --client-id ci --password-stdin --token-url https://idp.example.com/oauth/token`,
 	RunE: runLogin,
 }
 
@@ -51,6 +65,10 @@ func runLogin(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if oauth2Login {
+		return runOAuth2Login(timeout)
+	}
+
 	if len(username) == 0 {
 		return fmt.Errorf("must provide --username or -u")
 	}
@@ -117,6 +135,11 @@ func runLogin(cmd *cobra.Command, args []string) error {
 }
 
 func validateLogin(gatewayURL string, user string, pass string, timeout time.Duration, insecureTLS bool) error {
+	return validateGatewayAuth(gatewayURL, proxy.NewBasicAuth(user, pass), timeout, insecureTLS)
+}
+
+// validateGatewayAuth calls the gateway with the given credentials to check they are accepted
+func validateGatewayAuth(gatewayURL string, auth proxy.ClientAuth, timeout time.Duration, insecureTLS bool) error {
 
 	if len(checkTLSInsecure(gatewayURL, insecureTLS)) > 0 {
 		fmt.Println(NoTLSWarn)
@@ -128,7 +151,9 @@ func validateLogin(gatewayURL string, user string, pass string, timeout time.Dur
 		return fmt.Errorf("invalid URL: %s", gatewayURL)
 	}
 
-	req.SetBasicAuth(user, pass)
+	if err := auth.Set(req); err != nil {
+		return err
+	}
 	res, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("cannot connect to Forge4Flow on URL: %s. %v", gatewayURL, err)
@@ -0,0 +1,135 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/forge4flow/forge-cli/proxy"
+)
+
+const (
+	deviceCodeGrant        = "device-code"
+	clientCredentialsGrant = "client-credentials"
+)
+
+var (
+	oauth2Login  bool
+	oauth2Grant  string
+	oauth2Config proxy.OAuth2Config
+)
+
+// runOAuth2Login obtains a token from the identity provider and stores it,
+// along with its refresh token, for the gateway
+func runOAuth2Login(timeout time.Duration) error {
+	cfg := oauth2Config
+
+	if len(cfg.TokenURL) == 0 {
+		return fmt.Errorf("must provide --token-url with --oauth2")
+	}
+
+	if len(cfg.ClientID) == 0 {
+		return fmt.Errorf("must provide --client-id with --oauth2")
+	}
+
+	if passwordStdin {
+		secret, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		cfg.ClientSecret = strings.TrimSpace(string(secret))
+	}
+
+	gateway = getGatewayURL(gateway, defaultGateway, "", os.Getenv(openFaaSURLEnvironment))
+
+	tr := GetDefaultCLITransport(tlsInsecure, &timeout)
+	httpClient := &http.Client{Timeout: timeout}
+	if tr != nil {
+		httpClient.Transport = tr
+	}
+
+	ctx := context.Background()
+
+	var (
+		token *proxy.OAuth2Token
+		grant string
+		err   error
+	)
+
+	switch oauth2Grant {
+	case deviceCodeGrant:
+		grant = proxy.DeviceCodeGrant
+
+		auth, err := proxy.RequestDeviceAuthorization(ctx, httpClient, cfg)
+		if err != nil {
+			return err
+		}
+
+		printDeviceAuthorization(auth)
+
+		token, err = proxy.PollDeviceToken(ctx, httpClient, cfg, auth)
+		if err != nil {
+			return err
+		}
+	case clientCredentialsGrant:
+		grant = proxy.ClientCredentialsGrant
+
+		if len(cfg.ClientSecret) == 0 {
+			return fmt.Errorf("must provide --client-secret or --password-stdin with --grant %s", clientCredentialsGrant)
+		}
+
+		token, err = proxy.ClientCredentialsToken(ctx, httpClient, cfg)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown --grant %q, use %q or %q", oauth2Grant, deviceCodeGrant, clientCredentialsGrant)
+	}
+
+	fmt.Println("Calling the Forge4Flow server to validate the token...")
+
+	authConfig := config.AuthConfig{
+		Gateway: gateway,
+	}
+	authConfig.SetOption(proxy.OAuth2GrantOption, grant)
+	authConfig.SetOption(proxy.OAuth2TokenURLOption, cfg.TokenURL)
+	authConfig.SetOption(proxy.OAuth2ClientIDOption, cfg.ClientID)
+	authConfig.SetOption(proxy.OAuth2ScopeOption, cfg.Scope)
+	authConfig.SetOption(proxy.OAuth2AudienceOption, cfg.Audience)
+
+	// A confidential client needs its secret to request and refresh tokens
+	if len(cfg.ClientSecret) > 0 {
+		authConfig.SetOption(proxy.OAuth2ClientSecretOption, cfg.ClientSecret)
+	}
+	proxy.ApplyOAuth2Token(&authConfig, token)
+
+	if err := validateGatewayAuth(gateway, proxy.NewOAuth2Auth(authConfig, httpClient), timeout, tlsInsecure); err != nil {
+		return err
+	}
+
+	if err := config.UpdateAuthConfig(authConfig); err != nil {
+		return err
+	}
+
+	fmt.Println("OAuth2 credentials saved for", cfg.ClientID, gateway)
+
+	return nil
+}
+
+func printDeviceAuthorization(auth *proxy.DeviceAuthorization) {
+	if len(auth.VerificationURIComplete) > 0 {
+		fmt.Printf("Open the following URL in a browser to log in:\n\n  %s\n\n", auth.VerificationURIComplete)
+	} else {
+		fmt.Printf("Open the following URL in a browser:\n\n  %s\n\nand enter the code: %s\n\n", auth.VerificationURI, auth.UserCode)
+	}
+
+	fmt.Println("Waiting for the login to be approved...")
+}
@@ -0,0 +1,94 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/test"
+)
+
+func Test_login_OAuth2DeviceCodeKeepsClientSecret(t *testing.T) {
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+
+	refreshSecret := ""
+	mux := http.NewServeMux()
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(proxy.DeviceAuthorization{DeviceCode: "device", UserCode: "CODE", VerificationURI: "https://idp.example.com/activate", ExpiresIn: 60, Interval: 1})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		_, secret, _ := r.BasicAuth()
+
+		switch r.Form.Get("grant_type") {
+		case proxy.DeviceCodeGrant:
+			if secret != "secret" {
+				t.Errorf("want the client secret for the device code, got %q", secret)
+			}
+			json.NewEncoder(w).Encode(proxy.OAuth2Token{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 3600})
+		case proxy.RefreshTokenGrant:
+			refreshSecret = secret
+			json.NewEncoder(w).Encode(proxy.OAuth2Token{AccessToken: "new-access", ExpiresIn: 3600})
+		default:
+			t.Errorf("unexpected grant: %v", r.Form)
+		}
+	})
+	mux.HandleFunc("/system/functions", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	})
+
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	resetForTest()
+	defer resetForTest()
+	defer func() {
+		oauth2Login = false
+		oauth2Grant = deviceCodeGrant
+		oauth2Config = proxy.OAuth2Config{}
+		gateway = ""
+	}()
+
+	var err error
+	test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"login",
+			"--oauth2",
+			"--grant=" + deviceCodeGrant,
+			"--gateway=" + s.URL,
+			"--client-id=forge-cli",
+			"--client-secret=secret",
+			"--device-auth-url=" + s.URL + "/device",
+			"--token-url=" + s.URL + "/token",
+		})
+		err = forgeCmd.Execute()
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	authConfig, err := config.LookupAuthConfig(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := authConfig.GetOption(proxy.OAuth2ClientSecretOption); got != "secret" {
+		t.Fatalf("want the client secret to be saved, got %q", got)
+	}
+
+	// the access token has expired
+	authConfig.SetOption(proxy.OAuth2ExpiryOption, "1")
+
+	req, _ := http.NewRequest(http.MethodGet, s.URL+"/system/functions", nil)
+	if err := proxy.NewOAuth2Auth(authConfig, nil).Set(req); err != nil {
+		t.Fatalf("want the token to be refreshed, got %s", err)
+	}
+	if refreshSecret != "secret" {
+		t.Fatalf("want the refresh request to use the client secret, got %q", refreshSecret)
+	}
+}
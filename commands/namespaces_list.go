@@ -12,6 +12,8 @@ func init() {
 	namespacesCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
 	namespacesCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
 	namespacesCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
+	addOutputFlag(namespacesCmd.Flags())
+	addOutputFlag(namespaceListCmd.Flags())
 
 	forgeCmd.AddCommand(namespacesCmd)
 	namespaceCmd.AddCommand(namespaceListCmd)
@@ -33,8 +35,9 @@ var namespaceListCmd = &cobra.Command{
 	Aliases: []string{"ls"},
 	Short:   "List Forge4Flow namespaces",
 	Long:    `Lists Forge4Flow namespaces for the given gateway URL`,
-	Example: `forge-cli namespace list`,
-	RunE:    runNamespaces,
+	Example: `  forge-cli namespace list
+  forge-cli namespace list --output json`,
+	RunE: runNamespaces,
 }
 
 func runNamespaces(cmd *cobra.Command, args []string) error {
@@ -47,6 +50,14 @@ func runNamespaces(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+
+	if structuredOutput() {
+		if namespaces == nil {
+			namespaces = []string{}
+		}
+		return printOutput(namespaces)
+	}
+
 	printNamespaces(namespaces)
 	return nil
 }
@@ -197,8 +197,11 @@ func runNewFunctionTest(t *testing.T, nft NewFunctionTest) {
 
 func Test_newFunctionTests(t *testing.T) {
 	// Download templates
-	templatePullLocalTemplateRepo(t)
-	defer tearDownFetchTemplates(t)
+	localTemplateRepository := setupLocalTemplateRepo(t)
+	defer os.RemoveAll(localTemplateRepository)
+
+	chdirTemp(t)
+	templatePullRepo(t, localTemplateRepository)
 
 	for _, testcase := range NewFunctionTests {
 		t.Run(testcase.title, func(t *testing.T) {
@@ -273,8 +276,11 @@ func Test_duplicateFunctionName(t *testing.T) {
 	const functionName = "samplefunc"
 	const functionLang = "ruby"
 
-	templatePullLocalTemplateRepo(t)
-	defer tearDownFetchTemplates(t)
+	localTemplateRepository := setupLocalTemplateRepo(t)
+	defer os.RemoveAll(localTemplateRepository)
+
+	chdirTemp(t)
+	templatePullRepo(t, localTemplateRepository)
 	defer tearDownNewFunction(t, functionName)
 
 	// Create function
@@ -303,6 +309,8 @@ func Test_backfillTemplates(t *testing.T) {
 	// Delete cached templates
 	localTemplateRepository := setupLocalTemplateRepo(t)
 	defer os.RemoveAll(localTemplateRepository)
+
+	chdirTemp(t)
 	defer tearDownNewFunction(t, functionName)
 
 	os.Setenv(templateURLEnvironment, localTemplateRepository)
@@ -320,6 +328,23 @@ func Test_backfillTemplates(t *testing.T) {
 	}
 }
 
+// chdirTemp moves a test to an empty directory until it ends, as new writes
+// functions.yml, .gitignore and the handler to the working directory
+func chdirTemp(t *testing.T) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(wd)
+	})
+}
+
 func tearDownNewFunction(t *testing.T, functionName string) {
 	if _, err := os.Stat(".gitignore"); err == nil {
 		if err := os.Remove(".gitignore"); err != nil {
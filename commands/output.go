@@ -0,0 +1,378 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/forge4flow/forge-cli/flags"
+	"github.com/spf13/pflag"
+	yaml "gopkg.in/yaml.v3"
+)
+
+var outputFormat flags.OutputFormat
+
+// addOutputFlag adds --output to a read command
+func addOutputFlag(flagset *pflag.FlagSet) {
+	flagset.VarP(&outputFormat, "output", "o", "Output format: table, json, yaml, template=GO_TEMPLATE or jsonpath=JSONPATH")
+}
+
+// structuredOutput is true when --output asks for something other than the
+// command's own table
+func structuredOutput() bool {
+	return outputFormat.Format() != flags.TableOutputFormat
+}
+
+// printOutput prints v in the format given by --output
+func printOutput(v interface{}) error {
+	out, err := renderOutput(outputFormat, v)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(out)
+	return nil
+}
+
+// renderOutput serialises v as JSON or YAML, or evaluates a Go template or
+// JSONPath expression against it. Every format uses the keys of the JSON
+// output, so {{.name}} and {.name} both read the "name" field.
+func renderOutput(format flags.OutputFormat, v interface{}) (string, error) {
+	if format.Format() == flags.JSONOutputFormat {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	}
+
+	value, err := toUnstructured(v)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	switch format.Format() {
+	case flags.YAMLOutputFormat:
+		encoder := yaml.NewEncoder(&b)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(yamlNumbers(value)); err != nil {
+			return "", err
+		}
+		if err := encoder.Close(); err != nil {
+			return "", err
+		}
+
+	case flags.TemplateOutputFormat:
+		tmpl, err := template.New("output").Parse(format.Expression())
+		if err != nil {
+			return "", fmt.Errorf("error parsing template: %w", err)
+		}
+		if err := tmpl.Execute(&b, value); err != nil {
+			return "", fmt.Errorf("error executing template: %w", err)
+		}
+
+	case flags.JSONPathOutputFormat:
+		nodes, err := parseJSONPath(format.Expression())
+		if err != nil {
+			return "", fmt.Errorf("error parsing jsonpath %s: %w", format.Expression(), err)
+		}
+		if err := executeJSONPath(&b, nodes, value); err != nil {
+			return "", fmt.Errorf("error executing jsonpath %s: %w", format.Expression(), err)
+		}
+
+	default:
+		return "", fmt.Errorf("output format %s is not supported by this command", format.Format())
+	}
+
+	return b.String(), nil
+}
+
+// toUnstructured converts v to the maps and slices of its JSON form,
+// numbers are kept as json.Number so large counts print without exponents
+func toUnstructured(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// yamlNumbers replaces json.Number values with int64 or float64, which
+// yaml.v3 would otherwise print as quoted strings
+func yamlNumbers(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case json.Number:
+		if i, err := typed.Int64(); err == nil {
+			return i
+		}
+		if f, err := typed.Float64(); err == nil {
+			return f
+		}
+	case map[string]interface{}:
+		for k, v := range typed {
+			typed[k] = yamlNumbers(v)
+		}
+	case []interface{}:
+		for i, v := range typed {
+			typed[i] = yamlNumbers(v)
+		}
+	}
+	return value
+}
+
+// jsonPathNode is part of a JSONPath template: literal text, a path to print
+// or a {range path}...{end} block
+type jsonPathNode struct {
+	text     string
+	path     []jsonPathSegment
+	isPath   bool
+	isRange  bool
+	children []jsonPathNode
+}
+
+// jsonPathSegment is a key, an index or a [*] wildcard of a path
+type jsonPathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath parses the subset of the kubectl JSONPath syntax that is
+// useful for the CLI's output: {.a.b}, {['a.b']}, {[0]}, {[*]}, {"text"} and
+// {range .items[*]}...{end}
+func parseJSONPath(expression string) ([]jsonPathNode, error) {
+	root := &jsonPathNode{}
+	stack := []*jsonPathNode{root}
+
+	for len(expression) > 0 {
+		current := stack[len(stack)-1]
+
+		start := strings.Index(expression, "{")
+		if start == -1 {
+			current.children = append(current.children, jsonPathNode{text: expression})
+			break
+		}
+		if start > 0 {
+			current.children = append(current.children, jsonPathNode{text: expression[:start]})
+		}
+
+		end := closingBrace(expression, start)
+		if end == -1 {
+			return nil, fmt.Errorf("unclosed action")
+		}
+
+		action := strings.TrimSpace(expression[start+1 : end])
+		expression = expression[end+1:]
+
+		switch {
+		case strings.HasPrefix(action, `"`):
+			text, err := strconv.Unquote(action)
+			if err != nil {
+				return nil, fmt.Errorf("invalid text %s", action)
+			}
+			current.children = append(current.children, jsonPathNode{text: text})
+
+		case action == "end":
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("{end} without {range}")
+			}
+			stack = stack[:len(stack)-1]
+
+		case strings.HasPrefix(action, "range "):
+			path, err := parseJSONPathSegments(strings.TrimSpace(strings.TrimPrefix(action, "range ")))
+			if err != nil {
+				return nil, err
+			}
+			current.children = append(current.children, jsonPathNode{path: path, isRange: true})
+			stack = append(stack, &current.children[len(current.children)-1])
+
+		default:
+			path, err := parseJSONPathSegments(action)
+			if err != nil {
+				return nil, err
+			}
+			current.children = append(current.children, jsonPathNode{path: path, isPath: true})
+		}
+	}
+
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("{range} without {end}")
+	}
+	return root.children, nil
+}
+
+// closingBrace returns the index of the "}" closing the action at start,
+// ignoring braces in quoted text
+func closingBrace(expression string, start int) int {
+	var quote byte
+	for i := start + 1; i < len(expression); i++ {
+		switch c := expression[i]; {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '}':
+			return i
+		}
+	}
+	return -1
+}
+
+func parseJSONPathSegments(path string) ([]jsonPathSegment, error) {
+	original := path
+	path = strings.TrimPrefix(path, "$")
+
+	var segments []jsonPathSegment
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end == -1 {
+				end = len(path)
+			}
+			if end > 0 {
+				segments = append(segments, jsonPathSegment{key: path[:end]})
+			}
+			path = path[end:]
+
+		case '[':
+			end := strings.Index(path, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed [ in %s", original)
+			}
+			inner := strings.TrimSpace(path[1:end])
+			path = path[end+1:]
+
+			switch {
+			case inner == "*":
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			case len(inner) > 1 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				segments = append(segments, jsonPathSegment{key: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index [%s] in %s", inner, original)
+				}
+				segments = append(segments, jsonPathSegment{index: index, isIndex: true})
+			}
+
+		default:
+			return nil, fmt.Errorf("invalid path %s, paths start with . or [", original)
+		}
+	}
+
+	return segments, nil
+}
+
+func executeJSONPath(b *bytes.Buffer, nodes []jsonPathNode, value interface{}) error {
+	for _, node := range nodes {
+		switch {
+		case node.isPath:
+			values := evaluateJSONPath(node.path, value)
+			for i, v := range values {
+				if i > 0 {
+					b.WriteString(" ")
+				}
+				text, err := jsonPathText(v)
+				if err != nil {
+					return err
+				}
+				b.WriteString(text)
+			}
+
+		case node.isRange:
+			for _, v := range evaluateJSONPath(node.path, value) {
+				if err := executeJSONPath(b, node.children, v); err != nil {
+					return err
+				}
+			}
+
+		default:
+			b.WriteString(node.text)
+		}
+	}
+	return nil
+}
+
+// evaluateJSONPath returns the values matched by a path, keys which are not
+// present match nothing
+func evaluateJSONPath(path []jsonPathSegment, value interface{}) []interface{} {
+	values := []interface{}{value}
+
+	for _, segment := range path {
+		var next []interface{}
+		for _, v := range values {
+			switch typed := v.(type) {
+			case map[string]interface{}:
+				if segment.wildcard {
+					keys := make([]string, 0, len(typed))
+					for k := range typed {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						next = append(next, typed[k])
+					}
+				} else if item, ok := typed[segment.key]; ok && !segment.isIndex {
+					next = append(next, item)
+				}
+
+			case []interface{}:
+				if segment.wildcard {
+					next = append(next, typed...)
+				} else if segment.isIndex {
+					index := segment.index
+					if index < 0 {
+						index += len(typed)
+					}
+					if index >= 0 && index < len(typed) {
+						next = append(next, typed[index])
+					}
+				}
+			}
+		}
+		values = next
+	}
+
+	return values
+}
+
+func jsonPathText(value interface{}) (string, error) {
+	switch typed := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return typed, nil
+	case json.Number:
+		return typed.String(), nil
+	case bool:
+		return strconv.FormatBool(typed), nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
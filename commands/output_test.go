@@ -0,0 +1,134 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/forge4flow/forge-cli/flags"
+	"github.com/forge4flow/forge-cli/schema"
+	"github.com/openfaas/faas-provider/types"
+)
+
+func Test_renderOutput(t *testing.T) {
+	labels := map[string]string{"com.openfaas.scale.min": "2"}
+	description := schema.FunctionDescription{
+		FunctionStatus: types.FunctionStatus{
+			Name:            "figlet",
+			Image:           "ghcr.io/openfaas/figlet:latest",
+			Secrets:         []string{"api-key", "db-password"},
+			Labels:          &labels,
+			InvocationCount: 10,
+		},
+		Status:          "Ready",
+		InvocationCount: 12345678,
+		URL:             "http://127.0.0.1:8080/function/figlet",
+	}
+
+	cases := []struct {
+		name   string
+		format string
+		value  interface{}
+		want   string
+	}{
+		{
+			name:   "json uses the json keys",
+			format: "json",
+			value:  []types.Secret{{Name: "api-key"}},
+			want:   "[\n  {\n    \"name\": \"api-key\"\n  }\n]\n",
+		},
+		{
+			name:   "yaml uses the json keys and keeps numbers",
+			format: "yaml",
+			value:  []types.FunctionStatus{{Name: "figlet", Replicas: 2}},
+			want:   "- createdAt: \"0001-01-01T00:00:00Z\"\n  image: \"\"\n  name: figlet\n  replicas: 2\n",
+		},
+		{
+			name:   "template reads json keys",
+			format: "template={{.name}} {{.status}} {{.invocationCount}}",
+			value:  description,
+			want:   "figlet Ready 12345678",
+		},
+		{
+			name:   "jsonpath prints a field",
+			format: "jsonpath={.url}",
+			value:  description,
+			want:   "http://127.0.0.1:8080/function/figlet",
+		},
+		{
+			name:   "jsonpath joins multiple values with spaces",
+			format: "jsonpath={.secrets[*]}",
+			value:  description,
+			want:   "api-key db-password",
+		},
+		{
+			name:   "jsonpath reads keys with dots and negative indexes",
+			format: "jsonpath={.labels['com.openfaas.scale.min']}/{.secrets[-1]}",
+			value:  description,
+			want:   "2/db-password",
+		},
+		{
+			name:   "jsonpath ranges over a list",
+			format: `jsonpath={range [*]}{.name}{"\t"}{.replicas}{"\n"}{end}`,
+			value:  []types.FunctionStatus{{Name: "a", Replicas: 1}, {Name: "b", Replicas: 2}},
+			want:   "a\t1\nb\t2\n",
+		},
+		{
+			name:   "jsonpath prints objects as json",
+			format: "jsonpath={.labels}",
+			value:  description,
+			want:   `{"com.openfaas.scale.min":"2"}`,
+		},
+		{
+			name:   "jsonpath prints nothing for missing keys",
+			format: "jsonpath=[{.missing}]",
+			value:  description,
+			want:   "[]",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var format flags.OutputFormat
+			if err := format.Set(tc.format); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := renderOutput(format, tc.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tc.want {
+				t.Errorf("want:\n%q\ngot:\n%q", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_renderOutput_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{"template with a syntax error", "template={{.name", "error parsing template: template: output:1: unclosed action"},
+		{"jsonpath with a missing end", "jsonpath={range [*]}{.name}", "error parsing jsonpath {range [*]}{.name}: {range} without {end}"},
+		{"jsonpath with an unclosed action", "jsonpath={.name", "error parsing jsonpath {.name: unclosed action"},
+		{"jsonpath with an invalid index", "jsonpath={.secrets[a]}", "error parsing jsonpath {.secrets[a]}: invalid index [a] in .secrets[a]"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var format flags.OutputFormat
+			if err := format.Set(tc.format); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := renderOutput(format, types.FunctionStatus{Name: "figlet"})
+			if err == nil || err.Error() != tc.want {
+				t.Errorf("want error %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
@@ -6,16 +6,41 @@ package commands
 import (
 	"fmt"
 	"strings"
+
+	"github.com/forge4flow/forge-cli/config"
 )
 
 const (
 	openFaaSURLEnvironment      = "OPENFAAS_URL"
 	templateURLEnvironment      = "OPENFAAS_TEMPLATE_URL"
 	templateStoreURLEnvironment = "OPENFAAS_TEMPLATE_STORE_URL"
+	builderTokenEnvironment     = "OPENFAAS_BUILDER_TOKEN"
 	defaultFunctionNamespace    = ""
 )
 
+// lookupCurrentContext returns the current context from the config file, or nil
+// when none is set. It is a variable so that tests do not read the user's config.
+var lookupCurrentContext = func() *config.Context {
+	current, err := config.LookupCurrentContext()
+	if err != nil {
+		return nil
+	}
+	return current
+}
+
+// getGatewayURL picks the gateway from the flag, the stack file, the
+// environment, the current context and finally the default, in that order
 func getGatewayURL(argumentURL, defaultURL, yamlURL, environmentURL string) string {
+	gatewayURL := resolveGatewayURL(argumentURL, defaultURL, yamlURL, environmentURL)
+	useContextTLS(gatewayURL)
+
+	return gatewayURL
+}
+
+// resolveGatewayURL picks the gateway like getGatewayURL, without applying
+// the TLS setting of the current context, for commands such as build which
+// don't make requests to the gateway
+func resolveGatewayURL(argumentURL, defaultURL, yamlURL, environmentURL string) string {
 	var gatewayURL string
 
 	if len(argumentURL) > 0 && argumentURL != defaultURL {
@@ -24,15 +49,22 @@ func getGatewayURL(argumentURL, defaultURL, yamlURL, environmentURL string) stri
 		gatewayURL = yamlURL
 	} else if len(environmentURL) > 0 {
 		gatewayURL = environmentURL
+	} else if current := lookupCurrentContext(); current != nil && len(current.Gateway) > 0 {
+		gatewayURL = current.Gateway
 	} else {
 		gatewayURL = defaultURL
 	}
 
+	return normalizeGatewayURL(gatewayURL)
+}
+
+// normalizeGatewayURL lower-cases the gateway URL, trims trailing slashes and
+// adds http:// when it has no scheme
+func normalizeGatewayURL(gatewayURL string) string {
 	gatewayURL = strings.ToLower(strings.TrimRight(gatewayURL, "/"))
 	if !strings.HasPrefix(gatewayURL, "http") {
 		gatewayURL = fmt.Sprintf("http://%s", gatewayURL)
 	}
-
 	return gatewayURL
 }
 
@@ -70,6 +102,10 @@ func getNamespace(flagNamespace, stackNamespace string) string {
 		return stackNamespace
 	}
 
+	if current := lookupCurrentContext(); current != nil && len(current.Namespace) > 0 {
+		return current.Namespace
+	}
+
 	return defaultFunctionNamespace
 
 }
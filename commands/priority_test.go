@@ -1,6 +1,10 @@
 package commands
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/forge4flow/forge-cli/config"
+)
 
 func Test_getTemplateStoreURL(t *testing.T) {
 	tests := []struct {
@@ -99,3 +103,23 @@ func Test_getOverrideNamespace(t *testing.T) {
 		})
 	}
 }
+
+func Test_getNamespace_CurrentContext(t *testing.T) {
+	lookup := lookupCurrentContext
+	defer func() { lookupCurrentContext = lookup }()
+	lookupCurrentContext = func() *config.Context {
+		return &config.Context{Name: "staging", Gateway: "https://staging.example.com", Namespace: "staging-fn"}
+	}
+
+	if got := getNamespace("", ""); got != "staging-fn" {
+		t.Errorf("want context namespace when no flag or stack value is set, got %q", got)
+	}
+
+	if got := getNamespace("", "bar"); got != "bar" {
+		t.Errorf("want stack namespace over context, got %q", got)
+	}
+
+	if got := getNamespace("foo", "bar"); got != "foo" {
+		t.Errorf("want flag namespace over context, got %q", got)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	promoteCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	promoteCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
+	promoteCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(promoteCmd.Flags())
+	promoteCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
+	promoteCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
+	addCanaryGateFlags(promoteCmd.Flags())
+
+	forgeCmd.AddCommand(promoteCmd)
+}
+
+var promoteCmd = &cobra.Command{
+	Use:   `promote FUNCTION_NAME [--samples N] [--max-error-rate PERCENT]`,
+	Short: "Promote the canary of a function",
+	Long: `Deploys the canary created by "forge-cli deploy --canary" as the stable function
+and removes the canary. With --samples, the canary is smoke tested first and is
+only promoted when its error rate is within --max-error-rate.`,
+	Example: `  forge-cli promote figlet
+  forge-cli promote figlet --samples 20 --max-error-rate 5`,
+	RunE: runPromote,
+}
+
+func runPromote(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("please provide a name for the function")
+	}
+	functionName := args[0]
+	namespace := getNamespace(functionNamespace, "")
+
+	client, err := newHistoryClient()
+	if err != nil {
+		return err
+	}
+
+	if err := canaryGate(client.GatewayURL.String(), functionName, namespace, canarySamples, canaryMaxErrorRate); err != nil {
+		return err
+	}
+
+	if err := promoteCanary(context.Background(), client, functionName, namespace); err != nil {
+		return err
+	}
+
+	fmt.Printf("Promoted %s.\n", functionName)
+	return nil
+}
@@ -4,8 +4,10 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,9 +24,12 @@ var (
 	platforms         string
 	extraTags         []string
 	resetQemu         bool
-	mountSSH          bool
 	remoteBuilder     string
 	payloadSecretPath string
+
+	remoteBuilderToken   string
+	remoteBuilderTimeout time.Duration
+	remoteBuilderRetries int
 )
 
 func init() {
@@ -44,6 +49,7 @@ func init() {
 	publishCmd.Flags().Var(&tagFormat, "tag", "Override latest tag on function Docker image, accepts 'latest', 'sha', 'branch', or 'describe'")
 	publishCmd.Flags().StringArrayVar(&buildLabels, "build-label", []string{}, "Add a label for Docker image (LABEL=VALUE)")
 	publishCmd.Flags().StringArrayVar(&copyExtra, "copy-extra", []string{}, "Extra paths that will be copied into the function build context")
+	publishCmd.Flags().StringVar(&changedSince, "changed-since", "", "Only include functions affected by files changed in \"git diff REF...HEAD\"")
 	publishCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
 	publishCmd.Flags().BoolVar(&quietBuild, "quiet", false, "Perform a quiet build, without showing output from Docker")
 	publishCmd.Flags().BoolVar(&disableStackPull, "disable-stack-pull", false, "Disables the template configuration in the functions.yml")
@@ -52,6 +58,11 @@ func init() {
 	publishCmd.Flags().BoolVar(&resetQemu, "reset-qemu", false, "Runs \"docker run multiarch/qemu-user-static --reset -p yes\" to enable multi-arch builds. Compatible with AMD64 machines only.")
 	publishCmd.Flags().StringVar(&remoteBuilder, "remote-builder", "", "URL to the builder")
 	publishCmd.Flags().StringVar(&payloadSecretPath, "payload-secret", "", "Path to payload secret file")
+	publishCmd.Flags().StringVar(&remoteBuilderToken, "remote-builder-token", "", "Bearer token for the builder, defaults to $"+builderTokenEnvironment)
+	publishCmd.Flags().DurationVar(&remoteBuilderTimeout, "remote-builder-timeout", builder.DefaultRemoteBuilderTimeout, "Time allowed for the remote builder to build and push an image")
+	publishCmd.Flags().IntVar(&remoteBuilderRetries, "remote-builder-retries", builder.DefaultRemoteBuilderRetries, "Retries for requests to the remote builder when it can't be reached or is busy")
+	publishCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	publishCmd.Flags().StringVar(&imageBuilder, "builder", "", "Builder backend: "+strings.Join(builder.BackendNames, ", ")+", defaults to build.backend in the YAML file or docker, which publishes with buildx")
 
 	// Set bash-completion.
 	_ = publishCmd.Flags().SetAnnotation("handler", cobra.BashCompSubdirsInDir, []string{})
@@ -76,7 +87,8 @@ var publishCmd = &cobra.Command{
                    [--tag <sha|branch|describe>]
                    [--platforms linux/arm/v7]
                    [--reset-qemu]
-                   [--remote-builder http://127.0.0.1:8081/build]`,
+                   [--remote-builder http://127.0.0.1:8081/build]
+                   [--builder <docker|buildx|buildah|podman|kaniko|native|remote>]`,
 	Short: "Builds and pushes multi-arch Forge4Flow container images",
 	Long: `Builds and pushes multi-arch Forge4Flow container images using Docker buildx.
 Most users will want forge-cli build or forge-cli up for development and testing.
@@ -88,6 +100,13 @@ available in the local Docker library. This is due to technical constraints in
 Docker and buildx. You must use a multi-arch template to use this command with 
 correctly configured TARGETPLATFORM and BUILDPLATFORM arguments.
 
+Another builder can be given with "--builder" or with "backend" under "build"
+in the YAML file. buildah and podman publish a manifest list with an image for
+each platform, kaniko publishes a single platform. With "--builder native" the
+image is pushed without Docker, by appending the files of the template's
+Dockerfile to its base image for each of the platforms, see forge-cli build
+--help for the Dockerfiles which can be built this way.
+
 See also: forge-cli build`,
 	Example: `  forge-cli publish --platforms linux/amd64,linux/arm64,linux/arm/7
   forge-cli publish --platforms linux/arm/7 --filter webhook
@@ -96,6 +115,7 @@ See also: forge-cli build`,
   forge-cli publish --tag sha
   forge-cli publish --reset-qemu
   forge-cli publish --remote-builder http://127.0.0.1:8081/build
+  forge-cli publish --builder native --platforms linux/amd64,linux/arm64
   `,
 	PreRunE: preRunPublish,
 	RunE:    runPublish,
@@ -121,6 +141,10 @@ func preRunPublish(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("--yaml or -f is required")
 	}
 
+	if builderErr := validateImageBuilder(imageBuilder); builderErr != nil {
+		return builderErr
+	}
+
 	return err
 }
 
@@ -128,7 +152,7 @@ func runPublish(cmd *cobra.Command, args []string) error {
 
 	var services stack.Services
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
 		if err != nil {
 			return err
 		}
@@ -138,12 +162,24 @@ func runPublish(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if done, err := filterChangedSince(&services); err != nil || done {
+		return err
+	}
+
+	backendName, backend, err := imageBackend(&services)
+	if err != nil {
+		return err
+	}
+
+	// only the docker and buildx builders publish with buildx
+	usesBuildx := backendName == builder.DockerBuilder || backendName == builder.BuildxBuilder
+
 	templateAddress := getTemplateURL("", os.Getenv(templateURLEnvironment), DefaultTemplateRepository)
 	if pullErr := pullTemplates(templateAddress); pullErr != nil {
 		return fmt.Errorf("could not pull templates for Forge4Flow: %v", pullErr)
 	}
 
-	if resetQemu {
+	if resetQemu && usesBuildx {
 
 		task := v2execute.ExecTask{
 			Command: "docker",
@@ -171,27 +207,29 @@ func runPublish(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Ran qemu-user-static --reset. OK.\n")
 	}
 
-	task := v2execute.ExecTask{
-		Command: "docker",
-		Args: []string{"buildx",
-			"create",
-			"--use",
-			"--name=multiarch",
-			"--node=multiarch"},
-		StreamStdio: false,
-		Env:         []string{"DOCKER_CLI_EXPERIMENTAL=enabled"},
-	}
+	if usesBuildx {
+		task := v2execute.ExecTask{
+			Command: "docker",
+			Args: []string{"buildx",
+				"create",
+				"--use",
+				"--name=multiarch",
+				"--node=multiarch"},
+			StreamStdio: false,
+			Env:         []string{"DOCKER_CLI_EXPERIMENTAL=enabled"},
+		}
 
-	res, err := task.Execute(cmd.Context())
-	if err != nil {
-		return err
-	}
+		res, err := task.Execute(cmd.Context())
+		if err != nil {
+			return err
+		}
 
-	if res.ExitCode != 0 {
-		return fmt.Errorf("non-zero exit code: %d, stderr: %s", res.ExitCode, res.Stderr)
-	}
+		if res.ExitCode != 0 {
+			return fmt.Errorf("non-zero exit code: %d, stderr: %s", res.ExitCode, res.Stderr)
+		}
 
-	fmt.Printf("Created buildx node: \"multiarch\"\n")
+		fmt.Printf("Created buildx node: \"multiarch\"\n")
+	}
 
 	if len(services.StackConfiguration.TemplateConfigs) != 0 && !disableStackPull {
 		newTemplateInfos, err := filterExistingTemplates(services.StackConfiguration.TemplateConfigs, "./template")
@@ -205,7 +243,7 @@ func runPublish(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	errors := publish(&services, parallel, shrinkwrap, quietBuild, mountSSH)
+	errors := publish(&services, backend, parallel, shrinkwrap, quietBuild)
 	if len(errors) > 0 {
 		errorSummary := "Errors received during build:\n"
 		for _, err := range errors {
@@ -216,9 +254,12 @@ func runPublish(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func publish(services *stack.Services, queueDepth int, shrinkwrap, quietBuild, mountSSH bool) []error {
+func publish(services *stack.Services, backend builder.Backend, queueDepth int, shrinkwrap, quietBuild bool) []error {
 	startOuter := time.Now()
 
+	// hooks are given the gateway the functions are deployed to
+	gatewayURL := resolveGatewayURL("", defaultGateway, services.Provider.GatewayURL, os.Getenv(openFaaSURLEnvironment))
+
 	errors := []error{}
 
 	wg := sync.WaitGroup{}
@@ -238,24 +279,25 @@ func publish(services *stack.Services, queueDepth int, shrinkwrap, quietBuild, m
 					combinedBuildOptions := combineBuildOpts(function.BuildOptions, buildOptions)
 					combinedBuildArgMap := util.MergeMap(function.BuildArgs, buildArgMap)
 					combinedExtraPaths := util.MergeSlice(services.StackConfiguration.CopyExtraPaths, copyExtra)
-					err := builder.PublishImage(function.Image,
-						function.Handler,
-						function.Name,
-						function.Language,
-						nocache,
-						squash,
-						shrinkwrap,
-						combinedBuildArgMap,
-						combinedBuildOptions,
-						tagFormat,
-						buildLabelMap,
-						quietBuild,
-						combinedExtraPaths,
-						platforms,
-						extraTags,
-						remoteBuilder,
-						payloadSecretPath,
-					)
+					err := buildWithHooks(function, gatewayURL, func() error {
+						return builder.PublishImage(context.Background(), backend, builder.BuildOptions{
+							Image:          function.Image,
+							Handler:        function.Handler,
+							FunctionName:   function.Name,
+							Language:       function.Language,
+							NoCache:        nocache,
+							Squash:         squash,
+							Shrinkwrap:     shrinkwrap,
+							BuildArgMap:    combinedBuildArgMap,
+							BuildOptions:   combinedBuildOptions,
+							TagFormat:      tagFormat,
+							BuildLabelMap:  buildLabelMap,
+							Quiet:          quietBuild,
+							CopyExtraPaths: combinedExtraPaths,
+							Platforms:      platforms,
+							ExtraTags:      extraTags,
+						})
+					})
 
 					if err != nil {
 						errors = append(errors, err)
@@ -4,13 +4,12 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
 	"sync"
 
-	"github.com/forge4flow/forge-cli/exec"
-
 	"github.com/forge4flow/forge-cli/builder"
 	"github.com/forge4flow/forge-cli/schema"
 	"github.com/forge4flow/forge-cli/stack"
@@ -23,8 +22,11 @@ func init() {
 
 	pushCmd.Flags().IntVar(&parallel, "parallel", 1, "Push images in parallel to depth specified.")
 	pushCmd.Flags().Var(&tagFormat, "tag", "Override latest tag on function Docker image, accepts 'digest', 'latest', 'sha', 'branch', 'describe'")
+	pushCmd.Flags().StringVar(&changedSince, "changed-since", "", "Only include functions affected by files changed in \"git diff REF...HEAD\"")
 	pushCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
 	pushCmd.Flags().BoolVar(&quietBuild, "quiet", false, "Perform a quiet build, without showing output from Docker")
+	pushCmd.Flags().BoolVar(&forceBuild, "force", false, "Push images which were already pushed since they were last built")
+	pushCmd.Flags().StringVar(&imageBuilder, "builder", "", "Builder backend which built the images: "+strings.Join(builder.BackendNames, ", ")+", defaults to build.backend in the YAML file or docker")
 }
 
 // pushCmd handles pushing function container images to a remote repo
@@ -34,7 +36,10 @@ var pushCmd = &cobra.Command{
 	Long: `Pushes the Forge4Flow function container image(s) defined in the supplied YAML
 config to a remote repository.
 
-These container images must already be present in your local image cache.`,
+These container images must already be present in your local image cache, or
+for the native and kaniko builders, in the ./build/ folder. Images which were
+pushed since "forge-cli build" last built them are skipped, unless "--force" is
+given.`,
 
 	Example: `  forge-cli push -f https://domain/path/myfunctions.yml
   forge-cli push -f ./functions.yml
@@ -43,15 +48,21 @@ These container images must already be present in your local image cache.`,
   forge-cli push -f ./functions.yml --regex "fn[0-9]_.*"
   forge-cli push -f ./functions.yml --tag sha
   forge-cli push -f ./functions.yml --tag branch
-  forge-cli push -f ./functions.yml --tag describe`,
-	RunE: runPush,
+  forge-cli push -f ./functions.yml --tag describe
+  forge-cli push -f ./functions.yml --builder native`,
+	PreRunE: preRunPush,
+	RunE:    runPush,
+}
+
+func preRunPush(cmd *cobra.Command, args []string) error {
+	return validateImageBuilder(imageBuilder)
 }
 
 func runPush(cmd *cobra.Command, args []string) error {
 
 	var services stack.Services
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
 		if err != nil {
 			return err
 		}
@@ -61,6 +72,10 @@ func runPush(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if done, err := filterChangedSince(&services); err != nil || done {
+		return err
+	}
+
 	if len(services.Functions) > 0 {
 		invalidImages := validateImages(services.Functions)
 		if len(invalidImages) > 0 {
@@ -72,23 +87,34 @@ Unable to push one or more of your functions to Docker Hub:
 You must provide a username or registry prefix to the Function's image such as user1/function1`)
 		}
 
-		pushStack(&services, parallel, tagFormat)
+		backendName, backend, err := imageBackend(&services)
+		if err != nil {
+			return err
+		}
+
+		cache := builder.LoadBuildCache(builder.BuildCachePath, backendName)
+		errors := pushStack(&services, backend, cache, forceBuild, parallel, tagFormat)
+		saveBuildCache(cache)
+		if len(errors) > 0 {
+			errorSummary := "Errors received during push:\n"
+			for _, err := range errors {
+				errorSummary = errorSummary + "- " + err.Error() + "\n"
+			}
+			return fmt.Errorf("%s", aec.Apply(errorSummary, aec.RedF))
+		}
 	} else {
 		return fmt.Errorf("you must supply a valid YAML file")
 	}
 	return nil
 }
 
-func pushImage(image string, quietBuild bool) {
-	args := []string{"docker", "push", image}
-	if quietBuild {
-		args = append(args, "--quiet")
-	}
-
-	exec.Command("./", args)
-}
+// pushStack pushes the images of the stack, those which the cache records as
+// pushed since they were last built are skipped unless force is set. The cache
+// may be nil.
+func pushStack(services *stack.Services, backend builder.Backend, cache *builder.BuildCache, force bool, queueDepth int, tagFormat schema.BuildFormat) []error {
+	var errorsMu sync.Mutex
+	errors := []error{}
 
-func pushStack(services *stack.Services, queueDepth int, tagFormat schema.BuildFormat) {
 	wg := sync.WaitGroup{}
 
 	workChannel := make(chan stack.Function)
@@ -111,8 +137,18 @@ func pushStack(services *stack.Services, queueDepth int, tagFormat schema.BuildF
 					fmt.Println("Please provide a valid Image value in the YAML file.")
 				} else if function.SkipBuild {
 					fmt.Printf("Skipping %s\n", function.Name)
+				} else if !force && cache != nil && cache.Pushed(function.Name, imageName) {
+					fmt.Printf("%s was pushed since it was last built, skipping the push, use --force to push it\n", function.Name)
 				} else {
-					pushImage(imageName, quietBuild)
+					if err := backend.Push(context.Background(), function.Name, imageName, quietBuild); err != nil {
+						errorsMu.Lock()
+						errors = append(errors, err)
+						errorsMu.Unlock()
+						continue
+					}
+					if cache != nil {
+						cache.MarkPushed(function.Name, imageName)
+					}
 					fmt.Printf(aec.YellowF.Apply("[%d] < Pushing %s [%s] done.\n"), index, function.Name, imageName)
 				}
 			}
@@ -131,6 +167,7 @@ func pushStack(services *stack.Services, queueDepth int, tagFormat schema.BuildF
 
 	wg.Wait()
 
+	return errors
 }
 
 func validateImages(functions map[string]stack.Function) []string {
@@ -17,6 +17,7 @@ func init() {
 	// Setup flags that are used by multiple commands (variables defined in faas.go)
 	removeCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
 	removeCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(removeCmd.Flags())
 	removeCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
 	removeCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
 	removeCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
@@ -47,7 +48,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	var gatewayAddress string
 	var yamlGateway string
 	if len(yamlFile) > 0 && len(args) == 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
 		if err != nil {
 			return err
 		}
@@ -69,6 +70,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	proxyclient.Retry = newRetryPolicy()
 	ctx := context.Background()
 
 	if len(services.Functions) > 0 {
@@ -86,8 +88,9 @@ func runDelete(cmd *cobra.Command, args []string) error {
 		}
 
 		functionName = args[0]
-		fmt.Printf("Deleting: %s.%s\n", functionName, functionNamespace)
-		err := proxyclient.DeleteFunction(ctx, functionName, functionNamespace)
+		namespace := getNamespace(functionNamespace, "")
+		fmt.Printf("Deleting: %s.%s\n", functionName, namespace)
+		err := proxyclient.DeleteFunction(ctx, functionName, namespace)
 		if err != nil {
 			return err
 		}
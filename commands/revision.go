@@ -0,0 +1,239 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/versioncontrol"
+	"github.com/mitchellh/go-homedir"
+	"github.com/openfaas/faas-provider/types"
+)
+
+const (
+	// revisionAnnotation holds the revision number of the deployed function
+	revisionAnnotation           = "com.forge4flow.revision"
+	revisionImageAnnotation      = "com.forge4flow.revision.image"
+	revisionGitSHAAnnotation     = "com.forge4flow.revision.git-sha"
+	revisionDeployedAtAnnotation = "com.forge4flow.revision.deployed-at"
+	revisionDeployedByAnnotation = "com.forge4flow.revision.deployed-by"
+
+	// revisionHistoryAnnotation holds the previous revisions as JSON, so that
+	// the history can be read from any machine
+	revisionHistoryAnnotation = "com.forge4flow.revision.history"
+
+	// maxRemoteRevisions is how many previous revisions are kept in the
+	// function's annotations, which have a size limit
+	maxRemoteRevisions = 10
+
+	// maxLocalRevisions is how many revisions are kept in the local history
+	maxLocalRevisions = 20
+)
+
+// revision is a deployment of a function. The local history also stores the
+// spec which was deployed, so that a rollback can restore all of it rather
+// than just the image.
+type revision struct {
+	Revision   int                       `json:"revision"`
+	Image      string                    `json:"image"`
+	GitSHA     string                    `json:"gitSha,omitempty"`
+	DeployedAt time.Time                 `json:"deployedAt"`
+	DeployedBy string                    `json:"deployedBy,omitempty"`
+	Spec       *proxy.DeployFunctionSpec `json:"spec,omitempty"`
+}
+
+// stampRevision adds the revision annotations to the spec and returns the new
+// revision. The number follows the highest revision seen in the function's
+// annotations or in the local history.
+func stampRevision(ctx context.Context, client *proxy.Client, spec *proxy.DeployFunctionSpec) revision {
+	local, err := readLocalHistory(client.GatewayURL, spec.Namespace, spec.FunctionName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to read the local history of %s: %s\n", spec.FunctionName, err)
+	}
+
+	var remote []revision
+	status, err := client.GetFunctionInfo(ctx, spec.FunctionName, spec.Namespace)
+	if err == nil {
+		remote = remoteHistory(status)
+	} else if !errors.Is(err, proxy.ErrNotFound) {
+		fmt.Fprintf(os.Stderr, "Unable to read the revision of %s: %s\n", spec.FunctionName, err)
+	}
+
+	latest := 0
+	for _, r := range append(local, remote...) {
+		if r.Revision > latest {
+			latest = r.Revision
+		}
+	}
+
+	next := revision{
+		Revision:   latest + 1,
+		Image:      spec.Image,
+		GitSHA:     versioncontrol.GetGitSHA(),
+		DeployedAt: time.Now().UTC().Truncate(time.Second),
+		DeployedBy: deployer(),
+	}
+
+	if len(remote) > maxRemoteRevisions {
+		remote = remote[len(remote)-maxRemoteRevisions:]
+	}
+	history, _ := json.Marshal(remote)
+
+	if spec.Annotations == nil {
+		spec.Annotations = map[string]string{}
+	}
+	spec.Annotations[revisionAnnotation] = strconv.Itoa(next.Revision)
+	spec.Annotations[revisionImageAnnotation] = next.Image
+	spec.Annotations[revisionGitSHAAnnotation] = next.GitSHA
+	spec.Annotations[revisionDeployedAtAnnotation] = next.DeployedAt.Format(time.RFC3339)
+	spec.Annotations[revisionDeployedByAnnotation] = next.DeployedBy
+	spec.Annotations[revisionHistoryAnnotation] = string(history)
+
+	return next
+}
+
+// remoteHistory reads the revisions from a function's annotations, the last
+// revision is the one which is deployed
+func remoteHistory(status types.FunctionStatus) []revision {
+	if status.Annotations == nil {
+		return nil
+	}
+	annotations := *status.Annotations
+
+	var history []revision
+	if value, ok := annotations[revisionHistoryAnnotation]; ok && len(value) > 0 {
+		if err := json.Unmarshal([]byte(value), &history); err != nil {
+			history = nil
+		}
+	}
+
+	number, err := strconv.Atoi(annotations[revisionAnnotation])
+	if err != nil {
+		return history
+	}
+
+	current := revision{
+		Revision:   number,
+		Image:      annotations[revisionImageAnnotation],
+		GitSHA:     annotations[revisionGitSHAAnnotation],
+		DeployedBy: annotations[revisionDeployedByAnnotation],
+	}
+	current.DeployedAt, _ = time.Parse(time.RFC3339, annotations[revisionDeployedAtAnnotation])
+
+	return append(history, current)
+}
+
+// currentRevision returns the revision number of the deployed function, or 0
+// when it was not deployed with a revision
+func currentRevision(status types.FunctionStatus) int {
+	if status.Annotations == nil {
+		return 0
+	}
+	number, _ := strconv.Atoi((*status.Annotations)[revisionAnnotation])
+	return number
+}
+
+// recordRevision appends a deployed revision and its spec to the local
+// history. Credentials and the revision annotations are not stored.
+func recordRevision(gatewayURL *url.URL, deployed revision, spec proxy.DeployFunctionSpec) error {
+	history, err := readLocalHistory(gatewayURL, spec.Namespace, spec.FunctionName)
+	if err != nil {
+		return err
+	}
+
+	spec.Token = ""
+	spec.RegistryAuth = ""
+	spec.TLSInsecure = false
+	spec.Replace = false
+	spec.Update = true
+	spec.Annotations = withoutRevisionAnnotations(spec.Annotations)
+
+	deployed.Spec = &spec
+	history = append(history, deployed)
+	if len(history) > maxLocalRevisions {
+		history = history[len(history)-maxLocalRevisions:]
+	}
+
+	historyPath, err := localHistoryPath(gatewayURL, spec.Namespace, spec.FunctionName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(historyPath), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(historyPath, data, 0600)
+}
+
+// readLocalHistory returns the revisions deployed from this machine, oldest
+// first
+func readLocalHistory(gatewayURL *url.URL, namespace, functionName string) ([]revision, error) {
+	historyPath, err := localHistoryPath(gatewayURL, namespace, functionName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(historyPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var history []revision
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", historyPath, err)
+	}
+	return history, nil
+}
+
+// localHistoryPath is history/GATEWAY/NAMESPACE/FUNCTION.json within the
+// config directory
+func localHistoryPath(gatewayURL *url.URL, namespace, functionName string) (string, error) {
+	dir, err := homedir.Expand(config.ConfigDir())
+	if err != nil {
+		return "", err
+	}
+
+	if len(namespace) == 0 {
+		namespace = "_default"
+	}
+
+	gatewayDir := strings.NewReplacer(":", "_", "/", "_").Replace(gatewayURL.Host + gatewayURL.Path)
+	return filepath.Join(dir, "history", gatewayDir, namespace, functionName+".json"), nil
+}
+
+func withoutRevisionAnnotations(annotations map[string]string) map[string]string {
+	filtered := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		if k != revisionAnnotation && !strings.HasPrefix(k, revisionAnnotation+".") {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+// deployer names the user who deployed a revision
+func deployer() string {
+	if current, err := user.Current(); err == nil && len(current.Username) > 0 {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
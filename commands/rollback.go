@@ -0,0 +1,175 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/stack"
+	"github.com/openfaas/faas-provider/types"
+	"github.com/spf13/cobra"
+)
+
+var toRevision int
+
+func init() {
+	rollbackCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	rollbackCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
+	rollbackCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(rollbackCmd.Flags())
+	rollbackCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
+	rollbackCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
+	rollbackCmd.Flags().IntVar(&toRevision, "to-revision", 0, "Revision to roll back to, defaults to the previous revision")
+
+	forgeCmd.AddCommand(rollbackCmd)
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   `rollback FUNCTION_NAME [--to-revision N]`,
+	Short: "Roll a function back to a previous revision",
+	Long: `Re-deploys a previous revision of a function, as listed by "forge-cli history".
+
+Revisions in the local history are re-deployed with their full configuration.
+Revisions only known from the function's annotations re-deploy their image with
+the function's current configuration. The rollback is recorded as a new revision.`,
+	Example: `  forge-cli rollback figlet
+  forge-cli rollback figlet --to-revision 3`,
+	RunE: runRollback,
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("please provide a name for the function")
+	}
+	functionName := args[0]
+	namespace := getNamespace(functionNamespace, "")
+
+	client, err := newHistoryClient()
+	if err != nil {
+		return err
+	}
+
+	if msg := checkTLSInsecure(client.GatewayURL.String(), tlsInsecure); len(msg) > 0 {
+		fmt.Println(msg)
+	}
+
+	target, deployed, err := rollbackFunction(context.Background(), client, functionName, namespace, toRevision, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Rolled back %s to revision %d as revision %d.\n", functionName, target.Revision, deployed.Revision)
+	return nil
+}
+
+// rollbackFunction re-deploys the revision to, or the one before the current
+// revision when to is 0, and returns it along with the new revision. Progress
+// is printed to out.
+func rollbackFunction(ctx context.Context, client *proxy.Client, functionName, namespace string, to int, out io.Writer) (revision, revision, error) {
+	history, status, err := loadHistory(ctx, client, functionName, namespace)
+	if err != nil {
+		return revision{}, revision{}, err
+	}
+
+	target, err := rollbackTarget(history, currentRevision(status), to)
+	if err != nil {
+		return revision{}, revision{}, fmt.Errorf("cannot roll back %s: %w", functionName, err)
+	}
+
+	var spec *proxy.DeployFunctionSpec
+	if target.Spec != nil {
+		spec = target.Spec
+	} else if len(status.Name) > 0 {
+		spec = specFromStatus(status, namespace, target.Image)
+	} else {
+		return revision{}, revision{}, fmt.Errorf("cannot roll back %s: revision %d is not in the local history and the function is not deployed", functionName, target.Revision)
+	}
+	spec.Token = token
+	spec.TLSInsecure = tlsInsecure
+
+	fmt.Fprintf(out, "Rolling back %s to revision %d (%s).\n", functionName, target.Revision, target.Image)
+
+	deployed := stampRevision(ctx, client, spec)
+	if err := client.DeployFunction(ctx, spec); err != nil {
+		return revision{}, revision{}, fmt.Errorf("function '%s' failed to roll back: %w", functionName, err)
+	}
+
+	if err := recordRevision(client.GatewayURL, deployed, *spec); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to record revision %d of %s: %s\n", deployed.Revision, functionName, err)
+	}
+
+	return target, deployed, nil
+}
+
+// rollbackTarget picks the revision given by --to-revision, or the one
+// before the current revision. When the current revision is unknown, the one
+// before the latest revision is used.
+func rollbackTarget(history []revision, current, to int) (revision, error) {
+	if len(history) == 0 {
+		return revision{}, fmt.Errorf("no history found")
+	}
+
+	if to > 0 {
+		if to == current {
+			return revision{}, fmt.Errorf("revision %d is already deployed", to)
+		}
+		for _, r := range history {
+			if r.Revision == to {
+				return r, nil
+			}
+		}
+		return revision{}, fmt.Errorf("revision %d not found", to)
+	}
+
+	if current == 0 {
+		current = history[len(history)-1].Revision
+	}
+
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Revision < current {
+			return history[i], nil
+		}
+	}
+	return revision{}, fmt.Errorf("no revision before %d", current)
+}
+
+// specFromStatus re-creates the spec of a deployed function with another image
+func specFromStatus(status types.FunctionStatus, namespace, image string) *proxy.DeployFunctionSpec {
+	spec := &proxy.DeployFunctionSpec{
+		FProcess:               status.EnvProcess,
+		FunctionName:           status.Name,
+		Image:                  image,
+		EnvVars:                status.EnvVars,
+		Constraints:            status.Constraints,
+		Update:                 true,
+		Secrets:                status.Secrets,
+		ReadOnlyRootFilesystem: status.ReadOnlyRootFilesystem,
+		Namespace:              getNamespace(namespace, status.Namespace),
+	}
+
+	if status.Labels != nil {
+		spec.Labels = *status.Labels
+	}
+	if status.Annotations != nil {
+		spec.Annotations = withoutRevisionAnnotations(*status.Annotations)
+	}
+	if status.Limits != nil {
+		spec.FunctionResourceRequest.Limits = &stack.FunctionResources{
+			Memory: status.Limits.Memory,
+			CPU:    status.Limits.CPU,
+		}
+	}
+	if status.Requests != nil {
+		spec.FunctionResourceRequest.Requests = &stack.FunctionResources{
+			Memory: status.Requests.Memory,
+			CPU:    status.Requests.CPU,
+		}
+	}
+
+	return spec
+}
@@ -0,0 +1,119 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/test"
+)
+
+func Test_rollbackTarget(t *testing.T) {
+	history := []revision{{Revision: 1}, {Revision: 2}, {Revision: 4}}
+
+	cases := []struct {
+		name    string
+		current int
+		to      int
+		want    int
+		wantErr string
+	}{
+		{name: "previous revision", current: 4, want: 2},
+		{name: "previous of an older current revision", current: 2, want: 1},
+		{name: "unknown current revision uses the latest", current: 0, want: 2},
+		{name: "given revision", current: 4, to: 1, want: 1},
+		{name: "given revision is deployed", current: 4, to: 4, wantErr: "revision 4 is already deployed"},
+		{name: "given revision is missing", current: 4, to: 3, wantErr: "revision 3 not found"},
+		{name: "no earlier revision", current: 1, wantErr: "no revision before 1"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := rollbackTarget(history, tc.current, tc.to)
+			if len(tc.wantErr) > 0 {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("want error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got.Revision != tc.want {
+				t.Fatalf("want revision %d, got %d", tc.want, got.Revision)
+			}
+		})
+	}
+}
+
+func Test_rollback(t *testing.T) {
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/function/figlet?usage=1",
+			ResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/function/figlet?usage=1",
+			ResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			Method:             http.MethodPut,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			Method:             http.MethodPost,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusAccepted,
+		},
+	})
+	defer s.Close()
+
+	gatewayURL, _ := url.Parse(s.URL)
+	for i, image := range []string{"figlet:0.1", "figlet:0.2"} {
+		spec := proxy.DeployFunctionSpec{FunctionName: "figlet", Image: image, EnvVars: map[string]string{"version": image}}
+		if err := recordRevision(gatewayURL, revision{Revision: i + 1, Image: image, DeployedAt: time.Now()}, spec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resetForTest()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"rollback", "figlet",
+			"--gateway=" + s.URL,
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := "Rolled back figlet to revision 1 as revision 3."
+	if !strings.Contains(stdOut, want) {
+		t.Fatalf("want %q in output:\n%s", want, stdOut)
+	}
+
+	history, err := readLocalHistory(gatewayURL, "", "figlet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := history[len(history)-1]
+	if latest.Revision != 3 || latest.Image != "figlet:0.1" || latest.Spec.EnvVars["version"] != "figlet:0.1" {
+		t.Fatalf("want revision 3 to re-deploy the spec of revision 1, got: %+v", latest)
+	}
+}
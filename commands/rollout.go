@@ -0,0 +1,236 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/stack"
+	"github.com/openfaas/faas-provider/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// defaultWaitTimeout is how long --wait gives a rollout to converge
+const defaultWaitTimeout = 2 * time.Minute
+
+// rolloutPollInterval is the time between two checks of a function's status
+var rolloutPollInterval = time.Second
+
+var waitTimeout time.Duration
+
+func init() {
+	rolloutStatusCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	rolloutStatusCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
+	rolloutStatusCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(rolloutStatusCmd.Flags())
+	rolloutStatusCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
+	rolloutStatusCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
+	rolloutStatusCmd.Flags().DurationVar(&waitTimeout, "wait-timeout", defaultWaitTimeout, "How long to wait for the rollout to complete")
+
+	rolloutCmd.AddCommand(rolloutStatusCmd)
+	forgeCmd.AddCommand(rolloutCmd)
+}
+
+var rolloutCmd = &cobra.Command{
+	Use:   `rollout`,
+	Short: "Manage the rollout of Forge4Flow functions",
+	Long:  `Manage the rollout of Forge4Flow functions`,
+}
+
+var rolloutStatusCmd = &cobra.Command{
+	Use:   `status FUNCTION_NAME [--gateway GATEWAY_URL] [--wait-timeout DURATION]`,
+	Short: "Wait for a function to become ready",
+	Long: `Polls the gateway until every desired replica of the function is available,
+printing progress as it changes. Fails with a summary when the function is not
+ready within --wait-timeout.`,
+	Example: `  forge-cli rollout status figlet
+  forge-cli rollout status figlet --namespace staging --wait-timeout 5m`,
+	RunE: runRolloutStatus,
+}
+
+func runRolloutStatus(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("please provide a name for the function")
+	}
+
+	var yamlGateway string
+	if len(yamlFile) > 0 {
+		parsedServices, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
+		if err != nil {
+			return err
+		}
+
+		if parsedServices != nil {
+			yamlGateway = parsedServices.Provider.GatewayURL
+		}
+	}
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, yamlGateway, os.Getenv(openFaaSURLEnvironment))
+
+	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
+	if err != nil {
+		return err
+	}
+	transport := GetDefaultCLITransport(tlsInsecure, &commandTimeout)
+	client, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &commandTimeout)
+	if err != nil {
+		return err
+	}
+	client.Retry = newRetryPolicy()
+
+	target := rolloutTarget{
+		name:      args[0],
+		namespace: getNamespace(functionNamespace, ""),
+	}
+
+	return waitForRollouts(context.Background(), client, []rolloutTarget{target}, waitTimeout, os.Stdout)
+}
+
+// addWaitFlags adds --wait and --wait-timeout to commands which deploy
+func addWaitFlags(flagset *pflag.FlagSet, deployFlags *DeployFlags) {
+	flagset.BoolVar(&deployFlags.wait, "wait", false, "Wait for the deployed function(s) to become ready")
+	flagset.DurationVar(&deployFlags.waitTimeout, "wait-timeout", defaultWaitTimeout, "How long --wait waits for the rollout to complete")
+}
+
+// rolloutTarget is a function to wait for, an empty image accepts the
+// function with any image
+type rolloutTarget struct {
+	name      string
+	namespace string
+	image     string
+}
+
+func (t rolloutTarget) String() string {
+	if len(t.namespace) > 0 {
+		return t.name + "." + t.namespace
+	}
+	return t.name
+}
+
+// rolloutState is the last status seen for a rolloutTarget
+type rolloutState struct {
+	target rolloutTarget
+	status types.FunctionStatus
+	err    error
+}
+
+// ready is true once the function runs the expected image and all of its
+// desired replicas are available. A function scaled to zero is not ready.
+func (s rolloutState) ready() bool {
+	if s.err != nil {
+		return false
+	}
+	if len(s.target.image) > 0 && !sameImage(s.status.Image, s.target.image) {
+		return false
+	}
+	return s.status.AvailableReplicas > 0 && s.status.AvailableReplicas >= s.status.Replicas
+}
+
+func (s rolloutState) String() string {
+	switch {
+	case errors.Is(s.err, proxy.ErrNotFound):
+		return fmt.Sprintf("%s: not found", s.target)
+	case s.err != nil:
+		return fmt.Sprintf("%s: %s", s.target, s.err)
+	}
+
+	replicas := fmt.Sprintf("%d/%d replicas available", s.status.AvailableReplicas, s.status.Replicas)
+	if len(s.target.image) > 0 && !sameImage(s.status.Image, s.target.image) {
+		return fmt.Sprintf("%s: %s, running image %s, want %s", s.target, replicas, s.status.Image, s.target.image)
+	}
+	return fmt.Sprintf("%s: %s, image %s", s.target, replicas, s.status.Image)
+}
+
+// waitForRollouts polls the gateway until all of the functions are ready,
+// printing each function's progress to out when it changes. A function which
+// is not found is polled again, as the gateway may not have created it yet.
+func waitForRollouts(ctx context.Context, client *proxy.Client, targets []rolloutTarget, timeout time.Duration, out io.Writer) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	states := make([]rolloutState, len(targets))
+	printed := make([]string, len(targets))
+
+	for {
+		pending := 0
+		for i, target := range targets {
+			if states[i].target == target && states[i].ready() {
+				continue
+			}
+
+			status, err := client.GetFunctionInfo(ctx, target.name, target.namespace)
+			if errors.Is(err, proxy.ErrUnauthorized) {
+				return err
+			}
+			if ctx.Err() != nil {
+				break
+			}
+
+			states[i] = rolloutState{target: target, status: status, err: err}
+
+			progress := states[i].String()
+			if states[i].ready() {
+				progress = "Ready " + progress
+			} else {
+				progress = "Waiting for " + progress
+				pending++
+			}
+
+			if progress != printed[i] {
+				fmt.Fprintln(out, progress)
+				printed[i] = progress
+			}
+		}
+
+		if pending == 0 && ctx.Err() == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return rolloutFailed(states, targets, timeout)
+		case <-time.After(rolloutPollInterval):
+		}
+	}
+}
+
+func rolloutFailed(states []rolloutState, targets []rolloutTarget, timeout time.Duration) error {
+	var pending []string
+	for i, target := range targets {
+		switch {
+		case states[i].target != target:
+			pending = append(pending, fmt.Sprintf("  %s: no status received", target))
+		case !states[i].ready():
+			pending = append(pending, "  "+states[i].String())
+		}
+	}
+
+	return fmt.Errorf("rollout did not complete within %s:\n%s", timeout, strings.Join(pending, "\n"))
+}
+
+// sameImage compares two image references, ignoring the Docker Hub registry
+// and library prefix and a missing latest tag
+func sameImage(a, b string) bool {
+	return normalizeImage(a) == normalizeImage(b)
+}
+
+func normalizeImage(image string) string {
+	for _, prefix := range []string{"docker.io/", "index.docker.io/"} {
+		image = strings.TrimPrefix(image, prefix)
+	}
+	image = strings.TrimPrefix(image, "library/")
+
+	name := image[strings.LastIndex(image, "/")+1:]
+	if !strings.Contains(name, ":") && !strings.Contains(name, "@") {
+		image += ":latest"
+	}
+	return image
+}
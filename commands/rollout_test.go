@@ -0,0 +1,181 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forge4flow/forge-cli/config"
+	"github.com/forge4flow/forge-cli/test"
+	"github.com/openfaas/faas-provider/types"
+)
+
+func Test_rolloutStatus(t *testing.T) {
+	defer setRolloutPollInterval(time.Millisecond)()
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/function/figlet?usage=1",
+			ResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			Method:       http.MethodGet,
+			Uri:          "/system/function/figlet?usage=1",
+			ResponseBody: types.FunctionStatus{Name: "figlet", Image: "figlet:0.1", Replicas: 2, AvailableReplicas: 1},
+		},
+		{
+			Method:       http.MethodGet,
+			Uri:          "/system/function/figlet?usage=1",
+			ResponseBody: types.FunctionStatus{Name: "figlet", Image: "figlet:0.1", Replicas: 2, AvailableReplicas: 2},
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"rollout", "status", "figlet",
+			"--gateway=" + s.URL,
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := `Waiting for figlet: not found
+Waiting for figlet: 1/2 replicas available, image figlet:0.1
+Ready figlet: 2/2 replicas available, image figlet:0.1
+`
+	if stdOut != want {
+		t.Fatalf("want:\n%s\ngot:\n%s", want, stdOut)
+	}
+}
+
+func Test_rolloutStatus_Timeout(t *testing.T) {
+	defer setRolloutPollInterval(time.Minute)()
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:       http.MethodGet,
+			Uri:          "/system/function/figlet?namespace=staging&usage=1",
+			ResponseBody: types.FunctionStatus{Name: "figlet", Image: "figlet:0.1", Replicas: 2, AvailableReplicas: 1},
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+
+	var err error
+	test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"rollout", "status", "figlet",
+			"--gateway=" + s.URL,
+			"--namespace=staging",
+			"--wait-timeout=100ms",
+		})
+		err = forgeCmd.Execute()
+	})
+	functionNamespace = ""
+
+	want := "rollout did not complete within 100ms:\n  figlet.staging: 1/2 replicas available, image figlet:0.1"
+	if err == nil || err.Error() != want {
+		t.Fatalf("want error:\n%s\ngot:\n%v", want, err)
+	}
+}
+
+func Test_deploy_wait(t *testing.T) {
+	defer setRolloutPollInterval(time.Millisecond)()
+	defer func() { deployFlags.wait = false }()
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/function/test-function?usage=1",
+			ResponseStatusCode: http.StatusNotFound,
+		},
+		{
+			Method:             http.MethodPut,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusAccepted,
+		},
+		{
+			Method:       http.MethodGet,
+			Uri:          "/system/function/test-function?usage=1",
+			ResponseBody: types.FunctionStatus{Name: "test-function", Image: "golang:1.20", Replicas: 1, AvailableReplicas: 1},
+		},
+		{
+			Method:       http.MethodGet,
+			Uri:          "/system/function/test-function?usage=1",
+			ResponseBody: types.FunctionStatus{Name: "test-function", Image: "docker.io/library/golang", Replicas: 1, AvailableReplicas: 1},
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"deploy",
+			"--gateway=" + s.URL,
+			"--image=golang",
+			"--name=test-function",
+			"--wait",
+		})
+		err = forgeCmd.Execute()
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, want := range []string{
+		"Waiting for test-function: 1/1 replicas available, running image golang:1.20, want golang",
+		"Ready test-function: 1/1 replicas available, image docker.io/library/golang",
+	} {
+		if !strings.Contains(stdOut, want) {
+			t.Fatalf("want %q in output:\n%s", want, stdOut)
+		}
+	}
+}
+
+func Test_sameImage(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"golang", "golang:latest", true},
+		{"docker.io/library/golang:1.20", "golang:1.20", true},
+		{"index.docker.io/alexellis/figlet", "alexellis/figlet:latest", true},
+		{"localhost:5000/figlet", "localhost:5000/figlet:latest", true},
+		{"ghcr.io/openfaas/figlet:0.1", "ghcr.io/openfaas/figlet:0.2", false},
+		{"ghcr.io/library/figlet", "figlet", false},
+		{"figlet@sha256:abc", "figlet", false},
+	}
+
+	for _, tc := range cases {
+		if got := sameImage(tc.a, tc.b); got != tc.want {
+			t.Errorf("sameImage(%q, %q) want %v, got %v", tc.a, tc.b, tc.want, got)
+		}
+	}
+}
+
+// setRolloutPollInterval changes the poll interval and returns a func to
+// restore it
+func setRolloutPollInterval(interval time.Duration) func() {
+	previous := rolloutPollInterval
+	rolloutPollInterval = interval
+	return func() {
+		rolloutPollInterval = previous
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/stack"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scaleReplicas     uint64
+	scaleToZero       bool
+	scaleWait         bool
+	scaleWaitTimeout  time.Duration
+	scalePollInterval = 2 * time.Second
+)
+
+func init() {
+	scaleCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	scaleCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(scaleCmd.Flags())
+	scaleCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
+	scaleCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
+	scaleCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
+
+	scaleCmd.Flags().Uint64VarP(&scaleReplicas, "replicas", "r", 1, "Number of replicas to scale the function(s) to")
+	scaleCmd.Flags().BoolVar(&scaleToZero, "to-zero", false, "Scale the function(s) to zero replicas")
+	scaleCmd.Flags().BoolVar(&scaleWait, "wait", false, "Wait until the available replicas match the requested replicas")
+	scaleCmd.Flags().DurationVar(&scaleWaitTimeout, "wait-timeout", 2*time.Minute, "Maximum time to wait for the function(s) to scale")
+
+	forgeCmd.AddCommand(scaleCmd)
+}
+
+// scaleCmd sets the replica count of deployed Forge4Flow functions
+var scaleCmd = &cobra.Command{
+	Use: `scale FUNCTION_NAME --replicas N [--gateway GATEWAY_URL]
+  forge-cli scale -f YAML_FILE --replicas N [--regex "REGEX"] [--filter "WILDCARD"]`,
+	Short: "Scale deployed Forge4Flow functions",
+	Long: `Sets the number of replicas for deployed Forge4Flow functions either via the
+supplied YAML config using the "--yaml" flag (which may contain multiple function
+definitions), or by explicitly specifying a function name.
+
+Use --wait to block until the gateway reports the requested number of available
+replicas, which is useful for draining or warming functions before traffic shifts.`,
+	Example: `  forge-cli scale figlet --replicas 3
+  forge-cli scale figlet --to-zero
+  forge-cli scale figlet --replicas 2 --wait --wait-timeout 1m
+  forge-cli scale -f ./functions.yml --filter "*gif*" --replicas 0
+  forge-cli scale -f ./functions.yml --regex "fn[0-9]_.*" --replicas 5 --wait`,
+	PreRunE: preRunScale,
+	RunE:    runScale,
+}
+
+func preRunScale(cmd *cobra.Command, args []string) error {
+	if scaleToZero && cmd.Flags().Changed("replicas") && scaleReplicas != 0 {
+		return fmt.Errorf("--to-zero and --replicas are mutually exclusive")
+	}
+
+	if scaleWait && scaleWaitTimeout <= 0 {
+		return fmt.Errorf("--wait-timeout must be greater than zero")
+	}
+
+	return nil
+}
+
+func runScale(cmd *cobra.Command, args []string) error {
+	var services stack.Services
+	var yamlGateway string
+	if len(yamlFile) > 0 && len(args) == 0 {
+		parsedServices, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
+		if err != nil {
+			return err
+		}
+
+		if parsedServices != nil {
+			services = *parsedServices
+			yamlGateway = services.Provider.GatewayURL
+		}
+	}
+
+	replicas := scaleReplicas
+	if scaleToZero {
+		replicas = 0
+	}
+
+	targets := map[string]string{}
+	if len(services.Functions) > 0 {
+		for k, function := range services.Functions {
+			targets[k] = getNamespace(functionNamespace, function.Namespace)
+		}
+	} else {
+		if len(args) < 1 {
+			return fmt.Errorf("please provide the name of a function to scale")
+		}
+		targets[args[0]] = getNamespace(functionNamespace, "")
+	}
+
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, yamlGateway, os.Getenv(openFaaSURLEnvironment))
+
+	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
+	if err != nil {
+		return err
+	}
+	transport := GetDefaultCLITransport(tlsInsecure, &commandTimeout)
+	proxyClient, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &commandTimeout)
+	if err != nil {
+		return err
+	}
+	proxyClient.Retry = newRetryPolicy()
+
+	ctx := context.Background()
+
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("Scaling: %s to %d replica(s).\n", formatFunctionRef(name, targets[name]), replicas)
+		if err := proxyClient.ScaleFunction(ctx, name, targets[name], replicas); err != nil {
+			return err
+		}
+	}
+
+	if !scaleWait {
+		return nil
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, scaleWaitTimeout)
+	defer cancel()
+
+	for _, name := range names {
+		if err := waitForReplicas(waitCtx, proxyClient, name, targets[name], replicas, scalePollInterval); err != nil {
+			return err
+		}
+		fmt.Printf("Scaled: %s has %d available replica(s).\n", formatFunctionRef(name, targets[name]), replicas)
+	}
+
+	return nil
+}
+
+// waitForReplicas polls the gateway until the function reports the wanted
+// number of available replicas, or the context expires.
+func waitForReplicas(ctx context.Context, client *proxy.Client, name, namespace string, replicas uint64, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var available uint64
+	for {
+		function, err := client.GetFunctionInfo(ctx, name, namespace)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("timed out waiting for %s to scale to %d replica(s), %d available",
+					formatFunctionRef(name, namespace), replicas, available)
+			}
+			return err
+		}
+
+		available = function.AvailableReplicas
+		if available == replicas {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %s to scale to %d replica(s), %d available",
+				formatFunctionRef(name, namespace), replicas, available)
+		case <-ticker.C:
+		}
+	}
+}
+
+func formatFunctionRef(name, namespace string) string {
+	if len(namespace) > 0 {
+		return name + "." + namespace
+	}
+	return name
+}
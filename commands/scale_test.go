@@ -0,0 +1,91 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forge4flow/forge-cli/proxy"
+	"github.com/forge4flow/forge-cli/test"
+	types "github.com/openfaas/faas-provider/types"
+)
+
+func Test_scale(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodPost,
+			Uri:                "/system/scale-function/figlet",
+			ResponseStatusCode: http.StatusAccepted,
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+
+	stdOut := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{
+			"scale",
+			"figlet",
+			"--replicas=3",
+			"--gateway=" + s.URL,
+		})
+		if err := forgeCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if !strings.Contains(stdOut, "Scaling: figlet to 3 replica(s).") {
+		t.Fatalf("Output is not as expected:\n%s", stdOut)
+	}
+}
+
+func Test_waitForReplicas(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:       http.MethodGet,
+			ResponseBody: types.FunctionStatus{Name: "figlet", AvailableReplicas: 1},
+		},
+		{
+			Method:       http.MethodGet,
+			ResponseBody: types.FunctionStatus{Name: "figlet", AvailableReplicas: 2},
+		},
+	})
+	defer s.Close()
+
+	client, _ := proxy.NewClient(&proxy.BasicAuth{}, s.URL, nil, &commandTimeout)
+
+	err := waitForReplicas(context.Background(), client, "figlet", "", 2, time.Millisecond)
+	if err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+}
+
+func Test_waitForReplicas_Timeout(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:       http.MethodGet,
+			ResponseBody: types.FunctionStatus{Name: "figlet", AvailableReplicas: 1},
+		},
+	})
+	defer s.Close()
+
+	client, _ := proxy.NewClient(&proxy.BasicAuth{}, s.URL, nil, &commandTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := waitForReplicas(ctx, client, "figlet", "dev", 0, time.Second)
+	if err == nil {
+		t.Fatal("want timeout error, got nil")
+	}
+
+	want := "timed out waiting for figlet.dev to scale to 0 replica(s), 1 available"
+	if err.Error() != want {
+		t.Fatalf("want %q, got %q", want, err.Error())
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/forge4flow/forge-cli/stack"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	forgeCmd.AddCommand(schemaCmd)
+}
+
+var schemaCmd = &cobra.Command{
+	Use:   `schema`,
+	Short: "Print the JSON Schema for stack files",
+	Long: `Prints a JSON Schema for the stack file, which editors can use to complete
+and validate functions.yml while it is being written.`,
+	Example: `  forge-cli schema > stack.schema.json
+
+  # With the YAML language server, add to the top of functions.yml:
+  # yaml-language-server: $schema=./stack.schema.json`,
+	RunE: runSchema,
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	out, err := json.MarshalIndent(stack.JSONSchema(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(out))
+	return nil
+}
@@ -45,6 +45,7 @@ func init() {
 	secretCreateCmd.Flags().StringVar(&secretFile, "from-file", "", "Path and filename containing value for the secret")
 	secretCreateCmd.Flags().BoolVar(&trimSecret, "trim", true, "Trim whitespace from the start and end of the secret value")
 	secretCreateCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(secretCreateCmd.Flags())
 	secretCreateCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
 	secretCreateCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
 	secretCreateCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
@@ -76,7 +77,7 @@ func preRunSecretCreate(cmd *cobra.Command, args []string) error {
 func runSecretCreate(cmd *cobra.Command, args []string) error {
 	secret := types.Secret{
 		Name:      args[0],
-		Namespace: functionNamespace,
+		Namespace: getNamespace(functionNamespace, ""),
 	}
 
 	switch {
@@ -128,10 +129,13 @@ func runSecretCreate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	client.Retry = newRetryPolicy()
 
-	fmt.Printf("Creating secret: %s.%s\n", secret.Name, functionNamespace)
-	_, output := client.CreateSecret(context.Background(), secret)
-	fmt.Printf(output)
+	fmt.Printf("Creating secret: %s.%s\n", secret.Name, secret.Namespace)
+	if err := client.CreateSecret(context.Background(), secret); err != nil {
+		return err
+	}
+	fmt.Println("Created.")
 
 	return nil
 }
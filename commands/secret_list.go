@@ -22,7 +22,8 @@ var secretListCmd = &cobra.Command{
 	Short:   "List all secrets",
 	Long:    `List all secrets`,
 	Example: `forge-cli secret list
-forge-cli secret list --gateway=http://127.0.0.1:8080`,
+forge-cli secret list --gateway=http://127.0.0.1:8080
+forge-cli secret list -o jsonpath='{range [*]}{.name}{"\n"}{end}'`,
 	RunE:    runSecretList,
 	PreRunE: preRunSecretListCmd,
 }
@@ -30,8 +31,10 @@ forge-cli secret list --gateway=http://127.0.0.1:8080`,
 func init() {
 	secretListCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
 	secretListCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(secretListCmd.Flags())
 	secretListCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
 	secretListCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
+	addOutputFlag(secretListCmd.Flags())
 
 	secretCmd.AddCommand(secretListCmd)
 }
@@ -57,12 +60,20 @@ func runSecretList(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	client.Retry = newRetryPolicy()
 
-	secrets, err := client.GetSecretList(context.Background(), functionNamespace)
+	secrets, err := client.GetSecretList(context.Background(), getNamespace(functionNamespace, ""))
 	if err != nil {
 		return err
 	}
 
+	if structuredOutput() {
+		if secrets == nil {
+			secrets = []types.Secret{}
+		}
+		return printOutput(secrets)
+	}
+
 	if len(secrets) == 0 {
 		fmt.Printf("No secrets found.\n")
 		return nil
@@ -27,6 +27,7 @@ forge-cli secret remove NAME --gateway=http://127.0.0.1:8080`,
 func init() {
 	secretRemoveCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
 	secretRemoveCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(secretRemoveCmd.Flags())
 	secretRemoveCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
 	secretRemoveCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
 	secretCmd.AddCommand(secretRemoveCmd)
@@ -53,7 +54,7 @@ func runSecretRemove(cmd *cobra.Command, args []string) error {
 
 	secret := types.Secret{
 		Name:      args[0],
-		Namespace: functionNamespace,
+		Namespace: getNamespace(functionNamespace, ""),
 	}
 
 	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
@@ -65,6 +66,7 @@ func runSecretRemove(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	client.Retry = newRetryPolicy()
 
 	err = client.RemoveSecret(context.Background(), secret)
 	if err != nil {
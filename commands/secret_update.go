@@ -33,6 +33,7 @@ cat /path/to/secret/file | forge-cli secret update NAME`,
 func init() {
 	secretUpdateCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
 	secretUpdateCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(secretUpdateCmd.Flags())
 	secretUpdateCmd.Flags().StringVar(&literalSecret, "from-literal", "", "Value of the secret")
 	secretUpdateCmd.Flags().StringVar(&secretFile, "from-file", "", "Path to the secret file")
 	secretUpdateCmd.Flags().BoolVar(&trimSecret, "trim", true, "trim whitespace from the start and end of the secret value")
@@ -66,7 +67,7 @@ func runSecretUpdate(cmd *cobra.Command, args []string) error {
 
 	secret := types.Secret{
 		Name:      args[0],
-		Namespace: functionNamespace,
+		Namespace: getNamespace(functionNamespace, ""),
 	}
 
 	switch {
@@ -110,10 +111,13 @@ func runSecretUpdate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	client.Retry = newRetryPolicy()
 
 	fmt.Println("Updating secret: " + secret.Name)
-	_, output := client.UpdateSecret(context.Background(), secret)
-	fmt.Printf(output)
+	if err := client.UpdateSecret(context.Background(), secret); err != nil {
+		return err
+	}
+	fmt.Println("Updated.")
 
 	return nil
 }
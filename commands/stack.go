@@ -0,0 +1,70 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// yamlOverlays holds the stack files given after the first -f flag, they are
+// merged on top of yamlFile in order
+var yamlOverlays []string
+
+// yamlFileFlag is the value of the -f flag, the first file replaces the
+// default stack file and any further files are added as overlays
+type yamlFileFlag struct {
+	set bool
+}
+
+var yamlFlag = &yamlFileFlag{}
+
+func (f *yamlFileFlag) String() string {
+	return yamlFile
+}
+
+func (f *yamlFileFlag) Set(value string) error {
+	if !f.set {
+		yamlFile = value
+		f.set = true
+		return nil
+	}
+
+	yamlOverlays = append(yamlOverlays, value)
+	return nil
+}
+
+func (f *yamlFileFlag) Type() string {
+	return "string"
+}
+
+// reset forgets the files given to a previous run
+func (f *yamlFileFlag) reset() {
+	f.set = false
+	yamlOverlays = nil
+}
+
+// yamlFiles returns the stack file followed by its overlays
+func yamlFiles() []string {
+	return append([]string{yamlFile}, yamlOverlays...)
+}
+
+func init() {
+	forgeCmd.AddCommand(stackCmd)
+}
+
+var stackCmd = &cobra.Command{
+	Use:   `stack`,
+	Short: "Work with stack files",
+	Long: `Work with stack files composed from several files.
+
+A stack file can list other files under "include", which are merged before the
+file itself. Giving -f more than once merges each file on top of the previous
+ones, so that environment specific files only need to carry their differences:
+
+  forge-cli deploy -f stack.yml -f prod.yml
+
+Values set in a later file replace scalar values such as the image, maps such as
+environment, labels and annotations are merged key by key, and lists such as
+secrets are merged as sets.`,
+}
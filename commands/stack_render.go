@@ -0,0 +1,50 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/forge4flow/forge-cli/stack"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v3"
+)
+
+var stackRenderCmd = &cobra.Command{
+	Use:   `render -f YAML_FILE [-f OVERLAY_FILE...]`,
+	Short: "Print the merged stack file",
+	Long: `Print the stack file after merging its includes and any overlays given with
+additional -f flags, with environment variables substituted.`,
+	Example: `  forge-cli stack render
+  forge-cli stack render -f stack.yml -f prod.yml
+  forge-cli stack render -f stack.yml -f prod.yml --filter "api-*"`,
+	RunE: runStackRender,
+}
+
+func init() {
+	stackRenderCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
+	stackCmd.AddCommand(stackRenderCmd)
+}
+
+func runStackRender(cmd *cobra.Command, args []string) error {
+	if len(yamlFile) == 0 {
+		return fmt.Errorf("give a stack file with -f")
+	}
+
+	services, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(services); err != nil {
+		return err
+	}
+
+	fmt.Print(out.String())
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/forge4flow/forge-cli/test"
+)
+
+func Test_stackRender_Overlays(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "stack.yml")
+	prod := filepath.Join(dir, "prod.yml")
+
+	os.WriteFile(base, []byte(`provider:
+  name: functions4flow
+functions:
+  api:
+    lang: go
+    handler: ./api
+    image: api:latest
+`), 0600)
+	os.WriteFile(prod, []byte(`functions:
+  api:
+    image: api:1.2.0
+`), 0600)
+
+	var err error
+	out := test.CaptureStdout(func() {
+		forgeCmd.SetArgs([]string{"stack", "render", "-f", base, "-f", prod})
+		err = forgeCmd.Execute()
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !strings.Contains(out, "image: api:1.2.0") || !strings.Contains(out, "handler: ./api") {
+		t.Fatalf("want the overlay merged into the base, got:\n%s", out)
+	}
+
+	if got := yamlFiles(); len(got) != 2 || got[0] != base || got[1] != prod {
+		t.Fatalf("want the base followed by the overlay, got %v", got)
+	}
+}
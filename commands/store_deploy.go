@@ -28,6 +28,7 @@ func init() {
 	storeDeployCmd.Flags().StringArrayVar(&storeDeployFlags.secrets, "secret", []string{}, "Give the function access to a secure secret")
 	storeDeployCmd.Flags().StringArrayVarP(&storeDeployFlags.annotationOpts, "annotation", "", []string{}, "Set one or more annotation (ANNOTATION=VALUE)")
 	storeDeployCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	addRetryFlags(storeDeployCmd.Flags())
 	storeDeployCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
 	storeDeployCmd.Flags().DurationVar(&timeoutOverride, "timeout", commandTimeout, "Timeout for any HTTP calls made to the Forge4Flow API.")
 
@@ -138,8 +139,9 @@ func runStoreDeploy(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	proxyClient.Retry = newRetryPolicy()
 
-	statusCode, err := deployImage(context.Background(),
+	err = deployImage(context.Background(),
 		proxyClient,
 		imageName,
 		item.Fprocess,
@@ -149,17 +151,14 @@ func runStoreDeploy(cmd *cobra.Command, args []string) error {
 		tlsInsecure,
 		item.ReadOnlyRootFilesystem,
 		token,
-		functionNamespace,
+		getNamespace(functionNamespace, ""),
 		cpuRequest,
 		cpuLimit,
 		memoryRequest,
 		memoryLimit)
-
-	if badStatusCode(statusCode) {
-		failedStatusCode := map[string]int{itemName: statusCode}
-		err := deployFailed(failedStatusCode)
-		return err
+	if err != nil {
+		return deployFailed(map[string]error{itemName: err})
 	}
 
-	return err
+	return nil
 }
@@ -16,6 +16,7 @@ import (
 func init() {
 	// Setup flags used by store command
 	storeListCmd.Flags().BoolVarP(&verbose, "verbose", "v", true, "Enable verbose output to see the full description of each function in the store")
+	addOutputFlag(storeListCmd.Flags())
 
 	storeCmd.AddCommand(storeListCmd)
 }
@@ -26,7 +27,8 @@ var storeListCmd = &cobra.Command{
 	Short:   "List available Forge4Flow functions in a store",
 	Example: `  forge-cli store list
   forge-cli store list --verbose
-  forge-cli store list --url https://host:port/store.json`,
+  forge-cli store list --url https://host:port/store.json
+  forge-cli store list --output json`,
 	RunE: runStoreList,
 }
 
@@ -40,6 +42,13 @@ func runStoreList(cmd *cobra.Command, args []string) error {
 
 	filteredFunctions := filterStoreList(storeList, targetPlatform)
 
+	if structuredOutput() {
+		if filteredFunctions == nil {
+			filteredFunctions = []storeV2.StoreFunction{}
+		}
+		return printOutput(filteredFunctions)
+	}
+
 	if len(filteredFunctions) == 0 {
 		availablePlatforms := getStorePlatforms(storeList)
 		fmt.Printf("No functions found in the store for platform '%s', try one of the following: %s\n", targetPlatform, strings.Join(availablePlatforms, ", "))
@@ -129,7 +129,12 @@ func templatePullLocalTemplateRepo(t *testing.T) {
 	localTemplateRepository := setupLocalTemplateRepo(t)
 	defer os.RemoveAll(localTemplateRepository)
 
-	forgeCmd.SetArgs([]string{"template", "pull", localTemplateRepository})
+	templatePullRepo(t, localTemplateRepository)
+}
+
+// templatePullRepo pulls the templates of repository to ./template
+func templatePullRepo(t *testing.T, repository string) {
+	forgeCmd.SetArgs([]string{"template", "pull", repository})
 	err := forgeCmd.Execute()
 	if err != nil {
 		fmt.Printf("error while executing template pull: %s", err.Error())
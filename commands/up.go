@@ -58,6 +58,23 @@ definitions), or directly via flags.
 The push step may be skipped by setting the --skip-push flag
 and the deploy step with --skip-deploy.
 
+The images are pushed with the same builder as they were built with, see
+--builder. The remote builder pushes the images itself. Functions which are
+unchanged since they were last built are neither built nor pushed again, add
+--force to build and push every function. Add --skip-unchanged to also skip the
+deploy of functions whose image and settings are unchanged since they were last
+deployed.
+
+In CI, --changed-since REF only builds, pushes and deploys the functions with a
+file changed in "git diff REF...HEAD" under their handler or template folder.
+A change to a "copy" path of the YAML file, or to the YAML file itself, includes
+every function.
+
+The "hooks" of each function in the YAML file are run before and after it is
+built and deployed, then its "smoke_test" is run. A failing hook stops the
+function, add --rollback-on-failure to roll it back when it fails after being
+deployed.
+
 Note: All flags from the build, push and deploy flags are valid and can be combined,
 see the --help text for those commands for details.`,
 	Example: `  # Deploy everything
@@ -66,6 +83,12 @@ see the --help text for those commands for details.`,
   # Deploy a named function
   forge-cli up --filter echo
 
+  # Only deploy the functions which were rebuilt or changed in the YAML file
+  forge-cli up --skip-unchanged
+
+  # Deploy the functions changed on this branch
+  forge-cli up --changed-since origin/main
+
   # Deploy but skip the push step
   forge-cli up --skip-push
 
@@ -113,7 +136,7 @@ func upRunner(cmd *cobra.Command, args []string, ctx context.Context) error {
 			return err
 		}
 
-		if !skipPush && remoteBuilder == "" {
+		if !skipPush {
 			if err := runPush(cmd, args); err != nil {
 				return err
 			}
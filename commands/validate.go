@@ -5,8 +5,53 @@ package commands
 
 import (
 	"errors"
+	"fmt"
+
+	"github.com/forge4flow/forge-cli/stack"
+	"github.com/spf13/cobra"
 )
 
+func init() {
+	validateCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in functions.yml file")
+
+	forgeCmd.AddCommand(validateCmd)
+}
+
+var validateCmd = &cobra.Command{
+	Use:   `validate -f YAML_FILE [-f OVERLAY_FILE...]`,
+	Short: "Validate a stack file",
+	Long: `Checks the stack file, its overlays and the files they include for unknown
+fields, invalid function, secret and namespace names, bad resource quantities and
+missing handlers. Each problem is reported as FILE:LINE:COLUMN.
+
+Use "forge-cli schema" to get a JSON Schema for editor integration.`,
+	Example: `  forge-cli validate
+  forge-cli validate -f stack.yml -f prod.yml`,
+	RunE: runValidate,
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	if len(yamlFile) == 0 {
+		return fmt.Errorf("give a stack file with -f")
+	}
+
+	problems, err := stack.ValidateYAMLFiles(yamlFiles(), envsubst)
+	if err != nil {
+		return err
+	}
+
+	for _, problem := range problems {
+		fmt.Println(problem.Error())
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problem(s) in the stack file", len(problems))
+	}
+
+	fmt.Println("Stack file is valid.")
+	return nil
+}
+
 func validateLanguageFlag(language string) (string, error) {
 	var err error
 
@@ -16,6 +16,7 @@ import (
 	"github.com/forge4flow/forge-cli/stack"
 	"github.com/forge4flow/forge-cli/version"
 	"github.com/morikuni/aec"
+	gatewayTypes "github.com/openfaas/faas/gateway/types"
 	"github.com/spf13/cobra"
 )
 
@@ -34,6 +35,7 @@ func init() {
 	versionCmd.Flags().BoolVar(&warnUpdate, "warn-update", true, "Check for new version and warn about updating")
 
 	versionCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
+	addOutputFlag(versionCmd.Flags())
 	forgeCmd.AddCommand(versionCmd)
 }
 
@@ -46,7 +48,8 @@ var versionCmd = &cobra.Command{
 This currently consists of the GitSHA from which the client was built.
 - https://github.com/forge4flow/forge-cli/tree/%s`, version.GitCommit),
 	Example: `  forge-cli version
-  forge-cli version --short-version`,
+  forge-cli version --short-version
+  forge-cli version --output json`,
 	RunE: runVersionE,
 }
 
@@ -56,6 +59,10 @@ func runVersionE(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if structuredOutput() {
+		return printVersionOutput()
+	}
+
 	printLogo()
 	fmt.Printf(`CLI:
  commit:  %s
@@ -78,13 +85,68 @@ func runVersionE(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// versionOutput is printed by "version --output"
+type versionOutput struct {
+	CLI     cliVersion      `json:"cli"`
+	Gateway *gatewayVersion `json:"gateway,omitempty"`
+}
+
+type cliVersion struct {
+	Commit  string `json:"commit"`
+	Version string `json:"version"`
+}
+
+type gatewayVersion struct {
+	URL string `json:"url"`
+	gatewayTypes.GatewayInfo
+}
+
+// printVersionOutput prints the versions in the format given by --output,
+// the gateway is left out when it can't be reached and the update check is
+// skipped so that the output can be parsed
+func printVersionOutput() error {
+	out := versionOutput{
+		CLI: cliVersion{
+			Commit:  version.GitCommit,
+			Version: version.BuildVersion(),
+		},
+	}
+
+	if gatewayAddress, gatewayInfo, err := getServerVersions(); err == nil {
+		out.Gateway = &gatewayVersion{
+			URL:         gatewayAddress,
+			GatewayInfo: gatewayInfo,
+		}
+	}
+
+	return printOutput(out)
+}
+
 func printServerVersions() error {
+	gatewayAddress, gatewayInfo, err := getServerVersions()
+	if err != nil {
+		return err
+	}
+
+	printGatewayDetails(gatewayAddress, gatewayInfo.Version.Release, gatewayInfo.Version.SHA)
+
+	fmt.Printf(`
+Provider
+ name:          %s
+ orchestration: %s
+ version:       %s 
+ sha:           %s
+`, gatewayInfo.Provider.Name, gatewayInfo.Provider.Orchestration, gatewayInfo.Provider.Version.Release, gatewayInfo.Provider.Version.SHA)
+	return nil
+}
 
+// getServerVersions returns the gateway's address and its /system/info
+func getServerVersions() (string, gatewayTypes.GatewayInfo, error) {
 	var services stack.Services
 	var gatewayAddress string
 	var yamlGateway string
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := stack.ParseYAMLFiles(yamlFiles(), regex, filter, envsubst)
 		if err == nil && parsedServices != nil {
 			services = *parsedServices
 			yamlGateway = services.Provider.GatewayURL
@@ -96,28 +158,15 @@ func printServerVersions() error {
 	versionTimeout := 5 * time.Second
 	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
 	if err != nil {
-		return err
+		return gatewayAddress, gatewayTypes.GatewayInfo{}, err
 	}
 	transport := GetDefaultCLITransport(tlsInsecure, &versionTimeout)
 	cliClient, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &versionTimeout)
 	if err != nil {
-		return err
+		return gatewayAddress, gatewayTypes.GatewayInfo{}, err
 	}
 	gatewayInfo, err := cliClient.GetSystemInfo(context.Background())
-	if err != nil {
-		return err
-	}
-
-	printGatewayDetails(gatewayAddress, gatewayInfo.Version.Release, gatewayInfo.Version.SHA)
-
-	fmt.Printf(`
-Provider
- name:          %s
- orchestration: %s
- version:       %s 
- sha:           %s
-`, gatewayInfo.Provider.Name, gatewayInfo.Provider.Orchestration, gatewayInfo.Provider.Version.Release, gatewayInfo.Provider.Version.SHA)
-	return nil
+	return gatewayAddress, gatewayInfo, err
 }
 
 func printGatewayDetails(gatewayAddress, version, sha string) {
@@ -47,8 +47,20 @@ const (
 
 // ConfigFile for Forge4Flow CLI exclusively.
 type ConfigFile struct {
-	AuthConfigs []AuthConfig `yaml:"auths"`
-	FilePath    string       `yaml:"-"`
+	AuthConfigs    []AuthConfig `yaml:"auths"`
+	Contexts       []Context    `yaml:"contexts,omitempty"`
+	CurrentContext string       `yaml:"current-context,omitempty"`
+
+	// CredsStore is the name of the credential store used to save tokens
+	// instead of writing them to this file, i.e. "osxkeychain" runs the
+	// forge-cli-credential-osxkeychain helper and "file" uses a file
+	// next to this one, which is only protected by its permissions
+	CredsStore string `yaml:"credsStore,omitempty"`
+
+	// CredHelpers overrides CredsStore for individual gateway URLs
+	CredHelpers map[string]string `yaml:"credHelpers,omitempty"`
+
+	FilePath string `yaml:"-"`
 }
 
 type AuthConfig struct {
@@ -63,6 +75,34 @@ type Option struct {
 	Value string `yaml:"value"`
 }
 
+// GetOption returns the value of the named option, or an empty string when it is not set
+func (a AuthConfig) GetOption(name string) string {
+	for _, option := range a.Options {
+		if option.Name == name {
+			return option.Value
+		}
+	}
+	return ""
+}
+
+// SetOption creates or replaces the named option, an empty value removes it
+func (a *AuthConfig) SetOption(name string, value string) {
+	for i, option := range a.Options {
+		if option.Name == name {
+			if len(value) == 0 {
+				a.Options = append(a.Options[:i], a.Options[i+1:]...)
+			} else {
+				a.Options[i].Value = value
+			}
+			return
+		}
+	}
+
+	if len(value) > 0 {
+		a.Options = append(a.Options, Option{Name: name, Value: value})
+	}
+}
+
 var ErrConfigNotFound = errors.New("config file not found")
 
 type AuthConfigNotFoundError struct {
@@ -201,6 +241,10 @@ func (configFile *ConfigFile) load() error {
 	if len(conf.AuthConfigs) > 0 {
 		configFile.AuthConfigs = conf.AuthConfigs
 	}
+	configFile.Contexts = conf.Contexts
+	configFile.CurrentContext = conf.CurrentContext
+	configFile.CredsStore = conf.CredsStore
+	configFile.CredHelpers = conf.CredHelpers
 	return nil
 }
 
@@ -249,6 +293,18 @@ func UpdateAuthConfig(authConfig AuthConfig) error {
 		return err
 	}
 
+	if name := cfg.credentialStoreName(gateway); len(name) > 0 {
+		store, err := NewCredentialStore(name)
+		if err != nil {
+			return err
+		}
+
+		authConfig, err = storeAuthConfig(store, authConfig)
+		if err != nil {
+			return err
+		}
+	}
+
 	index := -1
 	for i, v := range cfg.AuthConfigs {
 		if gateway == v.Gateway {
@@ -294,14 +350,45 @@ func LookupAuthConfig(gateway string) (AuthConfig, error) {
 
 	for _, v := range cfg.AuthConfigs {
 		if gateway == v.Gateway {
-			authConfig = v
-			return authConfig, nil
+			return cfg.resolveAuthConfig(v)
+		}
+	}
+
+	// A context may share the credentials saved for another gateway URL
+	for _, c := range cfg.Contexts {
+		if c.Gateway != gateway || len(c.Auth) == 0 {
+			continue
+		}
+		for _, v := range cfg.AuthConfigs {
+			if c.Auth == v.Gateway {
+				return cfg.resolveAuthConfig(v)
+			}
 		}
 	}
 
 	return authConfig, &AuthConfigNotFoundError{Gateway: gateway}
 }
 
+// resolveAuthConfig reads the token and options from the credential store,
+// entries saved before a store was configured are returned as they are
+func (configFile *ConfigFile) resolveAuthConfig(authConfig AuthConfig) (AuthConfig, error) {
+	name := configFile.credentialStoreName(authConfig.Gateway)
+	if len(name) == 0 {
+		return authConfig, nil
+	}
+
+	store, err := NewCredentialStore(name)
+	if err != nil {
+		return authConfig, err
+	}
+
+	resolved, err := loadAuthConfig(store, authConfig)
+	if errors.Is(err, ErrCredentialsNotFound) {
+		return authConfig, nil
+	}
+	return resolved, err
+}
+
 // RemoveAuthConfig deletes the username and password for a given gateway
 func RemoveAuthConfig(gateway string) error {
 	if !fileExists() {
@@ -331,6 +418,18 @@ func RemoveAuthConfig(gateway string) error {
 	}
 
 	if index > -1 {
+		if name := cfg.credentialStoreName(gateway); len(name) > 0 {
+			store, err := NewCredentialStore(name)
+			if err != nil {
+				return err
+			}
+
+			err = store.Erase(gateway)
+			if err != nil && !errors.Is(err, ErrCredentialsNotFound) {
+				return err
+			}
+		}
+
 		cfg.AuthConfigs = removeAuthByIndex(cfg.AuthConfigs, index)
 		if err := cfg.save(); err != nil {
 			return err
@@ -464,3 +464,28 @@ func Test_ConfigDir(t *testing.T) {
 	}
 
 }
+
+func Test_AuthConfig_SetOption(t *testing.T) {
+	authConfig := AuthConfig{}
+
+	authConfig.SetOption("client_id", "forge-cli")
+	authConfig.SetOption("refresh_token", "abc")
+	authConfig.SetOption("refresh_token", "def")
+
+	if got := authConfig.GetOption("refresh_token"); got != "def" {
+		t.Errorf("want refresh_token %q, got %q", "def", got)
+	}
+
+	if len(authConfig.Options) != 2 {
+		t.Errorf("want 2 options, got %d", len(authConfig.Options))
+	}
+
+	authConfig.SetOption("client_id", "")
+	if got := authConfig.GetOption("client_id"); got != "" {
+		t.Errorf("want client_id to be removed, got %q", got)
+	}
+
+	if len(authConfig.Options) != 1 {
+		t.Errorf("want 1 option, got %d", len(authConfig.Options))
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Context is a named set of defaults for talking to a gateway, so that
+// commands don't need --gateway and --namespace on every call.
+type Context struct {
+	Name string `yaml:"name"`
+
+	// Gateway URL used when no gateway is given via flags or the stack file
+	Gateway string `yaml:"gateway"`
+
+	// Namespace used when no namespace is given via flags or the stack file
+	Namespace string `yaml:"namespace,omitempty"`
+
+	// Auth is the gateway URL of the saved credentials to use, when they
+	// were saved for a different URL than Gateway
+	Auth string `yaml:"auth,omitempty"`
+
+	// TLSInsecure disables TLS validation for the gateway
+	TLSInsecure bool `yaml:"tls-no-verify,omitempty"`
+}
+
+// ContextNotFoundError is returned when a named context does not exist
+type ContextNotFoundError struct {
+	Name string
+}
+
+func (e *ContextNotFoundError) Error() string {
+	return fmt.Sprintf("no context found with name %q", e.Name)
+}
+
+// loadConfigFile loads the config file, creating it when it does not exist
+func loadConfigFile() (*ConfigFile, error) {
+	configPath, err := EnsureFile()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := New(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.load(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// ListContexts returns all contexts and the name of the current context
+func ListContexts() ([]Context, string, error) {
+	if !fileExists() {
+		return nil, "", nil
+	}
+
+	cfg, err := loadConfigFile()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return cfg.Contexts, cfg.CurrentContext, nil
+}
+
+// LookupCurrentContext returns the current context, or nil when no context is in use
+func LookupCurrentContext() (*Context, error) {
+	if !fileExists() {
+		return nil, nil
+	}
+
+	cfg, err := loadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.CurrentContext) == 0 {
+		return nil, nil
+	}
+
+	for _, c := range cfg.Contexts {
+		if c.Name == cfg.CurrentContext {
+			return &c, nil
+		}
+	}
+
+	return nil, &ContextNotFoundError{Name: cfg.CurrentContext}
+}
+
+// UpdateContext creates or replaces a context with the same name
+func UpdateContext(context Context) error {
+	if len(context.Name) == 0 {
+		return fmt.Errorf("context name cannot be empty")
+	}
+
+	if _, err := url.ParseRequestURI(context.Gateway); err != nil || len(context.Gateway) == 0 {
+		return fmt.Errorf("invalid gateway URL")
+	}
+
+	cfg, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	index := -1
+	for i, c := range cfg.Contexts {
+		if c.Name == context.Name {
+			index = i
+			break
+		}
+	}
+
+	if index == -1 {
+		cfg.Contexts = append(cfg.Contexts, context)
+	} else {
+		cfg.Contexts[index] = context
+	}
+
+	return cfg.save()
+}
+
+// UseContext sets the current context, an empty name stops using contexts
+func UseContext(name string) error {
+	cfg, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	if len(name) > 0 {
+		found := false
+		for _, c := range cfg.Contexts {
+			if c.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &ContextNotFoundError{Name: name}
+		}
+	}
+
+	cfg.CurrentContext = name
+	return cfg.save()
+}
+
+// RemoveContext deletes a context, and unsets it if it was the current context
+func RemoveContext(name string) error {
+	if !fileExists() {
+		return ErrConfigNotFound
+	}
+
+	cfg, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	index := -1
+	for i, c := range cfg.Contexts {
+		if c.Name == name {
+			index = i
+			break
+		}
+	}
+
+	if index == -1 {
+		return &ContextNotFoundError{Name: name}
+	}
+
+	cfg.Contexts = append(cfg.Contexts[:index], cfg.Contexts[index+1:]...)
+	if cfg.CurrentContext == name {
+		cfg.CurrentContext = ""
+	}
+
+	return cfg.save()
+}
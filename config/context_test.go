@@ -0,0 +1,103 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func Test_Contexts_UpdateUseRemove(t *testing.T) {
+	configDir, err := os.MkdirTemp("", "forge-cli-file-test")
+	if err != nil {
+		t.Fatalf("can not create test config directory: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(ConfigLocationEnv, configDir)
+	defer os.Unsetenv(ConfigLocationEnv)
+
+	current, err := LookupCurrentContext()
+	if err != nil || current != nil {
+		t.Fatalf("want no current context, got %v, %v", current, err)
+	}
+
+	staging := Context{Name: "staging", Gateway: "https://staging.example.com", Namespace: "staging-fn"}
+	if err := UpdateContext(staging); err != nil {
+		t.Fatalf("unexpected error when updating context: %s", err)
+	}
+
+	if err := UpdateContext(Context{Name: "prod", Gateway: "https://prod.example.com"}); err != nil {
+		t.Fatalf("unexpected error when updating context: %s", err)
+	}
+
+	if err := UseContext("staging"); err != nil {
+		t.Fatalf("unexpected error when using context: %s", err)
+	}
+
+	current, err = LookupCurrentContext()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if current == nil || *current != staging {
+		t.Fatalf("want current context %v, got %v", staging, current)
+	}
+
+	var notFound *ContextNotFoundError
+	if err := UseContext("dev"); !errors.As(err, &notFound) {
+		t.Fatalf("want ContextNotFoundError, got %v", err)
+	}
+
+	// Saving credentials must keep the contexts in the file
+	if err := UpdateAuthConfig(AuthConfig{Gateway: "https://staging.example.com", Auth: BasicAuthType, Token: EncodeAuth("admin", "pass")}); err != nil {
+		t.Fatalf("unexpected error when updating auth config: %s", err)
+	}
+
+	if err := RemoveContext("staging"); err != nil {
+		t.Fatalf("unexpected error when removing context: %s", err)
+	}
+
+	contexts, currentName, err := ListContexts()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(contexts) != 1 || contexts[0].Name != "prod" {
+		t.Fatalf("want only the prod context, got %v", contexts)
+	}
+
+	if currentName != "" {
+		t.Fatalf("want current context to be unset after removal, got %q", currentName)
+	}
+}
+
+func Test_LookupAuthConfig_ViaContextAuth(t *testing.T) {
+	configDir, err := os.MkdirTemp("", "forge-cli-file-test")
+	if err != nil {
+		t.Fatalf("can not create test config directory: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(ConfigLocationEnv, configDir)
+	defer os.Unsetenv(ConfigLocationEnv)
+
+	token := EncodeAuth("admin", "pass")
+	if err := UpdateAuthConfig(AuthConfig{Gateway: "https://gw.example.com", Auth: BasicAuthType, Token: token}); err != nil {
+		t.Fatalf("unexpected error when updating auth config: %s", err)
+	}
+
+	if err := UpdateContext(Context{Name: "internal", Gateway: "http://gw.internal:8080", Auth: "https://gw.example.com"}); err != nil {
+		t.Fatalf("unexpected error when updating context: %s", err)
+	}
+
+	authConfig, err := LookupAuthConfig("http://gw.internal:8080")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if authConfig.Token != token {
+		t.Fatalf("want token %s, got %s", token, authConfig.Token)
+	}
+}
@@ -0,0 +1,334 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	// CredentialHelperPrefix is prepended to the name in credsStore or
+	// credHelpers to find the helper program on the PATH
+	CredentialHelperPrefix = "forge-cli-credential-"
+
+	// FileCredentialStore is the name of the built-in file store, it keeps
+	// credentials out of the config file but is only as safe as the
+	// permissions of the config directory
+	FileCredentialStore = "file"
+
+	// CredentialsKeyEnv is the name of the env variable holding a key for the
+	// file store. When not set, a random key is generated and saved next to
+	// the credentials file, so anyone who can read one can read the other.
+	CredentialsKeyEnv = "OPENFAAS_CREDENTIALS_KEY"
+
+	credentialsFile    = "credentials.enc"
+	credentialsKeyFile = "credentials.key"
+)
+
+// ErrCredentialsNotFound is returned when a store has no credentials for a gateway
+var ErrCredentialsNotFound = errors.New("credentials not found in the credential store")
+
+// Credentials is the payload exchanged with credential helpers, it follows
+// the protocol used by Docker credential helpers
+type Credentials struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// CredentialStore saves gateway credentials outside of the config file
+type CredentialStore interface {
+	Get(serverURL string) (*Credentials, error)
+	Store(credentials Credentials) error
+	Erase(serverURL string) error
+}
+
+// NewCredentialStore returns the store for the given credsStore name, an
+// error is returned when the helper program can not be found rather than
+// saving the credentials somewhere the user did not ask for.
+func NewCredentialStore(name string) (CredentialStore, error) {
+	if name == FileCredentialStore {
+		return newFileStore(), nil
+	}
+
+	program := CredentialHelperPrefix + name
+	if _, err := exec.LookPath(program); err != nil {
+		return nil, fmt.Errorf("credential helper %s not found, install it or change the credential store in %s: %w", program, DefaultFile, err)
+	}
+
+	return &helperStore{program: program}, nil
+}
+
+// credentialStoreName returns the store configured for a gateway, credHelpers
+// take priority over the default credsStore
+func (configFile *ConfigFile) credentialStoreName(gateway string) string {
+	if name, ok := configFile.CredHelpers[gateway]; ok {
+		return name
+	}
+	return configFile.CredsStore
+}
+
+// storedSecret holds the sensitive parts of an AuthConfig in Credentials.Secret
+type storedSecret struct {
+	Token   string   `json:"token,omitempty"`
+	Options []Option `json:"options,omitempty"`
+}
+
+// storeAuthConfig saves the token and options of the AuthConfig in the store,
+// and returns a copy of the AuthConfig without them
+func storeAuthConfig(store CredentialStore, authConfig AuthConfig) (AuthConfig, error) {
+	secret, err := json.Marshal(storedSecret{Token: authConfig.Token, Options: authConfig.Options})
+	if err != nil {
+		return authConfig, err
+	}
+
+	err = store.Store(Credentials{
+		ServerURL: authConfig.Gateway,
+		Username:  string(authConfig.Auth),
+		Secret:    string(secret),
+	})
+	if err != nil {
+		return authConfig, fmt.Errorf("unable to save credentials: %w", err)
+	}
+
+	authConfig.Token = ""
+	authConfig.Options = nil
+	return authConfig, nil
+}
+
+// loadAuthConfig fills in the token and options of the AuthConfig from the store
+func loadAuthConfig(store CredentialStore, authConfig AuthConfig) (AuthConfig, error) {
+	credentials, err := store.Get(authConfig.Gateway)
+	if err != nil {
+		return authConfig, err
+	}
+
+	var secret storedSecret
+	if err := json.Unmarshal([]byte(credentials.Secret), &secret); err != nil {
+		return authConfig, fmt.Errorf("unable to read credentials for %s: %w", authConfig.Gateway, err)
+	}
+
+	authConfig.Token = secret.Token
+	authConfig.Options = secret.Options
+	return authConfig, nil
+}
+
+// helperStore runs a credential helper program with the action as its
+// argument, and the request on stdin
+type helperStore struct {
+	program string
+}
+
+func (h *helperStore) Get(serverURL string) (*Credentials, error) {
+	out, err := h.run("get", strings.NewReader(serverURL))
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "credentials not found") {
+			return nil, ErrCredentialsNotFound
+		}
+		return nil, err
+	}
+
+	var credentials Credentials
+	if err := json.Unmarshal(out, &credentials); err != nil {
+		return nil, fmt.Errorf("invalid response from %s: %w", h.program, err)
+	}
+	return &credentials, nil
+}
+
+func (h *helperStore) Store(credentials Credentials) error {
+	payload, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.run("store", bytes.NewReader(payload))
+	return err
+}
+
+func (h *helperStore) Erase(serverURL string) error {
+	_, err := h.run("erase", strings.NewReader(serverURL))
+	return err
+}
+
+func (h *helperStore) run(action string, input io.Reader) ([]byte, error) {
+	cmd := exec.Command(h.program, action)
+	cmd.Stdin = input
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stdout.String() + stderr.String())
+		if len(message) == 0 {
+			message = err.Error()
+		}
+		return nil, fmt.Errorf("%s %s: %s", h.program, action, message)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// fileStore keeps credentials in a file in the config directory which only
+// the user can read. The contents are sealed with AES-GCM so that they are
+// not readable at a glance, this is not a substitute for a credential helper
+// as the key is either next to the file or hashed from CredentialsKeyEnv
+// without a password KDF.
+type fileStore struct {
+	path    string
+	keyPath string
+}
+
+func newFileStore() *fileStore {
+	dir, err := homedir.Expand(ConfigDir())
+	if err != nil {
+		dir = ConfigDir()
+	}
+
+	return &fileStore{
+		path:    filepath.Join(dir, credentialsFile),
+		keyPath: filepath.Join(dir, credentialsKeyFile),
+	}
+}
+
+func (f *fileStore) Get(serverURL string) (*Credentials, error) {
+	all, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, ok := all[serverURL]
+	if !ok {
+		return nil, ErrCredentialsNotFound
+	}
+	return &credentials, nil
+}
+
+func (f *fileStore) Store(credentials Credentials) error {
+	all, err := f.load()
+	if err != nil {
+		return err
+	}
+
+	all[credentials.ServerURL] = credentials
+	return f.save(all)
+}
+
+func (f *fileStore) Erase(serverURL string) error {
+	all, err := f.load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := all[serverURL]; !ok {
+		return ErrCredentialsNotFound
+	}
+
+	delete(all, serverURL)
+	return f.save(all)
+}
+
+func (f *fileStore) load() (map[string]Credentials, error) {
+	all := map[string]Credentials{}
+
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return all, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	gcm, err := f.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("credentials file %s is corrupt", f.path)
+	}
+
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s, check %s: %w", f.path, CredentialsKeyEnv, err)
+	}
+
+	if err := json.Unmarshal(plain, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+func (f *fileStore) save(all map[string]Credentials) error {
+	plain, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := f.cipher(true)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), DefaultPermissions); err != nil {
+		return err
+	}
+
+	return os.WriteFile(f.path, gcm.Seal(nonce, nonce, plain, nil), 0600)
+}
+
+// cipher returns an AES-GCM cipher using the key from the environment, or the
+// key file, which is generated when create is true
+func (f *fileStore) cipher(create bool) (cipher.AEAD, error) {
+	var key []byte
+
+	if passphrase, ok := os.LookupEnv(CredentialsKeyEnv); ok && len(passphrase) > 0 {
+		sum := sha256.Sum256([]byte(passphrase))
+		key = sum[:]
+	} else {
+		data, err := os.ReadFile(f.keyPath)
+		switch {
+		case err == nil:
+			key = data
+		case os.IsNotExist(err) && create:
+			key = make([]byte, 32)
+			if _, err := io.ReadFull(rand.Reader, key); err != nil {
+				return nil, err
+			}
+			if err := os.MkdirAll(filepath.Dir(f.keyPath), DefaultPermissions); err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(f.keyPath, key, 0600); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unable to read credentials key %s: %w", f.keyPath, err)
+		}
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,147 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writeTestConfig(t *testing.T, contents string) string {
+	t.Helper()
+
+	configDir := t.TempDir()
+	t.Setenv(ConfigLocationEnv, configDir)
+
+	if err := os.WriteFile(filepath.Join(configDir, DefaultFile), []byte(contents), 0600); err != nil {
+		t.Fatalf("can not write test config: %s", err)
+	}
+	return configDir
+}
+
+func Test_CredsStore_File(t *testing.T) {
+	configDir := writeTestConfig(t, "credsStore: file\n")
+
+	authConfig := AuthConfig{Gateway: "https://gw.example.com", Auth: Oauth2AuthType, Token: "access-token"}
+	authConfig.SetOption("refresh_token", "refresh-token")
+
+	if err := UpdateAuthConfig(authConfig); err != nil {
+		t.Fatalf("unexpected error when updating auth config: %s", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(configDir, DefaultFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "access-token") || strings.Contains(string(data), "refresh-token") {
+		t.Fatalf("want no secrets in the config file, got:\n%s", data)
+	}
+
+	sealed, err := os.ReadFile(filepath.Join(configDir, credentialsFile))
+	if err != nil {
+		t.Fatalf("want the credentials file to be written: %s", err)
+	}
+	if strings.Contains(string(sealed), "access-token") {
+		t.Fatal("want the credentials file not to hold the token in plain text")
+	}
+
+	got, err := LookupAuthConfig("https://gw.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error when looking up auth config: %s", err)
+	}
+	if got.Token != "access-token" || got.GetOption("refresh_token") != "refresh-token" {
+		t.Fatalf("want token and options from the store, got %+v", got)
+	}
+
+	if err := RemoveAuthConfig("https://gw.example.com"); err != nil {
+		t.Fatalf("unexpected error when removing auth config: %s", err)
+	}
+
+	if _, err := newFileStore().Get("https://gw.example.com"); !errors.Is(err, ErrCredentialsNotFound) {
+		t.Fatalf("want ErrCredentialsNotFound after removal, got %v", err)
+	}
+}
+
+func Test_CredsStore_FileWrongPassphrase(t *testing.T) {
+	writeTestConfig(t, "")
+	t.Setenv(CredentialsKeyEnv, "correct horse")
+
+	store := newFileStore()
+	if err := store.Store(Credentials{ServerURL: "https://gw.example.com", Secret: "s3cret"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	t.Setenv(CredentialsKeyEnv, "battery staple")
+	if _, err := store.Get("https://gw.example.com"); err == nil {
+		t.Fatal("want an error when opening with the wrong key")
+	}
+}
+
+func Test_CredsStore_Helper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test helper is a shell script")
+	}
+
+	binDir := t.TempDir()
+	helper := `#!/bin/sh
+store="$(dirname "$0")/stored.json"
+case "$1" in
+store) cat > "$store" ;;
+get) [ -f "$store" ] || { echo "credentials not found in native keychain"; exit 1; }; cat "$store" ;;
+erase) rm -f "$store" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(binDir, CredentialHelperPrefix+"test"), []byte(helper), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	writeTestConfig(t, `credsStore: file
+credHelpers:
+  https://gw.example.com: test
+`)
+
+	token := EncodeAuth("admin", "pass")
+	if err := UpdateAuthConfig(AuthConfig{Gateway: "https://gw.example.com", Auth: BasicAuthType, Token: token}); err != nil {
+		t.Fatalf("unexpected error when updating auth config: %s", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(binDir, "stored.json")); err != nil {
+		t.Fatalf("want the helper to store the credentials: %s", err)
+	}
+
+	got, err := LookupAuthConfig("https://gw.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error when looking up auth config: %s", err)
+	}
+	if got.Token != token {
+		t.Fatalf("want token %s, got %s", token, got.Token)
+	}
+
+	if err := RemoveAuthConfig("https://gw.example.com"); err != nil {
+		t.Fatalf("unexpected error when removing auth config: %s", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(binDir, "stored.json")); !os.IsNotExist(err) {
+		t.Fatalf("want the helper to erase the credentials, got %v", err)
+	}
+}
+
+func Test_CredsStore_MissingHelper(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	configDir := writeTestConfig(t, "credsStore: missing\n")
+
+	err := UpdateAuthConfig(AuthConfig{Gateway: "https://gw.example.com", Auth: BasicAuthType, Token: EncodeAuth("admin", "pass")})
+	if err == nil || !strings.Contains(err.Error(), CredentialHelperPrefix+"missing") {
+		t.Fatalf("want an error naming the missing helper, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(configDir, credentialsFile)); !os.IsNotExist(err) {
+		t.Fatalf("want no fallback to the file store, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OutputFormat determines how read commands print their results, a format
+// can carry an expression such as "jsonpath={.name}"
+type OutputFormat string
+
+const TableOutputFormat OutputFormat = "table"
+const JSONOutputFormat OutputFormat = "json"
+const YAMLOutputFormat OutputFormat = "yaml"
+const TemplateOutputFormat OutputFormat = "template"
+const JSONPathOutputFormat OutputFormat = "jsonpath"
+
+// Type implements pflag.Value
+func (o *OutputFormat) Type() string {
+	return "format"
+}
+
+// String implements Stringer
+func (o *OutputFormat) String() string {
+	if o == nil {
+		return ""
+	}
+	return string(*o)
+}
+
+// Set implements pflag.Value
+func (o *OutputFormat) Set(value string) error {
+	name, expression, hasExpression := strings.Cut(value, "=")
+	name = strings.ToLower(name)
+
+	switch OutputFormat(name) {
+	case TableOutputFormat, JSONOutputFormat, YAMLOutputFormat:
+		if hasExpression {
+			return fmt.Errorf("output format '%s' does not take an expression", name)
+		}
+		*o = OutputFormat(name)
+	case TemplateOutputFormat, "go-template", JSONPathOutputFormat:
+		if len(expression) == 0 {
+			return fmt.Errorf("output format '%s' needs an expression, for example: %s='...'", name, name)
+		}
+		if name == "go-template" {
+			name = string(TemplateOutputFormat)
+		}
+		*o = OutputFormat(name + "=" + expression)
+	default:
+		return fmt.Errorf("unknown output format: '%s'", value)
+	}
+	return nil
+}
+
+// Format returns the name of the format, "table" when it is not set
+func (o OutputFormat) Format() OutputFormat {
+	name, _, _ := strings.Cut(string(o), "=")
+	if len(name) == 0 {
+		return TableOutputFormat
+	}
+	return OutputFormat(name)
+}
+
+// Expression returns the template or JSONPath expression of the format
+func (o OutputFormat) Expression() string {
+	_, expression, _ := strings.Cut(string(o), "=")
+	return expression
+}
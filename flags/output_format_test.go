@@ -0,0 +1,55 @@
+package flags
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOutputFormat(t *testing.T) {
+	cases := []struct {
+		name       string
+		value      string
+		format     OutputFormat
+		expression string
+		err        error
+	}{
+		{"can accept table", "table", TableOutputFormat, "", nil},
+		{"can accept json", "json", JSONOutputFormat, "", nil},
+		{"can accept yaml in upper case", "YAML", YAMLOutputFormat, "", nil},
+		{"can accept template", "template={{.name}}", TemplateOutputFormat, "{{.name}}", nil},
+		{"go-template is an alias of template", "go-template={{.name}}", TemplateOutputFormat, "{{.name}}", nil},
+		{"can accept jsonpath with = in the expression", "jsonpath={.labels.a=b}", JSONPathOutputFormat, "{.labels.a=b}", nil},
+		{"jsonpath needs an expression", "jsonpath", "", "", errors.New("output format 'jsonpath' needs an expression, for example: jsonpath='...'")},
+		{"json does not take an expression", "json={.name}", "", "", errors.New("output format 'json' does not take an expression")},
+		{"unknown strings cause error string", "nonsense", "", "", errors.New("unknown output format: 'nonsense'")},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var f OutputFormat
+			err := f.Set(tc.value)
+			if tc.err != nil {
+				if err == nil || tc.err.Error() != err.Error() {
+					t.Fatalf("expected error %s, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if f.Format() != tc.format {
+				t.Errorf("expected format %s, got %s", tc.format, f.Format())
+			}
+			if f.Expression() != tc.expression {
+				t.Errorf("expected expression %s, got %s", tc.expression, f.Expression())
+			}
+		})
+	}
+}
+
+func TestOutputFormat_DefaultsToTable(t *testing.T) {
+	var f OutputFormat
+	if f.Format() != TableOutputFormat {
+		t.Errorf("expected format %s, got %s", TableOutputFormat, f.Format())
+	}
+}
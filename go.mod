@@ -7,7 +7,6 @@ require (
 	github.com/alexellis/arkade v0.0.0-20231127111019-8363b67093b1
 	github.com/alexellis/go-execute/v2 v2.2.1
 	github.com/alexellis/hmac v1.3.0
-	github.com/alexellis/hmac/v2 v2.0.0
 	github.com/bep/debounce v1.2.1
 	github.com/drone/envsubst v1.0.3
 	github.com/fsnotify/fsnotify v1.7.0
@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/forge4flow/forge-cli/config"
@@ -19,6 +20,14 @@ type BasicAuth struct {
 	password string
 }
 
+// NewBasicAuth returns a ClientAuth which sets the given username and password
+func NewBasicAuth(username, password string) *BasicAuth {
+	return &BasicAuth{
+		username: username,
+		password: password,
+	}
+}
+
 func (auth *BasicAuth) Set(req *http.Request) error {
 	req.SetBasicAuth(auth.username, auth.password)
 	return nil
@@ -34,15 +43,19 @@ func (c *BearerToken) Set(req *http.Request) error {
 	return nil
 }
 
-// NewCLIAuth returns a new CLI Auth
+// NewCLIAuth returns a new CLI Auth, a gateway without saved credentials is
+// not an error, but a credential store which cannot be read is
 func NewCLIAuth(token string, gateway string) (ClientAuth, error) {
-	authConfig, _ := config.LookupAuthConfig(gateway)
+	authConfig, err := config.LookupAuthConfig(gateway)
+	var notFound *config.AuthConfigNotFoundError
+	if err != nil && !errors.Is(err, config.ErrConfigNotFound) && !errors.As(err, &notFound) {
+		return nil, err
+	}
 
 	var (
 		username    string
 		password    string
 		bearerToken string
-		err         error
 	)
 
 	if authConfig.Auth == config.BasicAuthType {
@@ -58,6 +71,11 @@ func NewCLIAuth(token string, gateway string) (ClientAuth, error) {
 
 	}
 
+	// Tokens saved by "forge-cli login --oauth2" are refreshed as they expire
+	if authConfig.Auth == config.Oauth2AuthType && len(token) == 0 {
+		return NewOAuth2Auth(authConfig, nil), nil
+	}
+
 	// User specified token gets priority
 	if len(token) > 0 {
 		bearerToken = token
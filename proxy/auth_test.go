@@ -0,0 +1,46 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/forge4flow/forge-cli/config"
+)
+
+func Test_NewCLIAuth_NoConfig(t *testing.T) {
+	t.Setenv(config.ConfigLocationEnv, t.TempDir())
+
+	auth, err := NewCLIAuth("token", "https://gw.example.com")
+	if err != nil {
+		t.Fatalf("want no error without a config file, got: %s", err)
+	}
+	if _, ok := auth.(*BearerToken); !ok {
+		t.Fatalf("want a bearer token, got %T", auth)
+	}
+}
+
+func Test_NewCLIAuth_MissingCredentialHelper(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	configDir := t.TempDir()
+	t.Setenv(config.ConfigLocationEnv, configDir)
+
+	contents := `credsStore: missing
+auths:
+- gateway: https://gw.example.com
+  auth: basic
+`
+	if err := os.WriteFile(filepath.Join(configDir, config.DefaultFile), []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewCLIAuth("", "https://gw.example.com")
+	if err == nil || !strings.Contains(err.Error(), config.CredentialHelperPrefix+"missing") {
+		t.Fatalf("want an error naming the missing helper, got %v", err)
+	}
+}
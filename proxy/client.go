@@ -24,6 +24,10 @@ type Client struct {
 	GatewayURL *url.URL
 	//UserAgent user agent for the client
 	UserAgent string
+	//Retry policy for requests to the gateway, retries are disabled by default
+	Retry RetryPolicy
+	//Output is where deploy and delete progress is printed, os.Stdout when nil
+	Output io.Writer
 }
 
 // ClientAuth an interface for client authentication.
@@ -49,14 +53,28 @@ func NewClient(auth ClientAuth, gatewayURL string, transport http.RoundTripper,
 		client.Transport = transport
 	}
 
+	// refresh OAuth2 tokens with the same TLS settings and timeout
+	if oauth2, ok := auth.(*OAuth2Auth); ok && oauth2.httpClient == nil {
+		oauth2.httpClient = client
+	}
+
 	return &Client{
 		ClientAuth: auth,
 		httpClient: client,
 		GatewayURL: baseURL,
 		UserAgent:  fmt.Sprintf("forge-cli/%s", version.BuildVersion()),
+		Retry:      NewRetryPolicy(0, DefaultRetryWait),
 	}, nil
 }
 
+// output is the writer for progress messages
+func (c *Client) output() io.Writer {
+	if c.Output == nil {
+		return os.Stdout
+	}
+	return c.Output
+}
+
 // newRequest create a new HTTP request with authentication
 func (c *Client) newRequest(method, path string, query url.Values, body io.Reader) (*http.Request, error) {
 
@@ -81,7 +99,7 @@ func (c *Client) newRequest(method, path string, query url.Values, body io.Reade
 				return nil, err
 			}
 			bodyDebug = buf.String()
-			body = io.NopCloser(strings.NewReader(buf.String()))
+			body = strings.NewReader(buf.String())
 		}
 	}
 
@@ -98,7 +116,9 @@ func (c *Client) newRequest(method, path string, query url.Values, body io.Reade
 		req.Header.Set("User-Agent", c.UserAgent)
 	}
 
-	c.ClientAuth.Set(req)
+	if err := c.ClientAuth.Set(req); err != nil {
+		return nil, err
+	}
 
 	if os.Getenv("FAAS_DEBUG") == "1" {
 		fmt.Printf("%s %s\n", req.Method, req.URL.String())
@@ -140,16 +160,7 @@ func (c *Client) doRequest(ctx context.Context, req *http.Request) (*http.Respon
 		fmt.Println(string(dump))
 	}
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
-	}
-
-	return res, err
+	return c.doWithRetry(ctx, req)
 }
 
 func addQueryParams(u string, params map[string]string) (string, error) {
@@ -8,7 +8,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 
@@ -35,13 +34,13 @@ func (c *Client) DeleteFunction(ctx context.Context, functionName string, namesp
 
 	req, err := c.newRequest(http.MethodDelete, deleteEndpoint, query, reader)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(c.output(), err)
 		return err
 	}
 
 	res, err := c.doRequest(ctx, req)
 	if err != nil {
-		fmt.Printf("Error removing existing function: %s, gateway=%s, functionName=%s\n",
+		fmt.Fprintf(c.output(), "Error removing existing function: %s, gateway=%s, functionName=%s\n",
 			err.Error(), c.GatewayURL.String(), functionName)
 		return err
 	}
@@ -52,19 +51,15 @@ func (c *Client) DeleteFunction(ctx context.Context, functionName string, namesp
 
 	switch res.StatusCode {
 	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
-		fmt.Println("Removing old function.")
+		fmt.Fprintln(c.output(), "Removing old function.")
 	case http.StatusNotFound:
-		err = fmt.Errorf("No existing function to remove")
+		err = newAPIError(res, "No existing function to remove")
 	case http.StatusUnauthorized:
-		err = fmt.Errorf("unauthorized access, run \"forge-cli login\" to setup authentication for this server")
+		err = newAPIError(res, unauthorizedMessage)
 	default:
-		var bodyReadErr error
-		bytesOut, bodyReadErr := io.ReadAll(res.Body)
-		if bodyReadErr != nil {
-			err = bodyReadErr
-		} else {
-			err = fmt.Errorf("Server returned unexpected status code %d %s", res.StatusCode, string(bytesOut))
-		}
+		apiErr := newAPIError(res, "")
+		apiErr.message = fmt.Sprintf("Server returned unexpected status code %d %s", apiErr.StatusCode, apiErr.Body)
+		err = apiErr
 	}
 
 	return err
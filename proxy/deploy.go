@@ -7,8 +7,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"time"
@@ -60,27 +60,31 @@ func generateFuncStr(spec *DeployFunctionSpec) string {
 
 // DeployFunction first tries to deploy a function and if it exists will then attempt
 // a rolling update. Warnings are suppressed for the second API call (if required.)
-func (c *Client) DeployFunction(context context.Context, spec *DeployFunctionSpec) int {
+// A status code other than 200 or 202 is returned as an *APIError.
+func (c *Client) DeployFunction(context context.Context, spec *DeployFunctionSpec) error {
 
 	rollingUpdateInfo := fmt.Sprintf("Function %s already exists, attempting rolling-update.", spec.FunctionName)
-	statusCode, deployOutput := c.deploy(context, spec, spec.Update)
+	statusCode, deployOutput, err := c.deploy(context, spec, spec.Update)
 
-	if spec.Update == true && statusCode == http.StatusNotFound {
+	if spec.Update == true && errors.Is(err, ErrNotFound) {
 		// Re-run the function with update=false
 
-		statusCode, deployOutput = c.deploy(context, spec, false)
+		_, deployOutput, err = c.deploy(context, spec, false)
 	} else if statusCode == http.StatusOK {
-		fmt.Println(rollingUpdateInfo)
+		fmt.Fprintln(c.output(), rollingUpdateInfo)
 	}
-	fmt.Println()
-	fmt.Println(deployOutput)
-	return statusCode
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.output())
+	fmt.Fprintln(c.output(), deployOutput)
+	return nil
 }
 
 // deploy a function to an Forge4Flow gateway over REST
-func (c *Client) deploy(context context.Context, spec *DeployFunctionSpec, update bool) (int, string) {
+func (c *Client) deploy(context context.Context, spec *DeployFunctionSpec, update bool) (int, string, error) {
 
-	var deployOutput string
 	// Need to alter Gateway to allow nil/empty string as fprocess, to avoid this repetition.
 	var fprocessTemplate string
 	if len(spec.FProcess) > 0 {
@@ -147,18 +151,13 @@ func (c *Client) deploy(context context.Context, spec *DeployFunctionSpec, updat
 
 	var err error
 	request, err = c.newRequest(method, "/system/functions", query, reader)
-
 	if err != nil {
-		deployOutput += fmt.Sprintln(err)
-		return http.StatusInternalServerError, deployOutput
+		return http.StatusInternalServerError, "", err
 	}
 
 	res, err := c.doRequest(context, request)
-
 	if err != nil {
-		deployOutput += fmt.Sprintln("Is Forge4Flow deployed? Do you need to specify the --gateway flag?")
-		deployOutput += fmt.Sprintln(err)
-		return http.StatusInternalServerError, deployOutput
+		return http.StatusInternalServerError, "", fmt.Errorf("cannot connect to Forge4Flow on URL: %s, is Forge4Flow deployed? Do you need to specify the --gateway flag? error: %w", c.GatewayURL.String(), err)
 	}
 
 	if res.Body != nil {
@@ -167,19 +166,14 @@ func (c *Client) deploy(context context.Context, spec *DeployFunctionSpec, updat
 
 	switch res.StatusCode {
 	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
-		deployOutput += fmt.Sprintf("Deployed. %s.\n", res.Status)
-
-		deployedURL := fmt.Sprintf("URL: %s/function/%s", c.GatewayURL.String(), generateFuncStr(spec))
-		deployOutput += fmt.Sprintln(deployedURL)
+		deployOutput := fmt.Sprintf("Deployed. %s.\n", res.Status)
+		deployOutput += fmt.Sprintf("URL: %s/function/%s\n", c.GatewayURL.String(), generateFuncStr(spec))
+		return res.StatusCode, deployOutput, nil
 	case http.StatusUnauthorized:
-		deployOutput += fmt.Sprintln("unauthorized access, run \"forge-cli login\" to setup authentication for this server")
-
+		return res.StatusCode, "", newAPIError(res, unauthorizedMessage)
 	default:
-		bytesOut, err := io.ReadAll(res.Body)
-		if err == nil {
-			deployOutput += fmt.Sprintf("Unexpected status: %d, message: %s\n", res.StatusCode, string(bytesOut))
-		}
+		apiErr := newAPIError(res, "")
+		apiErr.message = fmt.Sprintf("Unexpected status: %d, message: %s", apiErr.StatusCode, apiErr.Body)
+		return res.StatusCode, "", apiErr
 	}
-
-	return res.StatusCode, deployOutput
 }
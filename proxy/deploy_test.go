@@ -5,6 +5,7 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"testing"
@@ -34,8 +35,9 @@ func runDeployProxyTest(t *testing.T, deployTest deployProxyTest) {
 	cliAuth := NewTestAuth(nil)
 	proxyClient, _ := NewClient(cliAuth, s.URL, nil, &defaultCommandTimeout)
 
+	var err error
 	stdout := test.CaptureStdout(func() {
-		proxyClient.DeployFunction(context.TODO(), &DeployFunctionSpec{
+		err = proxyClient.DeployFunction(context.TODO(), &DeployFunctionSpec{
 			"fprocess",
 			"function",
 			"image",
@@ -57,6 +59,10 @@ func runDeployProxyTest(t *testing.T, deployTest deployProxyTest) {
 		})
 	})
 
+	if err != nil {
+		stdout += err.Error()
+	}
+
 	r := regexp.MustCompile(deployTest.expectedOutput)
 	if !r.MatchString(stdout) {
 		t.Fatalf("Output not matched: %s", stdout)
@@ -94,6 +100,22 @@ func Test_RunDeployProxyTests(t *testing.T) {
 	}
 }
 
+func Test_DeployFunction_APIError(t *testing.T) {
+	s := test.MockHttpServerStatus(t, http.StatusConflict)
+	defer s.Close()
+
+	proxyClient, _ := NewClient(NewTestAuth(nil), s.URL, nil, &defaultCommandTimeout)
+	err := proxyClient.DeployFunction(context.TODO(), &DeployFunctionSpec{FunctionName: "function", Image: "image"})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Fatalf("want an APIError with status code %d, got: %v", http.StatusConflict, err)
+	}
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("want ErrConflict, got: %v", err)
+	}
+}
+
 func Test_DeployFunction_generateFuncStr(t *testing.T) {
 
 	testCases := []struct {
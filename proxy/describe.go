@@ -59,14 +59,11 @@ func (c *Client) GetFunctionInfo(ctx context.Context, functionName string, names
 		}
 
 	case http.StatusUnauthorized:
-		return result, fmt.Errorf("unauthorized access, run \"forge-cli login\" to setup authentication for this server")
+		return result, newAPIError(res, unauthorizedMessage)
 	case http.StatusNotFound:
-		return result, fmt.Errorf("no such function: %s", functionName)
+		return result, newAPIError(res, fmt.Sprintf("no such function: %s", functionName))
 	default:
-		bytesOut, err := io.ReadAll(res.Body)
-		if err == nil {
-			return result, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
-		}
+		return result, newAPIError(res, "")
 	}
 	return result, nil
 }
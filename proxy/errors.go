@@ -0,0 +1,94 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var (
+	// ErrNotFound is the classification of an APIError for a missing
+	// function, secret or namespace
+	ErrNotFound = errors.New("not found")
+
+	// ErrUnauthorized is the classification of an APIError when the
+	// credentials are missing, invalid or not allowed to perform the action
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrConflict is the classification of an APIError when the resource
+	// already exists
+	ErrConflict = errors.New("conflict")
+)
+
+const unauthorizedMessage = "unauthorized access, run \"forge-cli login\" to setup authentication for this server"
+
+// APIError is returned when the gateway replies with an unexpected status
+// code. Use errors.Is with ErrNotFound, ErrUnauthorized or ErrConflict to
+// branch on the kind of failure.
+type APIError struct {
+	// StatusCode of the response
+	StatusCode int
+	// Method of the request
+	Method string
+	// URL of the request
+	URL string
+	// Body of the response
+	Body string
+	// Err is the sentinel classification of the status code, nil when the
+	// status code has none
+	Err error
+
+	message string
+}
+
+func (e *APIError) Error() string {
+	if len(e.message) > 0 {
+		return e.message
+	}
+	return fmt.Sprintf("server returned unexpected status code: %d - %s", e.StatusCode, e.Body)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorForStatus returns the sentinel classification for a status code, or
+// nil when the status code has none
+func ErrorForStatus(statusCode int) error {
+	switch statusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusConflict:
+		return ErrConflict
+	}
+	return nil
+}
+
+// newAPIError reads the body of the response into an APIError, an empty
+// message uses the default "unexpected status code" text
+func newAPIError(res *http.Response, message string) *APIError {
+	apiErr := &APIError{
+		StatusCode: res.StatusCode,
+		Err:        ErrorForStatus(res.StatusCode),
+		message:    message,
+	}
+
+	if res.Request != nil {
+		apiErr.Method = res.Request.Method
+		apiErr.URL = res.Request.URL.String()
+	}
+
+	if res.Body != nil {
+		if body, err := io.ReadAll(res.Body); err == nil {
+			apiErr.Body = string(body)
+		}
+	}
+
+	return apiErr
+}
@@ -0,0 +1,67 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/forge4flow/forge-cli/test"
+	types "github.com/openfaas/faas-provider/types"
+)
+
+func Test_APIError_Classification(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		want   error
+	}{
+		{name: "not found", status: http.StatusNotFound, want: ErrNotFound},
+		{name: "unauthorized", status: http.StatusUnauthorized, want: ErrUnauthorized},
+		{name: "forbidden", status: http.StatusForbidden, want: ErrUnauthorized},
+		{name: "conflict", status: http.StatusConflict, want: ErrConflict},
+		{name: "unclassified", status: http.StatusInternalServerError, want: nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := test.MockHttpServer(t, []test.Request{
+				{
+					Method:             http.MethodDelete,
+					Uri:                "/system/secrets",
+					ResponseStatusCode: tc.status,
+					ResponseBody:       "response body",
+				},
+			})
+			defer s.Close()
+
+			client, _ := NewClient(NewTestAuth(nil), s.URL, nil, &defaultCommandTimeout)
+			err := client.RemoveSecret(context.Background(), types.Secret{Name: "db-password"})
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("want an *APIError, got %T: %v", err, err)
+			}
+
+			if apiErr.StatusCode != tc.status {
+				t.Errorf("want status code %d, got %d", tc.status, apiErr.StatusCode)
+			}
+			if apiErr.Method != http.MethodDelete || apiErr.URL != s.URL+"/system/secrets" {
+				t.Errorf("want request %s %s, got %s %s", http.MethodDelete, s.URL+"/system/secrets", apiErr.Method, apiErr.URL)
+			}
+			if apiErr.Body != "response body" {
+				t.Errorf("want body %q, got %q", "response body", apiErr.Body)
+			}
+
+			if tc.want != nil && !errors.Is(err, tc.want) {
+				t.Errorf("want errors.Is(err, %v) to be true", tc.want)
+			}
+			if tc.want == nil && apiErr.Err != nil {
+				t.Errorf("want no classification, got %v", apiErr.Err)
+			}
+		})
+	}
+}
@@ -49,10 +49,7 @@ func FunctionStoreList(store string) ([]v2.StoreFunction, error) {
 			return nil, fmt.Errorf("cannot parse result from Forge4Flow store at URL: %s\n%s", store, jsonErr.Error())
 		}
 	default:
-		bytesOut, err := io.ReadAll(res.Body)
-		if err == nil {
-			return nil, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
-		}
+		return nil, newAPIError(res, "")
 	}
 	return storeResults.Functions, nil
 }
@@ -4,26 +4,173 @@
 package proxy
 
 import (
-	"bytes"
+	"crypto/tls"
 	"io"
 	"os"
 
 	"fmt"
 	"net/http"
+	"net/http/httptrace"
 	"strings"
+	"sync"
 	"time"
 )
 
-// InvokeFunction a function
-func InvokeFunction(gateway string, name string, bytesIn *[]byte, contentType string, query []string, headers []string, async bool, httpMethod string, tlsInsecure bool, namespace string) (*[]byte, error) {
-	var resBytes []byte
+// InvokeResult describes the response of a function, its body is copied to
+// the writer given to Invoke
+type InvokeResult struct {
+	Status     string
+	StatusCode int
+	Proto      string
+	Header     http.Header
 
-	gateway = strings.TrimRight(gateway, "/")
+	// CallID identifies the invocation, for an async invocation it is given
+	// to the callback
+	CallID string
+
+	Timing InvokeTiming
+}
+
+// InvokeTiming is how long each phase of an invocation took. DNS, Connect
+// and TLS are zero when a connection is re-used.
+type InvokeTiming struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+
+	// FirstByte is the time from the start of the request to the first
+	// byte of the response
+	FirstByte time.Duration
+
+	// Total includes reading the whole body
+	Total time.Duration
+}
+
+// InvokeOptions control how Invoke handles the response of a function
+type InvokeOptions struct {
+	TLSInsecure bool
+
+	// OnResponse is called with the status and headers of any response
+	// before the body is read, an error stops the invocation
+	OnResponse func(result *InvokeResult) error
+
+	// AnyStatus copies the body of a non-2xx response rather than returning
+	// an error for it
+	AnyStatus bool
+}
+
+// InvokeFunction invokes a function with the body read from in, and copies
+// the response to out as it arrives so that neither is held in memory
+func InvokeFunction(gateway string, name string, in io.Reader, out io.Writer, contentType string, query []string, headers []string, async bool, httpMethod string, tlsInsecure bool, namespace string) (*InvokeResult, error) {
+	req, err := NewInvokeRequest(gateway, name, in, contentType, query, headers, async, httpMethod, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return Invoke(req, out, InvokeOptions{TLSInsecure: tlsInsecure})
+}
 
-	reader := bytes.NewReader(*bytesIn)
+// Invoke sends a request created by NewInvokeRequest and copies the body of
+// the response to out. A non-2xx response is returned as an *APIError unless
+// AnyStatus is set.
+func Invoke(req *http.Request, out io.Writer, opts InvokeOptions) (*InvokeResult, error) {
+	gateway := req.URL.Scheme + "://" + req.URL.Host
 
 	var disableFunctionTimeout *time.Duration
-	client := MakeHTTPClient(disableFunctionTimeout, tlsInsecure)
+	client := MakeHTTPClient(disableFunctionTimeout, opts.TLSInsecure)
+
+	// Removed by AE - the system-level basic auth secrets should not be transmitted
+	// to functions. Functions should implement their own auth.
+	// SetAuth(req, gateway)
+
+	result := &InvokeResult{}
+	start := time.Now()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), invokeTrace(&result.Timing, start)))
+
+	res, err := client.Do(req)
+
+	if err != nil {
+		fmt.Println()
+		fmt.Println(err)
+		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", gateway, err)
+	}
+
+	if res.Body != nil {
+		defer res.Body.Close()
+	}
+
+	result.Status = res.Status
+	result.StatusCode = res.StatusCode
+	result.Proto = res.Proto
+	result.Header = res.Header
+	result.CallID = res.Header.Get("X-Call-Id")
+
+	if opts.OnResponse != nil {
+		if err := opts.OnResponse(result); err != nil {
+			return result, err
+		}
+	}
+
+	success := res.StatusCode >= 200 && res.StatusCode < 300
+	if !success && !opts.AnyStatus {
+		result.Timing.Total = time.Since(start)
+		if res.StatusCode == http.StatusUnauthorized {
+			return result, newAPIError(res, unauthorizedMessage)
+		}
+		return result, newAPIError(res, "")
+	}
+
+	if res.StatusCode == http.StatusAccepted {
+		if len(result.CallID) > 0 {
+			fmt.Fprintf(os.Stderr, "Function submitted asynchronously, call ID: %s\n", result.CallID)
+		} else {
+			fmt.Fprintf(os.Stderr, "Function submitted asynchronously.\n")
+		}
+	}
+
+	if _, err := io.Copy(out, res.Body); err != nil {
+		return result, fmt.Errorf("cannot read result from Forge4Flow on URL: %s %s", gateway, err)
+	}
+	result.Timing.Total = time.Since(start)
+
+	return result, nil
+}
+
+// invokeTrace records the timing of a request, the callbacks can be called
+// concurrently when dialing more than one address
+func invokeTrace(timing *InvokeTiming, start time.Time) *httptrace.ClientTrace {
+	var mu sync.Mutex
+	var dnsStart, connectStart, tlsStart time.Time
+
+	since := func(from *time.Time, d *time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !from.IsZero() {
+			*d = time.Since(*from)
+		}
+	}
+	mark := func(t *time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		*t = time.Now()
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { mark(&dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { since(&dnsStart, &timing.DNS) },
+		ConnectStart:         func(string, string) { mark(&connectStart) },
+		ConnectDone:          func(string, string, error) { since(&connectStart, &timing.Connect) },
+		TLSHandshakeStart:    func() { mark(&tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { since(&tlsStart, &timing.TLS) },
+		GotFirstResponseByte: func() { since(&start, &timing.FirstByte) },
+	}
+}
+
+// NewInvokeRequest creates the request to invoke a function, the name is
+// suffixed with the namespace to route the request to it. A body of unknown
+// length, such as a pipe, is sent with chunked encoding.
+func NewInvokeRequest(gateway string, name string, body io.Reader, contentType string, query []string, headers []string, async bool, httpMethod string, namespace string) (*http.Request, error) {
+	gateway = strings.TrimRight(gateway, "/")
 
 	qs, qsErr := buildQueryString(query)
 	if qsErr != nil {
@@ -51,11 +198,22 @@ func InvokeFunction(gateway string, name string, bytesIn *[]byte, contentType st
 	}
 	gatewayURL += qs
 
-	req, err := http.NewRequest(httpMethod, gatewayURL, reader)
+	if body == nil {
+		body = http.NoBody
+	}
+
+	req, err := http.NewRequest(httpMethod, gatewayURL, body)
 	if err != nil {
 		fmt.Println()
 		fmt.Println(err)
-		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s", gateway)
+		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", gateway, err)
+	}
+
+	// a redirected file can be streamed with its length
+	if file, ok := body.(*os.File); ok {
+		if size, ok := remainingSize(file); ok {
+			req.ContentLength = size
+		}
 	}
 
 	req.Header.Add("Content-Type", contentType)
@@ -64,41 +222,21 @@ func InvokeFunction(gateway string, name string, bytesIn *[]byte, contentType st
 		req.Header.Add(name, value)
 	}
 
-	// Removed by AE - the system-level basic auth secrets should not be transmitted
-	// to functions. Functions should implement their own auth.
-	// SetAuth(req, gateway)
-
-	res, err := client.Do(req)
-
-	if err != nil {
-		fmt.Println()
-		fmt.Println(err)
-		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s", gateway)
-	}
+	return req, nil
+}
 
-	if res.Body != nil {
-		defer res.Body.Close()
+// remainingSize is the number of bytes left to read from a regular file
+func remainingSize(file *os.File) (int64, bool) {
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return 0, false
 	}
 
-	switch res.StatusCode {
-	case http.StatusAccepted:
-		fmt.Fprintf(os.Stderr, "Function submitted asynchronously.\n")
-	case http.StatusOK:
-		var readErr error
-		resBytes, readErr = io.ReadAll(res.Body)
-		if readErr != nil {
-			return nil, fmt.Errorf("cannot read result from Forge4Flow on URL: %s %s", gateway, readErr)
-		}
-	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("unauthorized access, run \"forge-cli login\" to setup authentication for this server")
-	default:
-		bytesOut, err := io.ReadAll(res.Body)
-		if err == nil {
-			return nil, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
-		}
+	offset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, false
 	}
-
-	return &resBytes, nil
+	return info.Size() - offset, true
 }
 
 func buildQueryString(query []string) (string, error) {
@@ -5,11 +5,14 @@ package proxy
 
 import (
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
 
 	"testing"
 
 	"regexp"
+	"strings"
 
 	"github.com/forge4flow/forge-cli/test"
 )
@@ -18,11 +21,11 @@ func Test_InvokeFunction(t *testing.T) {
 	s := test.MockHttpServerStatus(t, http.StatusOK)
 	defer s.Close()
 
-	bytesIn := []byte("test data")
 	_, err := InvokeFunction(
 		s.URL,
 		"function",
-		&bytesIn,
+		strings.NewReader("test data"),
+		io.Discard,
 		"text/plain",
 		[]string{},
 		[]string{},
@@ -41,11 +44,11 @@ func Test_InvokeFunction_Async(t *testing.T) {
 	s := test.MockHttpServerStatus(t, http.StatusAccepted)
 	defer s.Close()
 
-	bytesIn := []byte("test data")
 	_, err := InvokeFunction(
 		s.URL,
 		"function",
-		&bytesIn,
+		strings.NewReader("test data"),
+		io.Discard,
 		"text/plain",
 		[]string{},
 		[]string{},
@@ -64,11 +67,11 @@ func Test_InvokeFunction_Not2xx(t *testing.T) {
 	s := test.MockHttpServerStatus(t, http.StatusNotFound)
 	defer s.Close()
 
-	bytesIn := []byte("test data")
 	_, err := InvokeFunction(
 		s.URL,
 		"function",
-		&bytesIn,
+		strings.NewReader("test data"),
+		io.Discard,
 		"text/plain",
 		[]string{},
 		[]string{},
@@ -90,11 +93,11 @@ func Test_InvokeFunction_Not2xx(t *testing.T) {
 
 func Test_InvokeFunction_MissingURLPrefix(t *testing.T) {
 
-	bytesIn := []byte("test data")
 	_, err := InvokeFunction(
 		"127.0.0.1:8080",
 		"function",
-		&bytesIn,
+		strings.NewReader("test data"),
+		io.Discard,
 		"text/plain",
 		[]string{},
 		[]string{},
@@ -166,3 +169,88 @@ func compareMaps(mapA map[string]string, mapB map[string]string) bool {
 	}
 	return true
 }
+
+func Test_InvokeFunction_StreamsBodies(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TransferEncoding) == 0 || r.TransferEncoding[0] != "chunked" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		io.Copy(w, r.Body)
+	}))
+	defer s.Close()
+
+	// a pipe has no length, so the body is sent in chunks
+	in, pipe := io.Pipe()
+	go func() {
+		pipe.Write([]byte("streamed "))
+		pipe.Write([]byte("body"))
+		pipe.Close()
+	}()
+
+	var out strings.Builder
+	_, err := InvokeFunction(s.URL, "echo", in, &out, "text/plain", nil, nil, false, http.MethodPost, tlsNoVerify, "")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if out.String() != "streamed body" {
+		t.Fatalf("want: %q, got: %q", "streamed body", out.String())
+	}
+}
+
+func Test_Invoke_Result(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Call-Id", "call-1")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer s.Close()
+
+	req, err := NewInvokeRequest(s.URL, "resize", nil, "text/plain", nil, nil, true, http.MethodPost, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var called bool
+	result, err := Invoke(req, io.Discard, InvokeOptions{
+		OnResponse: func(result *InvokeResult) error {
+			called = true
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if !called {
+		t.Errorf("want OnResponse to be called")
+	}
+	if result.StatusCode != http.StatusAccepted || result.CallID != "call-1" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.Timing.FirstByte <= 0 || result.Timing.Total < result.Timing.FirstByte {
+		t.Errorf("unexpected timing: %+v", result.Timing)
+	}
+}
+
+func Test_Invoke_AnyStatus(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	}))
+	defer s.Close()
+
+	req, err := NewInvokeRequest(s.URL, "teapot", nil, "text/plain", nil, nil, false, http.MethodGet, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	result, err := Invoke(req, &out, InvokeOptions{AnyStatus: true})
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+	if result.StatusCode != http.StatusTeapot || out.String() != "short and stout" {
+		t.Fatalf("unexpected result: %+v, body: %q", result, out.String())
+	}
+}
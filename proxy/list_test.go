@@ -5,6 +5,7 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"reflect"
 	"regexp"
@@ -72,3 +73,16 @@ func Test_ListFunctions_Not200(t *testing.T) {
 		t.Fatalf("Error not matched: %s", err)
 	}
 }
+
+func Test_ListFunctions_CannotConnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cliAuth := NewTestAuth(nil)
+	client, _ := NewClient(cliAuth, "http://127.0.0.1:8080", nil, &defaultCommandTimeout)
+	_, err := client.ListFunctions(ctx, "")
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("want the cause of the connection failure to be wrapped, got: %v", err)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -21,7 +20,7 @@ func (c *Client) GetLogs(ctx context.Context, params logs.Request) (<-chan logs.
 
 	logRequest, err := c.newRequest(http.MethodGet, "/system/logs", url.Values{}, nil)
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s", c.GatewayURL.String())
+		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", c.GatewayURL.String(), err)
 	}
 
 	logRequest.URL.RawQuery = reqAsQueryValues(params).Encode()
@@ -32,7 +31,7 @@ func (c *Client) GetLogs(ctx context.Context, params logs.Request) (<-chan logs.
 
 	res, err := c.doRequest(ctx, logRequest)
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s", c.GatewayURL.String())
+		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", c.GatewayURL.String(), err)
 	}
 
 	logStream := make(chan logs.Message, 1000)
@@ -54,12 +53,9 @@ func (c *Client) GetLogs(ctx context.Context, params logs.Request) (<-chan logs.
 			}
 		}()
 	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("unauthorized access, run \"forge-cli login\" to setup authentication for this server")
+		return nil, newAPIError(res, unauthorizedMessage)
 	default:
-		bytesOut, err := io.ReadAll(res.Body)
-		if err == nil {
-			return nil, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
-		}
+		return nil, newAPIError(res, "")
 	}
 	return logStream, nil
 }
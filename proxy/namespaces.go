@@ -21,12 +21,12 @@ func (c *Client) ListNamespaces(ctx context.Context) ([]string, error) {
 	getRequest, err := c.newRequest(http.MethodGet, namespacesPath, query, nil)
 
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s", c.GatewayURL.String())
+		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", c.GatewayURL.String(), err)
 	}
 
 	res, err := c.doRequest(ctx, getRequest)
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s", c.GatewayURL.String())
+		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", c.GatewayURL.String(), err)
 	}
 
 	if res.Body != nil {
@@ -45,12 +45,9 @@ func (c *Client) ListNamespaces(ctx context.Context) ([]string, error) {
 			return nil, fmt.Errorf("cannot parse namespaces from Forge4Flow on URL: %s\n%s", c.GatewayURL.String(), jsonErr.Error())
 		}
 	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("unauthorized access, run \"forge-cli login\" to setup authentication for this server")
+		return nil, newAPIError(res, unauthorizedMessage)
 	default:
-		bytesOut, err := io.ReadAll(res.Body)
-		if err == nil {
-			return nil, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
-		}
+		return nil, newAPIError(res, "")
 	}
 	return namespaces, nil
 }
@@ -0,0 +1,368 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forge4flow/forge-cli/config"
+)
+
+const (
+	// OAuth2 grant types supported by the CLI
+	DeviceCodeGrant        = "urn:ietf:params:oauth:grant-type:device_code"
+	ClientCredentialsGrant = "client_credentials"
+	RefreshTokenGrant      = "refresh_token"
+
+	// Names of the options stored alongside an oauth2 AuthConfig
+	OAuth2TokenURLOption     = "token_url"
+	OAuth2ClientIDOption     = "client_id"
+	OAuth2ClientSecretOption = "client_secret"
+	OAuth2ScopeOption        = "scope"
+	OAuth2AudienceOption     = "audience"
+	OAuth2GrantOption        = "grant_type"
+	OAuth2RefreshTokenOption = "refresh_token"
+	OAuth2ExpiryOption       = "expiry"
+
+	// expiryDelta refreshes tokens slightly before they expire to allow for
+	// clock skew and request latency
+	expiryDelta = 30 * time.Second
+)
+
+// OAuth2Config describes the identity provider and client used to obtain tokens
+type OAuth2Config struct {
+	TokenURL      string
+	DeviceAuthURL string
+	ClientID      string
+	ClientSecret  string
+	Scope         string
+	Audience      string
+}
+
+// OAuth2Token is a token response from the identity provider
+type OAuth2Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+
+	// Expiry is computed from ExpiresIn when the token is received
+	Expiry time.Time `json:"-"`
+}
+
+// Expired reports whether the token has expired or is about to expire
+func (t *OAuth2Token) Expired() bool {
+	if t.Expiry.IsZero() {
+		return false
+	}
+	return time.Now().Add(expiryDelta).After(t.Expiry)
+}
+
+// DeviceAuthorization is the response to a device authorization request
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval,omitempty"`
+}
+
+type oauth2Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *oauth2Error) Error() string {
+	if len(e.Description) > 0 {
+		return fmt.Sprintf("%s: %s", e.Code, e.Description)
+	}
+	return e.Code
+}
+
+// RequestDeviceAuthorization starts the device code flow and returns the code
+// the user must enter at the verification URI
+func RequestDeviceAuthorization(ctx context.Context, client *http.Client, cfg OAuth2Config) (*DeviceAuthorization, error) {
+	if len(cfg.DeviceAuthURL) == 0 {
+		return nil, fmt.Errorf("a device authorization URL is required for the device code flow")
+	}
+
+	values := url.Values{}
+	values.Set("client_id", cfg.ClientID)
+	if len(cfg.Scope) > 0 {
+		values.Set("scope", cfg.Scope)
+	}
+	if len(cfg.Audience) > 0 {
+		values.Set("audience", cfg.Audience)
+	}
+
+	var auth DeviceAuthorization
+	if err := postForm(ctx, client, cfg.DeviceAuthURL, values, "", "", &auth); err != nil {
+		return nil, fmt.Errorf("cannot request device authorization: %w", err)
+	}
+
+	if len(auth.DeviceCode) == 0 {
+		return nil, fmt.Errorf("device authorization response did not contain a device_code")
+	}
+
+	return &auth, nil
+}
+
+// PollDeviceToken polls the token endpoint until the user has approved the
+// device authorization, it is denied, or it expires
+func PollDeviceToken(ctx context.Context, client *http.Client, cfg OAuth2Config, auth *DeviceAuthorization) (*OAuth2Token, error) {
+	interval := time.Duration(auth.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	if auth.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(auth.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+
+	values := url.Values{}
+	values.Set("grant_type", DeviceCodeGrant)
+	values.Set("device_code", auth.DeviceCode)
+	values.Set("client_id", cfg.ClientID)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("device authorization expired before it was approved")
+		case <-time.After(interval):
+		}
+
+		token, err := requestToken(ctx, client, cfg, values)
+		if err == nil {
+			return token, nil
+		}
+
+		oerr, ok := err.(*oauth2Error)
+		if !ok {
+			return nil, err
+		}
+
+		switch oerr.Code {
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += 5 * time.Second
+			continue
+		default:
+			return nil, fmt.Errorf("device authorization failed: %w", oerr)
+		}
+	}
+}
+
+// ClientCredentialsToken obtains a token for the client itself, without a user
+func ClientCredentialsToken(ctx context.Context, client *http.Client, cfg OAuth2Config) (*OAuth2Token, error) {
+	values := url.Values{}
+	values.Set("grant_type", ClientCredentialsGrant)
+
+	return requestToken(ctx, client, cfg, values)
+}
+
+// RefreshOAuth2Token exchanges a refresh token for a new access token
+func RefreshOAuth2Token(ctx context.Context, client *http.Client, cfg OAuth2Config, refreshToken string) (*OAuth2Token, error) {
+	values := url.Values{}
+	values.Set("grant_type", RefreshTokenGrant)
+	values.Set("refresh_token", refreshToken)
+
+	token, err := requestToken(ctx, client, cfg, values)
+	if err != nil {
+		return nil, err
+	}
+
+	// Providers may omit the refresh token when it has not been rotated
+	if len(token.RefreshToken) == 0 {
+		token.RefreshToken = refreshToken
+	}
+
+	return token, nil
+}
+
+func requestToken(ctx context.Context, client *http.Client, cfg OAuth2Config, values url.Values) (*OAuth2Token, error) {
+	if len(cfg.TokenURL) == 0 {
+		return nil, fmt.Errorf("a token URL is required")
+	}
+
+	if len(cfg.ClientSecret) == 0 {
+		values.Set("client_id", cfg.ClientID)
+	}
+	if len(cfg.Scope) > 0 && values.Get("grant_type") != DeviceCodeGrant {
+		values.Set("scope", cfg.Scope)
+	}
+	if len(cfg.Audience) > 0 && values.Get("grant_type") == ClientCredentialsGrant {
+		values.Set("audience", cfg.Audience)
+	}
+
+	var token OAuth2Token
+	if err := postForm(ctx, client, cfg.TokenURL, values, cfg.ClientID, cfg.ClientSecret, &token); err != nil {
+		return nil, err
+	}
+
+	if len(token.AccessToken) == 0 {
+		return nil, fmt.Errorf("token response did not contain an access_token")
+	}
+
+	if token.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	return &token, nil
+}
+
+func postForm(ctx context.Context, client *http.Client, endpoint string, values url.Values, clientID, clientSecret string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if len(clientSecret) > 0 {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot connect to identity provider on URL: %s, error: %w", endpoint, err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		oerr := &oauth2Error{}
+		if err := json.Unmarshal(body, oerr); err == nil && len(oerr.Code) > 0 {
+			return oerr
+		}
+		return fmt.Errorf("identity provider returned unexpected status code: %d - %s", res.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("cannot parse response from identity provider: %w", err)
+	}
+
+	return nil
+}
+
+// OAuth2Auth sets a bearer token obtained via OAuth2 on each request, and
+// refreshes it with the stored refresh token or client credentials when it
+// has expired
+type OAuth2Auth struct {
+	authConfig config.AuthConfig
+	httpClient *http.Client
+	token      *OAuth2Token
+	mu         sync.Mutex
+
+	// persist saves a refreshed token, it defaults to config.UpdateAuthConfig
+	persist func(config.AuthConfig) error
+}
+
+// NewOAuth2Auth creates a ClientAuth from an oauth2 AuthConfig saved by "forge-cli login --oauth2".
+// When httpClient is nil, tokens are refreshed with the HTTP client of the
+// Client it is given to, so they share its transport and timeout.
+func NewOAuth2Auth(authConfig config.AuthConfig, httpClient *http.Client) *OAuth2Auth {
+	token := &OAuth2Token{
+		AccessToken:  authConfig.Token,
+		RefreshToken: authConfig.GetOption(OAuth2RefreshTokenOption),
+	}
+	if expiry := authConfig.GetOption(OAuth2ExpiryOption); len(expiry) > 0 {
+		if unix, err := strconv.ParseInt(expiry, 10, 64); err == nil {
+			token.Expiry = time.Unix(unix, 0)
+		}
+	}
+
+	return &OAuth2Auth{
+		authConfig: authConfig,
+		httpClient: httpClient,
+		token:      token,
+		persist:    config.UpdateAuthConfig,
+	}
+}
+
+// Set refreshes the access token if required and sets it as a bearer token
+func (a *OAuth2Auth) Set(req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.token.AccessToken) == 0 || a.token.Expired() {
+		if err := a.refresh(req.Context()); err != nil {
+			return fmt.Errorf("unable to refresh OAuth2 token, run \"forge-cli login --oauth2\": %w", err)
+		}
+	}
+
+	req.Header.Set("Authorization", "Bearer "+a.token.AccessToken)
+	return nil
+}
+
+func (a *OAuth2Auth) refresh(ctx context.Context) error {
+	cfg := OAuth2ConfigFromAuthConfig(a.authConfig)
+
+	var (
+		token *OAuth2Token
+		err   error
+	)
+
+	httpClient := a.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	switch {
+	case len(a.token.RefreshToken) > 0:
+		token, err = RefreshOAuth2Token(ctx, httpClient, cfg, a.token.RefreshToken)
+	case a.authConfig.GetOption(OAuth2GrantOption) == ClientCredentialsGrant:
+		token, err = ClientCredentialsToken(ctx, httpClient, cfg)
+	default:
+		return fmt.Errorf("the access token has expired and no refresh token is available")
+	}
+	if err != nil {
+		return err
+	}
+
+	a.token = token
+	ApplyOAuth2Token(&a.authConfig, token)
+
+	return a.persist(a.authConfig)
+}
+
+// OAuth2ConfigFromAuthConfig reads the identity provider settings stored in the options of an AuthConfig
+func OAuth2ConfigFromAuthConfig(authConfig config.AuthConfig) OAuth2Config {
+	return OAuth2Config{
+		TokenURL:     authConfig.GetOption(OAuth2TokenURLOption),
+		ClientID:     authConfig.GetOption(OAuth2ClientIDOption),
+		ClientSecret: authConfig.GetOption(OAuth2ClientSecretOption),
+		Scope:        authConfig.GetOption(OAuth2ScopeOption),
+		Audience:     authConfig.GetOption(OAuth2AudienceOption),
+	}
+}
+
+// ApplyOAuth2Token stores the access token, refresh token and expiry on an AuthConfig
+func ApplyOAuth2Token(authConfig *config.AuthConfig, token *OAuth2Token) {
+	authConfig.Auth = config.Oauth2AuthType
+	authConfig.Token = token.AccessToken
+	authConfig.SetOption(OAuth2RefreshTokenOption, token.RefreshToken)
+
+	expiry := ""
+	if !token.Expiry.IsZero() {
+		expiry = strconv.FormatInt(token.Expiry.Unix(), 10)
+	}
+	authConfig.SetOption(OAuth2ExpiryOption, expiry)
+}
@@ -0,0 +1,210 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/forge4flow/forge-cli/config"
+)
+
+func Test_PollDeviceToken_WaitsForApproval(t *testing.T) {
+	attempts := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("grant_type") != DeviceCodeGrant {
+			t.Errorf("want grant_type %s, got %s", DeviceCodeGrant, r.Form.Get("grant_type"))
+		}
+
+		attempts++
+		w.Header().Set("Content-Type", "application/json")
+		if attempts < 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "authorization_pending"})
+			return
+		}
+
+		json.NewEncoder(w).Encode(OAuth2Token{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 3600})
+	}))
+	defer s.Close()
+
+	cfg := OAuth2Config{TokenURL: s.URL, ClientID: "forge-cli"}
+	auth := &DeviceAuthorization{DeviceCode: "code", Interval: 1, ExpiresIn: 5}
+
+	token, err := PollDeviceToken(context.Background(), http.DefaultClient, cfg, auth)
+	if err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+
+	if token.AccessToken != "access" || token.RefreshToken != "refresh" {
+		t.Fatalf("unexpected token: %+v", token)
+	}
+
+	if attempts != 2 {
+		t.Fatalf("want 2 attempts, got %d", attempts)
+	}
+}
+
+func Test_PollDeviceToken_Denied(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "access_denied"})
+	}))
+	defer s.Close()
+
+	cfg := OAuth2Config{TokenURL: s.URL, ClientID: "forge-cli"}
+	auth := &DeviceAuthorization{DeviceCode: "code", Interval: 1}
+
+	_, err := PollDeviceToken(context.Background(), http.DefaultClient, cfg, auth)
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+
+	want := "device authorization failed: access_denied"
+	if err.Error() != want {
+		t.Fatalf("want %q, got %q", want, err.Error())
+	}
+}
+
+func Test_ClientCredentialsToken(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "ci" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+
+		r.ParseForm()
+		if r.Form.Get("audience") != "forge" {
+			t.Errorf("want audience forge, got %q", r.Form.Get("audience"))
+		}
+
+		json.NewEncoder(w).Encode(OAuth2Token{AccessToken: "access", ExpiresIn: 60})
+	}))
+	defer s.Close()
+
+	cfg := OAuth2Config{TokenURL: s.URL, ClientID: "ci", ClientSecret: "secret", Audience: "forge"}
+	token, err := ClientCredentialsToken(context.Background(), http.DefaultClient, cfg)
+	if err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+
+	if token.AccessToken != "access" {
+		t.Fatalf("want access token %q, got %q", "access", token.AccessToken)
+	}
+
+	if token.Expiry.IsZero() {
+		t.Fatal("want expiry to be set")
+	}
+}
+
+func Test_OAuth2Auth_RefreshesExpiredToken(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("grant_type") != RefreshTokenGrant || r.Form.Get("refresh_token") != "refresh" {
+			t.Errorf("unexpected refresh request: %v", r.Form)
+		}
+
+		json.NewEncoder(w).Encode(OAuth2Token{AccessToken: "new-access", ExpiresIn: 3600})
+	}))
+	defer s.Close()
+
+	authConfig := config.AuthConfig{
+		Gateway: "http://127.0.0.1:8080",
+		Auth:    config.Oauth2AuthType,
+		Token:   "old-access",
+	}
+	authConfig.SetOption(OAuth2TokenURLOption, s.URL)
+	authConfig.SetOption(OAuth2ClientIDOption, "forge-cli")
+	authConfig.SetOption(OAuth2RefreshTokenOption, "refresh")
+	authConfig.SetOption(OAuth2ExpiryOption, "1")
+
+	var saved config.AuthConfig
+	auth := NewOAuth2Auth(authConfig, http.DefaultClient)
+	auth.persist = func(c config.AuthConfig) error {
+		saved = c
+		return nil
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:8080/system/functions", nil)
+	if err := auth.Set(req); err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+
+	if got := req.Header.Get("Authorization"); got != "Bearer new-access" {
+		t.Fatalf("want Authorization %q, got %q", "Bearer new-access", got)
+	}
+
+	if saved.Token != "new-access" {
+		t.Fatalf("want refreshed token to be saved, got %q", saved.Token)
+	}
+
+	if saved.GetOption(OAuth2RefreshTokenOption) != "refresh" {
+		t.Fatalf("want refresh token to be kept, got %q", saved.GetOption(OAuth2RefreshTokenOption))
+	}
+}
+
+func Test_OAuth2Auth_ValidTokenIsNotRefreshed(t *testing.T) {
+	authConfig := config.AuthConfig{
+		Auth:  config.Oauth2AuthType,
+		Token: "access",
+	}
+	authConfig.SetOption(OAuth2ExpiryOption, "99999999999")
+
+	auth := NewOAuth2Auth(authConfig, http.DefaultClient)
+	auth.persist = func(c config.AuthConfig) error {
+		t.Fatal("token should not be refreshed")
+		return nil
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:8080/system/functions", nil)
+	if err := auth.Set(req); err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+
+	if got := req.Header.Get("Authorization"); got != "Bearer access" {
+		t.Fatalf("want Authorization %q, got %q", "Bearer access", got)
+	}
+}
+
+func Test_OAuth2Auth_RefreshesWithClientTransport(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(OAuth2Token{AccessToken: "new-access", ExpiresIn: 3600})
+	}))
+	defer s.Close()
+
+	authConfig := config.AuthConfig{Auth: config.Oauth2AuthType}
+	authConfig.SetOption(OAuth2TokenURLOption, s.URL)
+	authConfig.SetOption(OAuth2RefreshTokenOption, "refresh")
+
+	auth := NewOAuth2Auth(authConfig, nil)
+	auth.persist = func(c config.AuthConfig) error { return nil }
+
+	transport := &countingTransport{}
+	client, err := NewClient(auth, "http://127.0.0.1:8080", transport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.newRequest(http.MethodGet, "/system/functions", nil, nil); err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+	if transport.requests != 1 {
+		t.Fatalf("want the token to be refreshed with the client's transport, got %d requests", transport.requests)
+	}
+}
+
+type countingTransport struct {
+	requests int
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
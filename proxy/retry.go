@@ -0,0 +1,181 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultRetryWait is the initial wait between retries
+	DefaultRetryWait = time.Second
+
+	// DefaultMaxRetryWait caps the exponential backoff between retries
+	DefaultMaxRetryWait = 30 * time.Second
+)
+
+// RetryPolicy controls how requests to the gateway are retried when the
+// gateway is unavailable or rate-limiting the client.
+//
+// Idempotent requests are retried on connection errors and on 429, 502, 503
+// and 504 responses. Other requests, such as the POST used to create a
+// function, are only retried when the connection could not be established or
+// the gateway returned 429, so that they are never applied twice.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt, zero
+	// disables retries
+	MaxRetries int
+
+	// Wait is the backoff before the first retry, it doubles on every retry
+	Wait time.Duration
+
+	// MaxWait caps the backoff between retries
+	MaxWait time.Duration
+}
+
+// NewRetryPolicy returns a RetryPolicy with the default maximum wait
+func NewRetryPolicy(retries int, wait time.Duration) RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: retries,
+		Wait:       wait,
+		MaxWait:    DefaultMaxRetryWait,
+	}
+}
+
+// backoff returns the wait before the given retry, starting at zero, using
+// exponential backoff with jitter
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	wait := p.Wait
+	if wait <= 0 {
+		wait = DefaultRetryWait
+	}
+
+	maxWait := p.MaxWait
+	if maxWait <= 0 {
+		maxWait = DefaultMaxRetryWait
+	}
+
+	for i := 0; i < retry && wait < maxWait; i++ {
+		wait *= 2
+	}
+	if wait > maxWait {
+		wait = maxWait
+	}
+
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryable returns true when the request can be sent again after the
+// given response or error
+func retryable(req *http.Request, res *http.Response, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	if err != nil {
+		if isIdempotent(req.Method) {
+			return true
+		}
+
+		// The request never reached the gateway
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+
+	switch res.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return isIdempotent(req.Method)
+	}
+
+	return false
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header, which is either a number of
+// seconds or an HTTP date
+func retryAfter(res *http.Response, now time.Time) (time.Duration, bool) {
+	value := res.Header.Get("Retry-After")
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// doWithRetry sends the request, retrying it according to the client's
+// RetryPolicy
+func (c *Client) doWithRetry(ctx context.Context, req *http.Request) (*http.Response, error) {
+	for retry := 0; ; retry++ {
+		if retry > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		res, err := c.httpClient.Do(req)
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if retry >= c.Retry.MaxRetries || !retryable(req, res, err) {
+			return res, err
+		}
+
+		wait := c.Retry.backoff(retry)
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = res.Status
+			if after, ok := retryAfter(res, time.Now()); ok {
+				wait = after
+			}
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		fmt.Fprintf(os.Stderr, "%s %s: %s, retrying in %s (%d/%d)\n",
+			req.Method, req.URL.Path, reason, wait.Round(time.Millisecond), retry+1, c.Retry.MaxRetries)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// statusSequenceServer replies with the given status codes in order, then 200
+func statusSequenceServer(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *int) {
+	t.Helper()
+
+	count := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			body, _ := io.ReadAll(r.Body)
+			if r.Method == http.MethodPost && len(body) == 0 {
+				t.Errorf("request %d: want the body to be sent again", count+1)
+			}
+		}
+
+		count++
+		if count <= len(statuses) {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(statuses[count-1])
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}))
+	t.Cleanup(s.Close)
+
+	return s, &count
+}
+
+func Test_Retry_IdempotentRequestOnServiceUnavailable(t *testing.T) {
+	s, count := statusSequenceServer(t, nil, http.StatusServiceUnavailable, http.StatusBadGateway)
+
+	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, &defaultCommandTimeout)
+	client.Retry = RetryPolicy{MaxRetries: 3, Wait: time.Millisecond}
+
+	if _, err := client.ListFunctions(context.Background(), ""); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if *count != 3 {
+		t.Fatalf("want 3 requests, got %d", *count)
+	}
+}
+
+func Test_Retry_GivesUpAfterMaxRetries(t *testing.T) {
+	s, count := statusSequenceServer(t, nil, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+
+	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, &defaultCommandTimeout)
+	client.Retry = RetryPolicy{MaxRetries: 1, Wait: time.Millisecond}
+
+	if _, err := client.ListFunctions(context.Background(), ""); err == nil {
+		t.Fatal("want an error after the retries are exhausted")
+	}
+
+	if *count != 2 {
+		t.Fatalf("want 2 requests, got %d", *count)
+	}
+}
+
+func Test_Retry_PostNotRetriedOnServiceUnavailable(t *testing.T) {
+	s, count := statusSequenceServer(t, nil, http.StatusServiceUnavailable)
+
+	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, &defaultCommandTimeout)
+	client.Retry = RetryPolicy{MaxRetries: 3, Wait: time.Millisecond}
+
+	client.ScaleFunction(context.Background(), "figlet", "", 1)
+
+	if *count != 1 {
+		t.Fatalf("want a single request, got %d", *count)
+	}
+}
+
+func Test_Retry_PostRetriedOnTooManyRequests(t *testing.T) {
+	s, count := statusSequenceServer(t, http.Header{"Retry-After": []string{"0"}}, http.StatusTooManyRequests)
+
+	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, &defaultCommandTimeout)
+	client.Retry = RetryPolicy{MaxRetries: 3, Wait: time.Hour}
+
+	if err := client.ScaleFunction(context.Background(), "figlet", "", 1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if *count != 2 {
+		t.Fatalf("want 2 requests, got %d", *count)
+	}
+}
+
+func Test_Retry_DisabledByDefault(t *testing.T) {
+	s, count := statusSequenceServer(t, nil, http.StatusServiceUnavailable)
+
+	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, &defaultCommandTimeout)
+	client.ListFunctions(context.Background(), "")
+
+	if *count != 1 {
+		t.Fatalf("want a single request, got %d", *count)
+	}
+}
+
+func Test_retryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{value: "", ok: false},
+		{value: "5", want: 5 * time.Second, ok: true},
+		{value: "Mon, 01 Jan 2024 12:00:10 GMT", want: 10 * time.Second, ok: true},
+		{value: "Mon, 01 Jan 2024 11:00:00 GMT", want: 0, ok: true},
+		{value: "soon", ok: false},
+	}
+
+	for _, tc := range cases {
+		res := &http.Response{Header: http.Header{}}
+		if len(tc.value) > 0 {
+			res.Header.Set("Retry-After", tc.value)
+		}
+
+		got, ok := retryAfter(res, now)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("%q: want %s, %v got %s, %v", tc.value, tc.want, tc.ok, got, ok)
+		}
+	}
+}
+
+func Test_RetryPolicy_backoff(t *testing.T) {
+	policy := RetryPolicy{Wait: 100 * time.Millisecond, MaxWait: time.Second}
+
+	cases := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{retry: 0, max: 100 * time.Millisecond},
+		{retry: 2, max: 400 * time.Millisecond},
+		{retry: 10, max: time.Second},
+	}
+
+	for _, tc := range cases {
+		got := policy.backoff(tc.retry)
+		if got < tc.max/2 || got > tc.max {
+			t.Errorf("retry %d: want backoff between %s and %s, got %s", tc.retry, tc.max/2, tc.max, got)
+		}
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"path/filepath"
@@ -32,12 +31,12 @@ func (c *Client) ScaleFunction(ctx context.Context, functionName, namespace stri
 
 	req, err := c.newRequest(http.MethodPost, functionPath, query, bodyReader)
 	if err != nil {
-		return fmt.Errorf("cannot connect to Forge4Flow on URL: %s", c.GatewayURL.String())
+		return fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", c.GatewayURL.String(), err)
 	}
 
 	res, err := c.doRequest(ctx, req)
 	if err != nil {
-		return fmt.Errorf("cannot connect to Forge4Flow on URL: %s", c.GatewayURL.String())
+		return fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", c.GatewayURL.String(), err)
 
 	}
 
@@ -50,19 +49,15 @@ func (c *Client) ScaleFunction(ctx context.Context, functionName, namespace stri
 		break
 
 	case http.StatusNotFound:
-		return fmt.Errorf("function %s not found", functionName)
+		return newAPIError(res, fmt.Sprintf("function %s not found", functionName))
 
 	case http.StatusUnauthorized:
-		return fmt.Errorf("unauthorized action, please setup authentication for this server")
+		return newAPIError(res, "unauthorized action, please setup authentication for this server")
 
 	default:
-		var bodyReadErr error
-		bytesOut, bodyReadErr := io.ReadAll(res.Body)
-		if bodyReadErr != nil {
-			return bodyReadErr
-		}
-
-		return fmt.Errorf("server returned unexpected status code %d %s", res.StatusCode, string(bytesOut))
+		apiErr := newAPIError(res, "")
+		apiErr.message = fmt.Sprintf("server returned unexpected status code %d %s", apiErr.StatusCode, apiErr.Body)
+		return apiErr
 	}
 	return nil
 }
@@ -32,12 +32,12 @@ func (c *Client) GetSecretList(ctx context.Context, namespace string) ([]types.S
 	getRequest, err := c.newRequest(http.MethodGet, secretPath, query, nil)
 
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s", c.GatewayURL.String())
+		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", c.GatewayURL.String(), err)
 	}
 
 	res, err := c.doRequest(ctx, getRequest)
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s", c.GatewayURL.String())
+		return nil, fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", c.GatewayURL.String(), err)
 	}
 
 	if res.Body != nil {
@@ -58,36 +58,29 @@ func (c *Client) GetSecretList(ctx context.Context, namespace string) ([]types.S
 		}
 
 	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("unauthorized access, run \"forge-cli login\" to setup authentication for this server")
+		return nil, newAPIError(res, unauthorizedMessage)
 
 	default:
-		bytesOut, err := io.ReadAll(res.Body)
-		if err == nil {
-			return nil, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
-		}
+		return nil, newAPIError(res, "")
 	}
 
 	return results, nil
 }
 
 // UpdateSecret update a secret via the Forge4Flow API by name
-func (c *Client) UpdateSecret(ctx context.Context, secret types.Secret) (int, string) {
-	var output string
+func (c *Client) UpdateSecret(ctx context.Context, secret types.Secret) error {
 	reqBytes, _ := json.Marshal(&secret)
 
 	query := url.Values{}
 
 	putRequest, err := c.newRequest(http.MethodPut, secretEndpoint, query, bytes.NewBuffer(reqBytes))
-
 	if err != nil {
-		output += fmt.Sprintf("cannot connect to Forge4Flow on URL: %s", c.GatewayURL.String())
-		return http.StatusInternalServerError, output
+		return fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", c.GatewayURL.String(), err)
 	}
 
 	res, err := c.doRequest(ctx, putRequest)
 	if err != nil {
-		output += fmt.Sprintf("cannot connect to Forge4Flow on URL: %s", c.GatewayURL.String())
-		return http.StatusInternalServerError, output
+		return fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", c.GatewayURL.String(), err)
 	}
 
 	if res.Body != nil {
@@ -96,23 +89,17 @@ func (c *Client) UpdateSecret(ctx context.Context, secret types.Secret) (int, st
 
 	switch res.StatusCode {
 	case http.StatusOK, http.StatusAccepted:
-		output += fmt.Sprintf("Updated: %s\n", res.Status)
 		break
-
 	case http.StatusNotFound:
-		output += fmt.Sprintf("unable to find secret: %s", secret.Name)
-
+		return newAPIError(res, fmt.Sprintf("unable to find secret: %s", secret.Name))
 	case http.StatusUnauthorized:
-		output += fmt.Sprintf("unauthorized access, run \"forge-cli login\" to setup authentication for this server")
+		return newAPIError(res, unauthorizedMessage)
 
 	default:
-		bytesOut, err := io.ReadAll(res.Body)
-		if err == nil {
-			output += fmt.Sprintf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
-		}
+		return newAPIError(res, "")
 	}
 
-	return res.StatusCode, output
+	return nil
 }
 
 // RemoveSecret remove a secret via the Forge4Flow API by name
@@ -123,12 +110,12 @@ func (c *Client) RemoveSecret(ctx context.Context, secret types.Secret) error {
 
 	req, err := c.newRequest(http.MethodDelete, secretEndpoint, query, bytes.NewBuffer(body))
 	if err != nil {
-		return fmt.Errorf("cannot connect to Forge4Flow on URL: %s", c.GatewayURL.String())
+		return fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", c.GatewayURL.String(), err)
 	}
 
 	res, err := c.doRequest(ctx, req)
 	if err != nil {
-		return fmt.Errorf("cannot connect to Forge4Flow on URL: %s", c.GatewayURL.String())
+		return fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", c.GatewayURL.String(), err)
 	}
 
 	if res.Body != nil {
@@ -139,38 +126,31 @@ func (c *Client) RemoveSecret(ctx context.Context, secret types.Secret) error {
 	case http.StatusOK, http.StatusAccepted:
 		break
 	case http.StatusNotFound:
-		return fmt.Errorf("unable to find secret: %s", secret.Name)
+		return newAPIError(res, fmt.Sprintf("unable to find secret: %s", secret.Name))
 	case http.StatusUnauthorized:
-		return fmt.Errorf("unauthorized access, run \"forge-cli login\" to setup authentication for this server")
+		return newAPIError(res, unauthorizedMessage)
 
 	default:
-		bytesOut, err := io.ReadAll(res.Body)
-		if err == nil {
-			return fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
-		}
+		return newAPIError(res, "")
 	}
 
 	return nil
 }
 
 // CreateSecret create secret
-func (c *Client) CreateSecret(ctx context.Context, secret types.Secret) (int, string) {
-	var output string
+func (c *Client) CreateSecret(ctx context.Context, secret types.Secret) error {
 	reqBytes, _ := json.Marshal(&secret)
 	reader := bytes.NewReader(reqBytes)
 	query := url.Values{}
 
 	request, err := c.newRequest(http.MethodPost, secretEndpoint, query, reader)
-
 	if err != nil {
-		output += fmt.Sprintf("cannot connect to Forge4Flow on URL: %s\n", c.GatewayURL.String())
-		return http.StatusInternalServerError, output
+		return fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", c.GatewayURL.String(), err)
 	}
 
 	res, err := c.doRequest(ctx, request)
 	if err != nil {
-		output += fmt.Sprintf("cannot connect to Forge4Flow on URL: %s\n", c.GatewayURL.String())
-		return http.StatusInternalServerError, output
+		return fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", c.GatewayURL.String(), err)
 	}
 
 	if res.Body != nil {
@@ -179,20 +159,15 @@ func (c *Client) CreateSecret(ctx context.Context, secret types.Secret) (int, st
 
 	switch res.StatusCode {
 	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
-		output += fmt.Sprintf("Created: %s\n", res.Status)
-
+		break
 	case http.StatusUnauthorized:
-		output += fmt.Sprintln("unauthorized access, run \"forge-cli login\" to setup authentication for this server")
-
+		return newAPIError(res, unauthorizedMessage)
 	case http.StatusConflict:
-		output += fmt.Sprintf("secret with the name %q already exists\n", secret.Name)
+		return newAPIError(res, fmt.Sprintf("secret with the name %q already exists", secret.Name))
 
 	default:
-		bytesOut, err := io.ReadAll(res.Body)
-		if err == nil {
-			output += fmt.Sprintf("server returned unexpected status code: %d - %s\n", res.StatusCode, string(bytesOut))
-		}
+		return newAPIError(res, "")
 	}
 
-	return res.StatusCode, output
+	return nil
 }
@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"regexp"
 	"testing"
@@ -101,10 +102,8 @@ func Test_CreateSecret_200OK(t *testing.T) {
 		Namespace: "openfaas-fn",
 	}
 	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, nil)
-	status, _ := client.CreateSecret(context.Background(), secret)
-
-	if status != http.StatusOK {
-		t.Errorf("expected: %d, got: %d", http.StatusOK, status)
+	if err := client.CreateSecret(context.Background(), secret); err != nil {
+		t.Errorf("expected no error, got: %s", err)
 	}
 }
 
@@ -116,10 +115,8 @@ func Test_CreateSecret_201Created(t *testing.T) {
 		Namespace: "openfaas-fn",
 	}
 	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, nil)
-	status, _ := client.CreateSecret(context.Background(), secret)
-
-	if status != http.StatusCreated {
-		t.Errorf("expected: %d, got: %d", http.StatusCreated, status)
+	if err := client.CreateSecret(context.Background(), secret); err != nil {
+		t.Errorf("expected no error, got: %s", err)
 	}
 }
 
@@ -131,10 +128,8 @@ func Test_CreateSecret_202Accepted(t *testing.T) {
 		Namespace: "openfaas-fn",
 	}
 	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, nil)
-	status, _ := client.CreateSecret(context.Background(), secret)
-
-	if status != http.StatusAccepted {
-		t.Errorf("expected: %d, got: %d", http.StatusAccepted, status)
+	if err := client.CreateSecret(context.Background(), secret); err != nil {
+		t.Errorf("expected no error, got: %s", err)
 	}
 }
 
@@ -147,15 +142,16 @@ func Test_CreateSecret_Not200(t *testing.T) {
 		Namespace: "openfaas-fn",
 	}
 	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, nil)
-	status, output := client.CreateSecret(context.Background(), secret)
+	err := client.CreateSecret(context.Background(), secret)
 
-	if status != http.StatusBadRequest {
-		t.Errorf("expected: %d, got: %d", http.StatusBadRequest, status)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status code: %d, got: %v", http.StatusBadRequest, err)
 	}
 
 	r := regexp.MustCompile(`(?m:server returned unexpected status code)`)
-	if !r.MatchString(output) {
-		t.Fatalf("Error not matched: %s", output)
+	if !r.MatchString(err.Error()) {
+		t.Fatalf("Error not matched: %s", err)
 	}
 }
 
@@ -168,15 +164,16 @@ func Test_CreateSecret_Unauthorized401(t *testing.T) {
 		Namespace: "openfaas-fn",
 	}
 	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, nil)
-	status, output := client.CreateSecret(context.Background(), secret)
+	err := client.CreateSecret(context.Background(), secret)
 
-	if status != http.StatusUnauthorized {
-		t.Errorf("expected: %d, got: %d", http.StatusUnauthorized, status)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status code: %d, got: %v", http.StatusUnauthorized, err)
 	}
 
 	r := regexp.MustCompile(`(?m:unauthorized access, run \"forge-cli login\" to setup authentication for this server)`)
-	if !r.MatchString(output) {
-		t.Fatalf("Error not matched: %s", output)
+	if !r.MatchString(err.Error()) {
+		t.Fatalf("Error not matched: %s", err)
 	}
 }
 
@@ -189,15 +186,20 @@ func Test_CreateSecret_Conflict409(t *testing.T) {
 		Namespace: "openfaas-fn",
 	}
 	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, nil)
-	status, output := client.CreateSecret(context.Background(), secret)
+	err := client.CreateSecret(context.Background(), secret)
 
-	if status != http.StatusConflict {
-		t.Errorf("want: %d, got: %d", http.StatusConflict, status)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Fatalf("want status code: %d, got: %v", http.StatusConflict, err)
+	}
+
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("want ErrConflict, got: %v", err)
 	}
 
 	r := regexp.MustCompile(`(?m:secret with the name "` + secret.Name + `" already exists)`)
-	if !r.MatchString(output) {
-		t.Fatalf("Error not matched: %s", output)
+	if !r.MatchString(err.Error()) {
+		t.Fatalf("Error not matched: %s", err)
 	}
 }
 
@@ -210,14 +212,15 @@ func Test_CreateSecret_ForbiddenNamespace(t *testing.T) {
 		Namespace: "kube-system",
 	}
 	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, nil)
-	status, output := client.CreateSecret(context.Background(), secret)
+	err := client.CreateSecret(context.Background(), secret)
 
-	if status != http.StatusBadRequest {
-		t.Errorf("want: %d, got: %d", http.StatusBadRequest, status)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("want status code: %d, got: %v", http.StatusBadRequest, err)
 	}
 
 	r := regexp.MustCompile(`(?m:server returned unexpected status code: 400)`)
-	if !r.MatchString(output) {
-		t.Fatalf("Error not matched: %s", output)
+	if !r.MatchString(err.Error()) {
+		t.Fatalf("Error not matched: %s", err)
 	}
 }
@@ -27,7 +27,7 @@ func (c *Client) GetSystemInfo(ctx context.Context) (types.GatewayInfo, error) {
 
 	response, err := c.doRequest(ctx, req)
 	if err != nil {
-		return info, fmt.Errorf("cannot connect to Forge4Flow on URL: %s", c.GatewayURL.String())
+		return info, fmt.Errorf("cannot connect to Forge4Flow on URL: %s, error: %w", c.GatewayURL.String(), err)
 	}
 
 	if response.Body != nil {
@@ -46,12 +46,9 @@ func (c *Client) GetSystemInfo(ctx context.Context) (types.GatewayInfo, error) {
 		}
 
 	case http.StatusUnauthorized:
-		return info, fmt.Errorf("unauthorized access, run \"forge-cli login\" to setup authentication for this server")
+		return info, newAPIError(response, unauthorizedMessage)
 	default:
-		bytesOut, err := io.ReadAll(response.Body)
-		if err == nil {
-			return info, fmt.Errorf("server returned unexpected status code: %d - %s", response.StatusCode, string(bytesOut))
-		}
+		return info, newAPIError(response, "")
 	}
 
 	return info, nil
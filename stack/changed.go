@@ -0,0 +1,84 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package stack
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// FilterChanged keeps the functions affected by the changed files, those with
+// a changed file under their handler or their language's template folder.
+// sharedPaths are used by every function, such as the "copy" paths of the
+// stack and its YAML files, so a change under one of them keeps every
+// function. Relative paths are from the working directory.
+func FilterChanged(services *Services, changedFiles []string, sharedPaths []string) error {
+	changed := make([]string, 0, len(changedFiles))
+	for _, file := range changedFiles {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		changed = append(changed, abs)
+	}
+
+	for _, shared := range sharedPaths {
+		affected, err := containsChange(shared, changed)
+		if err != nil {
+			return err
+		}
+		if affected {
+			return nil
+		}
+	}
+
+	for name, function := range services.Functions {
+		paths := []string{function.Handler}
+		if len(function.Language) > 0 {
+			paths = append(paths, filepath.Join("template", strings.ToLower(function.Language)))
+		}
+
+		affected := false
+		for _, path := range paths {
+			if len(path) == 0 {
+				continue
+			}
+
+			match, err := containsChange(path, changed)
+			if err != nil {
+				return err
+			}
+			if match {
+				affected = true
+				break
+			}
+		}
+
+		if !affected {
+			delete(services.Functions, name)
+		}
+	}
+
+	return nil
+}
+
+// containsChange is true when one of the changed files is path, or is under
+// it when path is a folder
+func containsChange(path string, changed []string) (bool, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+	// git gives paths without symlinks, the path may not exist any more
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+
+	for _, file := range changed {
+		if file == abs || strings.HasPrefix(file, abs+string(filepath.Separator)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
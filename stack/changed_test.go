@@ -0,0 +1,81 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package stack
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func Test_FilterChanged(t *testing.T) {
+	newServices := func() *Services {
+		return &Services{Functions: map[string]Function{
+			"echo":     {Handler: "./functions/echo", Language: "go"},
+			"echo-two": {Handler: "./functions/echo-two", Language: "go"},
+			"resize":   {Handler: "./functions/resize", Language: "Python3"},
+			"nginx":    {Handler: "./nginx", Language: "dockerfile"},
+		}}
+	}
+
+	cases := []struct {
+		name    string
+		changed []string
+		shared  []string
+		want    []string
+	}{
+		{
+			name:    "handler",
+			changed: []string{"functions/echo/handler.go"},
+			want:    []string{"echo"},
+		},
+		{
+			name:    "folder with the same prefix",
+			changed: []string{"functions/echo-two/handler.go"},
+			want:    []string{"echo-two"},
+		},
+		{
+			name:    "template",
+			changed: []string{"template/python3/index.py"},
+			want:    []string{"resize"},
+		},
+		{
+			name:    "copy path",
+			changed: []string{"common/util.go"},
+			shared:  []string{"common", "stack.yml"},
+			want:    []string{"echo", "echo-two", "nginx", "resize"},
+		},
+		{
+			name:    "stack file",
+			changed: []string{"stack.yml"},
+			shared:  []string{"common", "stack.yml"},
+			want:    []string{"echo", "echo-two", "nginx", "resize"},
+		},
+		{
+			name:    "unrelated",
+			changed: []string{"README.md", "functions/echo.md"},
+			shared:  []string{"common"},
+			want:    []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			services := newServices()
+			if err := FilterChanged(services, tc.changed, tc.shared); err != nil {
+				t.Fatal(err)
+			}
+
+			got := []string{}
+			for name := range services.Functions {
+				got = append(got, name)
+			}
+			sort.Strings(got)
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("want functions %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package stack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DeployOrder returns the names of the functions so that every function comes
+// after the functions it depends on, functions which don't depend on each
+// other are sorted by name. Dependencies on functions which are not in the
+// map, such as those removed by --filter, are ignored.
+func DeployOrder(functions map[string]Function) ([]string, error) {
+	remaining := make(map[string]int, len(functions))
+	dependents := map[string][]string{}
+	for name, function := range functions {
+		remaining[name] = 0
+		for _, dependency := range uniqueDependencies(function) {
+			if _, ok := functions[dependency]; ok {
+				remaining[name]++
+				dependents[dependency] = append(dependents[dependency], name)
+			}
+		}
+	}
+
+	var ready []string
+	for name, count := range remaining {
+		if count == 0 {
+			ready = append(ready, name)
+		}
+	}
+	sort.Strings(ready)
+
+	order := make([]string, 0, len(functions))
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+
+		for _, dependent := range dependents[name] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+		sort.Strings(ready)
+	}
+
+	if len(order) < len(functions) {
+		return nil, fmt.Errorf("functions depend on each other in a cycle: %s", strings.Join(findCycle(functions, remaining), " -> "))
+	}
+
+	return order, nil
+}
+
+// validateDependencies checks that depends_on only names functions of the
+// stack and that the dependencies have no cycles
+func validateDependencies(functions map[string]Function) error {
+	names := make([]string, 0, len(functions))
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, dependency := range functions[name].DependsOn {
+			if _, ok := functions[dependency]; !ok {
+				return fmt.Errorf("function %q depends on %q, which is not in the stack file", name, dependency)
+			}
+			if dependency == name {
+				return fmt.Errorf("function %q depends on itself", name)
+			}
+		}
+	}
+
+	_, err := DeployOrder(functions)
+	return err
+}
+
+// findCycle returns a cycle among the functions which could not be sorted,
+// starting and ending with the same function
+func findCycle(functions map[string]Function, remaining map[string]int) []string {
+	var unsorted []string
+	for name, count := range remaining {
+		if count > 0 {
+			unsorted = append(unsorted, name)
+		}
+	}
+	sort.Strings(unsorted)
+
+	// Every unsorted function has an unsorted dependency, so following them
+	// from any function must come back to a function already on the path
+	var path []string
+	seen := map[string]int{}
+	name := unsorted[0]
+	for {
+		if start, ok := seen[name]; ok {
+			return append(path[start:], name)
+		}
+		seen[name] = len(path)
+		path = append(path, name)
+
+		dependencies := uniqueDependencies(functions[name])
+		sort.Strings(dependencies)
+		for _, dependency := range dependencies {
+			if remaining[dependency] > 0 {
+				name = dependency
+				break
+			}
+		}
+	}
+}
+
+func uniqueDependencies(function Function) []string {
+	var unique []string
+	for _, dependency := range function.DependsOn {
+		if !containsString(unique, dependency) {
+			unique = append(unique, dependency)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,135 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package stack
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_DeployOrder(t *testing.T) {
+	functions := map[string]Function{
+		"api":     {DependsOn: []string{"db", "cache"}},
+		"cache":   {},
+		"db":      {},
+		"indexer": {DependsOn: []string{"api", "db", "db"}},
+		"web":     {DependsOn: []string{"api", "filtered-out"}},
+	}
+
+	order, err := DeployOrder(functions)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{"cache", "db", "api", "indexer", "web"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("want %v, got %v", want, order)
+	}
+}
+
+func Test_DeployOrder_Cycle(t *testing.T) {
+	functions := map[string]Function{
+		"a":    {DependsOn: []string{"b"}},
+		"b":    {DependsOn: []string{"c"}},
+		"c":    {DependsOn: []string{"a"}},
+		"free": {},
+	}
+
+	_, err := DeployOrder(functions)
+
+	want := "functions depend on each other in a cycle: a -> b -> c -> a"
+	if err == nil || err.Error() != want {
+		t.Fatalf("want error %q, got %v", want, err)
+	}
+}
+
+func Test_ParseYAMLData_Dependencies(t *testing.T) {
+	cases := []struct {
+		name      string
+		functions string
+		wantErr   string
+	}{
+		{
+			name: "valid dependencies",
+			functions: `  api:
+    image: api
+    depends_on:
+      - db
+  db:
+    image: db
+`,
+		},
+		{
+			name: "missing dependency",
+			functions: `  api:
+    image: api
+    depends_on:
+      - db
+`,
+			wantErr: `function "api" depends on "db", which is not in the stack file`,
+		},
+		{
+			name: "depends on itself",
+			functions: `  api:
+    image: api
+    depends_on:
+      - api
+`,
+			wantErr: `function "api" depends on itself`,
+		},
+		{
+			name: "cycle",
+			functions: `  api:
+    image: api
+    depends_on:
+      - db
+  db:
+    image: db
+    depends_on:
+      - api
+`,
+			wantErr: "functions depend on each other in a cycle: api -> db -> api",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := "provider:\n  name: functions4flow\nfunctions:\n" + tc.functions
+
+			_, err := ParseYAMLData([]byte(data), "", "", false)
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("want error %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_ParseYAMLData_DependencyRemovedByFilter(t *testing.T) {
+	data := `provider:
+  name: functions4flow
+functions:
+  api:
+    image: api
+    depends_on:
+      - db
+  db:
+    image: db
+`
+
+	services, err := ParseYAMLData([]byte(data), "", "api", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	order, err := DeployOrder(services.Functions)
+	if err != nil || !reflect.DeepEqual(order, []string{"api"}) {
+		t.Fatalf("want only api, got %v, error: %v", order, err)
+	}
+}
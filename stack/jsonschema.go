@@ -0,0 +1,123 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package stack
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// functionNamePattern only allows valid Kubernetes service names (RFC-1123 labels)
+	functionNamePattern = `^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+
+	// quantityPattern matches Kubernetes resource quantities such as 128Mi, 0.5 or 500m
+	quantityPattern = `^[0-9]+(\.[0-9]+)?(m|k|M|G|T|P|E|Ki|Mi|Gi|Ti|Pi|Ei)?$`
+)
+
+// schemaOverrides adds keywords to the schema generated for a struct field,
+// keyed by "Type.Field"
+var schemaOverrides = map[string]map[string]interface{}{
+	"Services.Version": {
+		"type": []string{"string", "number"},
+		"enum": schemaVersions(),
+	},
+	"Services.Functions": {
+		"propertyNames": map[string]interface{}{"pattern": functionNamePattern},
+	},
+	"Provider.Name": {
+		"const": providerName,
+	},
+	"FunctionResources.Memory": {
+		"pattern": quantityPattern,
+	},
+	"FunctionResources.CPU": {
+		"pattern": quantityPattern,
+	},
+}
+
+// schemaVersions allows each valid version as a string, and as a number since
+// an unquoted "version: 1.0" is a number in YAML
+func schemaVersions() []interface{} {
+	versions := []interface{}{}
+	for _, version := range ValidSchemaVersions {
+		versions = append(versions, version)
+		if number, err := strconv.ParseFloat(version, 64); err == nil {
+			versions = append(versions, number)
+		}
+	}
+	return versions
+}
+
+// JSONSchema returns a JSON Schema (draft-07) for the stack file, generated
+// from the yaml tags of Services, for use by editors
+func JSONSchema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(Services{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "Forge4Flow stack file"
+	schema["required"] = []string{"provider", "functions"}
+	return schema
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		values := typeSchema(t.Elem())
+		if t.Elem().Kind() == reflect.String {
+			// YAML authors often write unquoted numbers and booleans
+			values = map[string]interface{}{"type": []string{"string", "number", "boolean"}}
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := yamlFieldName(field)
+			if len(name) == 0 {
+				continue
+			}
+
+			property := typeSchema(field.Type)
+			for k, v := range schemaOverrides[t.Name()+"."+field.Name] {
+				property[k] = v
+			}
+			properties[name] = property
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	}
+
+	return map[string]interface{}{}
+}
+
+// yamlFieldName returns the key used for a struct field in YAML, or an empty
+// string when the field is not serialised
+func yamlFieldName(field reflect.StructField) string {
+	if len(field.PkgPath) > 0 {
+		return ""
+	}
+
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
@@ -0,0 +1,204 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package stack
+
+import (
+	"github.com/forge4flow/forge-cli/util"
+)
+
+// mergeServices overlays one stack on top of another, it is used for files
+// listed under "include" and for stack files given with multiple -f flags.
+//
+//   - scalar values such as the provider, image or handler are replaced when
+//     they are set in the overlay
+//   - boolean values can be switched on by an overlay, but not off
+//   - maps such as environment, labels and annotations are merged key by key,
+//     with the overlay taking precedence
+//   - secrets, constraints, build_options, depends_on and copy are merged as sets
+//   - environment_file is appended to, so the overlay's files are applied last
+//   - hooks are merged one by one and a smoke_test in the overlay replaces
+//     the base's smoke_test
+//   - templates are merged by name
+//   - functions only present in the overlay are added
+func mergeServices(base, overlay Services) Services {
+	merged := base
+
+	if len(overlay.Version) > 0 {
+		merged.Version = overlay.Version
+	}
+	if len(overlay.Provider.Name) > 0 {
+		merged.Provider.Name = overlay.Provider.Name
+	}
+	if len(overlay.Provider.GatewayURL) > 0 {
+		merged.Provider.GatewayURL = overlay.Provider.GatewayURL
+	}
+
+	merged.StackConfiguration = mergeStackConfiguration(base.StackConfiguration, overlay.StackConfiguration)
+	mergeString(&merged.Build.Backend, overlay.Build.Backend)
+
+	if len(overlay.Functions) > 0 {
+		merged.Functions = make(map[string]Function, len(base.Functions)+len(overlay.Functions))
+		for name, function := range base.Functions {
+			merged.Functions[name] = function
+		}
+
+		for name, function := range overlay.Functions {
+			if existing, ok := merged.Functions[name]; ok {
+				merged.Functions[name] = mergeFunction(existing, function)
+			} else {
+				merged.Functions[name] = function
+			}
+		}
+	}
+
+	return merged
+}
+
+func mergeFunction(base, overlay Function) Function {
+	merged := base
+
+	mergeString(&merged.Language, overlay.Language)
+	mergeString(&merged.Handler, overlay.Handler)
+	mergeString(&merged.Image, overlay.Image)
+	mergeString(&merged.FProcess, overlay.FProcess)
+	mergeString(&merged.Namespace, overlay.Namespace)
+	mergeString(&merged.Platforms, overlay.Platforms)
+
+	merged.SkipBuild = base.SkipBuild || overlay.SkipBuild
+	merged.ReadOnlyRootFilesystem = base.ReadOnlyRootFilesystem || overlay.ReadOnlyRootFilesystem
+
+	if overlay.Environment != nil {
+		merged.Environment = util.MergeMap(base.Environment, overlay.Environment)
+	}
+	if overlay.BuildArgs != nil {
+		merged.BuildArgs = util.MergeMap(base.BuildArgs, overlay.BuildArgs)
+	}
+	if overlay.BuildSecrets != nil {
+		merged.BuildSecrets = util.MergeMap(base.BuildSecrets, overlay.BuildSecrets)
+	}
+	merged.Labels = mergeMapPointer(base.Labels, overlay.Labels)
+	merged.Annotations = mergeMapPointer(base.Annotations, overlay.Annotations)
+
+	if overlay.Secrets != nil {
+		merged.Secrets = util.MergeSlice(base.Secrets, overlay.Secrets)
+	}
+	if overlay.BuildOptions != nil {
+		merged.BuildOptions = util.MergeSlice(base.BuildOptions, overlay.BuildOptions)
+	}
+	if overlay.DependsOn != nil {
+		merged.DependsOn = util.MergeSlice(base.DependsOn, overlay.DependsOn)
+	}
+	if overlay.Constraints != nil {
+		var constraints []string
+		if base.Constraints != nil {
+			constraints = *base.Constraints
+		}
+		constraints = util.MergeSlice(constraints, *overlay.Constraints)
+		merged.Constraints = &constraints
+	}
+
+	for _, file := range overlay.EnvironmentFile {
+		if !containsString(merged.EnvironmentFile, file) {
+			merged.EnvironmentFile = append(merged.EnvironmentFile, file)
+		}
+	}
+
+	merged.Limits = mergeResources(base.Limits, overlay.Limits)
+	merged.Requests = mergeResources(base.Requests, overlay.Requests)
+	merged.Hooks = mergeHooks(base.Hooks, overlay.Hooks)
+
+	if overlay.SmokeTest != nil {
+		merged.SmokeTest = overlay.SmokeTest
+	}
+
+	return merged
+}
+
+func mergeStackConfiguration(base, overlay StackConfiguration) StackConfiguration {
+	merged := base
+
+	if overlay.CopyExtraPaths != nil {
+		merged.CopyExtraPaths = util.MergeSlice(base.CopyExtraPaths, overlay.CopyExtraPaths)
+	}
+
+	if overlay.TemplateConfigs != nil {
+		merged.TemplateConfigs = append([]TemplateSource{}, base.TemplateConfigs...)
+		for _, template := range overlay.TemplateConfigs {
+			replaced := false
+			for i, existing := range merged.TemplateConfigs {
+				if existing.Name == template.Name {
+					merged.TemplateConfigs[i] = template
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				merged.TemplateConfigs = append(merged.TemplateConfigs, template)
+			}
+		}
+	}
+
+	return merged
+}
+
+func mergeString(base *string, overlay string) {
+	if len(overlay) > 0 {
+		*base = overlay
+	}
+}
+
+func mergeMapPointer(base, overlay *map[string]string) *map[string]string {
+	if overlay == nil {
+		return base
+	}
+
+	var baseMap map[string]string
+	if base != nil {
+		baseMap = *base
+	}
+
+	merged := util.MergeMap(baseMap, *overlay)
+	return &merged
+}
+
+func mergeResources(base, overlay *FunctionResources) *FunctionResources {
+	if overlay == nil {
+		return base
+	}
+
+	merged := FunctionResources{}
+	if base != nil {
+		merged = *base
+	}
+	mergeString(&merged.Memory, overlay.Memory)
+	mergeString(&merged.CPU, overlay.CPU)
+
+	return &merged
+}
+
+func mergeHooks(base, overlay *FunctionHooks) *FunctionHooks {
+	if overlay == nil {
+		return base
+	}
+
+	merged := FunctionHooks{}
+	if base != nil {
+		merged = *base
+	}
+	mergeString(&merged.PreBuild, overlay.PreBuild)
+	mergeString(&merged.PostBuild, overlay.PostBuild)
+	mergeString(&merged.PreDeploy, overlay.PreDeploy)
+	mergeString(&merged.PostDeploy, overlay.PostDeploy)
+
+	return &merged
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
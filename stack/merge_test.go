@@ -0,0 +1,228 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeStackFile(t *testing.T, dir, name, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const baseStack = `version: 1.0
+provider:
+  name: functions4flow
+  gateway: http://127.0.0.1:8080
+functions:
+  api:
+    lang: go
+    handler: ./api
+    image: api:latest
+    environment:
+      write_debug: "true"
+      log_level: debug
+    secrets:
+      - db-password
+    environment_file:
+      - common.yml
+    labels:
+      team: payments
+    limits:
+      memory: 64Mi
+  worker:
+    lang: python3
+    handler: ./worker
+    image: worker:latest
+`
+
+func Test_ParseYAMLFiles_Overlay(t *testing.T) {
+	dir := t.TempDir()
+	base := writeStackFile(t, dir, "stack.yml", baseStack)
+	prod := writeStackFile(t, dir, "prod.yml", `provider:
+  gateway: https://gw.example.com
+functions:
+  api:
+    image: api:1.2.0
+    environment:
+      log_level: info
+    secrets:
+      - api-key
+    environment_file:
+      - prod-env.yml
+    labels:
+      tier: critical
+    limits:
+      cpu: 500m
+    readonly_root_filesystem: true
+  cron:
+    lang: go
+    handler: ./cron
+    image: cron:1.0.0
+`)
+
+	services, err := ParseYAMLFiles([]string{base, prod}, "", "", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if services.Provider.Name != "functions4flow" || services.Provider.GatewayURL != "https://gw.example.com" {
+		t.Errorf("want the provider gateway from the overlay, got %+v", services.Provider)
+	}
+
+	if len(services.Functions) != 3 {
+		t.Fatalf("want 3 functions, got %d", len(services.Functions))
+	}
+
+	api := services.Functions["api"]
+	if api.Image != "api:1.2.0" || api.Handler != "./api" || api.Language != "go" {
+		t.Errorf("want the image from the overlay and the rest from the base, got %+v", api)
+	}
+
+	wantEnv := map[string]string{"write_debug": "true", "log_level": "info"}
+	if !reflect.DeepEqual(api.Environment, wantEnv) {
+		t.Errorf("want environment %v, got %v", wantEnv, api.Environment)
+	}
+
+	wantLabels := map[string]string{"team": "payments", "tier": "critical"}
+	if api.Labels == nil || !reflect.DeepEqual(*api.Labels, wantLabels) {
+		t.Errorf("want labels %v, got %v", wantLabels, api.Labels)
+	}
+
+	if !reflect.DeepEqual(api.Secrets, []string{"api-key", "db-password"}) {
+		t.Errorf("want secrets merged as a set, got %v", api.Secrets)
+	}
+
+	if !reflect.DeepEqual(api.EnvironmentFile, []string{"common.yml", "prod-env.yml"}) {
+		t.Errorf("want the overlay's environment files last, got %v", api.EnvironmentFile)
+	}
+
+	if api.Limits == nil || api.Limits.Memory != "64Mi" || api.Limits.CPU != "500m" {
+		t.Errorf("want limits merged field by field, got %+v", api.Limits)
+	}
+
+	if !api.ReadOnlyRootFilesystem {
+		t.Error("want readonly_root_filesystem to be switched on by the overlay")
+	}
+
+	if services.Functions["worker"].Image != "worker:latest" {
+		t.Errorf("want the worker function untouched, got %+v", services.Functions["worker"])
+	}
+}
+
+func Test_ParseYAMLFiles_Include(t *testing.T) {
+	dir := t.TempDir()
+	writeStackFile(t, dir, "base/stack.yml", baseStack)
+	writeStackFile(t, dir, "base/extra.yml", `functions:
+  worker:
+    image: worker:0.9.0
+`)
+	staging := writeStackFile(t, dir, "staging.yml", `include:
+  - base/stack.yml
+  - base/extra.yml
+functions:
+  api:
+    image: api:rc
+`)
+
+	services, err := ParseYAMLFiles([]string{staging}, "", "", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if services.Functions["api"].Image != "api:rc" {
+		t.Errorf("want the including file to take precedence, got %s", services.Functions["api"].Image)
+	}
+	if services.Functions["worker"].Image != "worker:0.9.0" {
+		t.Errorf("want includes merged in order, got %s", services.Functions["worker"].Image)
+	}
+	if len(services.Include) != 0 {
+		t.Errorf("want include to be cleared after merging, got %v", services.Include)
+	}
+}
+
+func Test_ParseYAMLFiles_IncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	a := writeStackFile(t, dir, "a.yml", "include:\n  - b.yml\n")
+	writeStackFile(t, dir, "b.yml", "include:\n  - a.yml\n")
+
+	_, err := ParseYAMLFiles([]string{a}, "", "", false)
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("want an include cycle error, got %v", err)
+	}
+}
+
+func Test_ParseYAMLFiles_FilterAfterMerge(t *testing.T) {
+	dir := t.TempDir()
+	base := writeStackFile(t, dir, "stack.yml", baseStack)
+	overlay := writeStackFile(t, dir, "dev.yml", `functions:
+  worker-dev:
+    lang: python3
+    handler: ./worker
+    image: worker:dev
+`)
+
+	services, err := ParseYAMLFiles([]string{base, overlay}, "", "worker*", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(services.Functions) != 2 {
+		t.Fatalf("want worker and worker-dev, got %v", services.Functions)
+	}
+}
+
+func Test_ParseYAMLFiles_HooksOverlay(t *testing.T) {
+	dir := t.TempDir()
+	base := writeStackFile(t, dir, "stack.yml", `provider:
+  name: functions4flow
+functions:
+  api:
+    lang: go
+    image: api:latest
+    hooks:
+      pre_build: make generate
+      pre_deploy: ./migrate.sh
+    smoke_test:
+      path: /healthz
+`)
+	prod := writeStackFile(t, dir, "prod.yml", `functions:
+  api:
+    hooks:
+      pre_deploy: ./migrate.sh --prod
+    smoke_test:
+      method: POST
+      body: ping
+      expected_body: pong
+`)
+
+	services, err := ParseYAMLFiles([]string{base, prod}, "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api := services.Functions["api"]
+	wantHooks := &FunctionHooks{PreBuild: "make generate", PreDeploy: "./migrate.sh --prod"}
+	if !reflect.DeepEqual(api.Hooks, wantHooks) {
+		t.Errorf("hooks, want: %+v, got: %+v", wantHooks, api.Hooks)
+	}
+
+	wantSmokeTest := &SmokeTest{Method: "POST", Body: "ping", ExpectedBody: "pong"}
+	if !reflect.DeepEqual(api.SmokeTest, wantSmokeTest) {
+		t.Errorf("smoke_test, want: %+v, got: %+v", wantSmokeTest, api.SmokeTest)
+	}
+}
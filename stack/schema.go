@@ -65,6 +65,44 @@ type Function struct {
 
 	// BuildSecrets is a set of secrets to mount with buildkit
 	BuildSecrets map[string]string `yaml:"build_secrets,omitempty"`
+
+	// DependsOn lists the functions which must be deployed before this one
+	DependsOn []string `yaml:"depends_on,omitempty"`
+
+	// Hooks are shell commands run before and after the function is built
+	// and deployed
+	Hooks *FunctionHooks `yaml:"hooks,omitempty"`
+
+	// SmokeTest is a request made to the function once it is deployed
+	SmokeTest *SmokeTest `yaml:"smoke_test,omitempty"`
+}
+
+// FunctionHooks are run with "sh -c" and the function's environment, along
+// with FUNCTION_NAME, FUNCTION_IMAGE, FUNCTION_NAMESPACE and OPENFAAS_URL. A
+// hook which exits with an error stops the build or deployment of the function
+type FunctionHooks struct {
+	PreBuild   string `yaml:"pre_build,omitempty"`
+	PostBuild  string `yaml:"post_build,omitempty"`
+	PreDeploy  string `yaml:"pre_deploy,omitempty"`
+	PostDeploy string `yaml:"post_deploy,omitempty"`
+}
+
+// SmokeTest describes a request to a deployed function and its expected
+// response
+type SmokeTest struct {
+	// Method defaults to GET
+	Method string `yaml:"method,omitempty"`
+
+	// Path is appended to the function's URL
+	Path string `yaml:"path,omitempty"`
+
+	Body string `yaml:"body,omitempty"`
+
+	// ExpectedStatus defaults to 200
+	ExpectedStatus int `yaml:"expected_status,omitempty"`
+
+	// ExpectedBody is a regular expression the response body must match
+	ExpectedBody string `yaml:"expected_body,omitempty"`
 }
 
 // Configuration for the functions.yml file
@@ -85,6 +123,13 @@ type StackConfiguration struct {
 	CopyExtraPaths []string `yaml:"copy"`
 }
 
+// BuildConfiguration for the functions of a stack
+type BuildConfiguration struct {
+	// Backend builds the images, such as docker, buildx, buildah, podman,
+	// kaniko, native or remote, the --builder flag takes precedence
+	Backend string `yaml:"backend,omitempty"`
+}
+
 // TemplateSource for build templates
 type TemplateSource struct {
 	Name   string `yaml:"name"`
@@ -108,6 +153,13 @@ type Services struct {
 	Functions          map[string]Function `yaml:"functions,omitempty"`
 	Provider           Provider            `yaml:"provider,omitempty"`
 	StackConfiguration StackConfiguration  `yaml:"configuration,omitempty"`
+
+	// Build configures how the functions are built
+	Build BuildConfiguration `yaml:"build,omitempty"`
+
+	// Include lists stack files to merge before this file, paths are
+	// relative to the file that includes them
+	Include []string `yaml:"include,omitempty"`
 }
 
 // LanguageTemplate read from template.yml within root of a language template folder
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"time"
 
@@ -28,22 +29,110 @@ var ValidSchemaVersions = []string{
 
 // ParseYAMLFile parse YAML file into a stack of "services".
 func ParseYAMLFile(yamlFile, regex, filter string, envsubst bool) (*Services, error) {
-	var err error
-	var fileData []byte
+	return ParseYAMLFiles([]string{yamlFile}, regex, filter, envsubst)
+}
+
+// ParseYAMLFiles parses one or more YAML files into a stack of "services",
+// each file is merged on top of the ones before it, so that environment
+// specific files only need to carry their differences.
+func ParseYAMLFiles(yamlFiles []string, regex, filter string, envsubst bool) (*Services, error) {
+	var merged Services
+	for _, yamlFile := range yamlFiles {
+		services, err := loadYAMLFile(yamlFile, envsubst, nil)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeServices(merged, *services)
+	}
+
+	return filterServices(&merged, regex, filter)
+}
+
+// loadYAMLFile reads a local or remote YAML file and merges its includes,
+// visiting holds the files being included to detect cycles
+func loadYAMLFile(yamlFile string, envsubst bool, visiting []string) (*Services, error) {
+	fileData, err := readYAML(yamlFile)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := parseYAML(fileData, envsubst)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveIncludes(services, yamlFile, envsubst, append(visiting, includeKey(yamlFile)))
+}
+
+// readYAML reads a local file, or fetches it when given a URL
+func readYAML(yamlFile string) ([]byte, error) {
 	urlParsed, err := url.Parse(yamlFile)
 	if err == nil && len(urlParsed.Scheme) > 0 {
 		fmt.Println("Parsed: " + urlParsed.String())
-		fileData, err = fetchYAML(urlParsed)
-		if err != nil {
-			return nil, err
+		return fetchYAML(urlParsed)
+	}
+
+	return os.ReadFile(yamlFile)
+}
+
+// resolveIncludes merges the files listed under "include" in order, then
+// the services on top of them
+func resolveIncludes(services *Services, from string, envsubst bool, visiting []string) (*Services, error) {
+	if len(services.Include) == 0 {
+		return services, nil
+	}
+
+	var merged Services
+	for _, include := range services.Include {
+		location := includePath(from, include)
+
+		key := includeKey(location)
+		for _, v := range visiting {
+			if v == key {
+				return nil, fmt.Errorf("include cycle detected: %s includes %s", from, include)
+			}
 		}
-	} else {
-		fileData, err = os.ReadFile(yamlFile)
+
+		included, err := loadYAMLFile(location, envsubst, visiting)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("unable to include %s from %s: %w", include, from, err)
 		}
+		merged = mergeServices(merged, *included)
 	}
-	return ParseYAMLData(fileData, regex, filter, envsubst)
+
+	services.Include = nil
+	merged = mergeServices(merged, *services)
+	return &merged, nil
+}
+
+// includePath resolves an include relative to the file or URL including it
+func includePath(from, include string) string {
+	if includeURL, err := url.Parse(include); err == nil && len(includeURL.Scheme) > 0 {
+		return include
+	}
+
+	if fromURL, err := url.Parse(from); err == nil && len(fromURL.Scheme) > 0 {
+		if includeURL, err := url.Parse(include); err == nil {
+			return fromURL.ResolveReference(includeURL).String()
+		}
+	}
+
+	if filepath.IsAbs(include) {
+		return include
+	}
+	return filepath.Join(filepath.Dir(from), include)
+}
+
+// includeKey identifies a file when detecting include cycles
+func includeKey(location string) string {
+	if u, err := url.Parse(location); err == nil && len(u.Scheme) > 0 {
+		return location
+	}
+
+	if abs, err := filepath.Abs(location); err == nil {
+		return abs
+	}
+	return filepath.Clean(location)
 }
 
 func substituteEnvironment(data []byte) ([]byte, error) {
@@ -63,11 +152,25 @@ func substituteEnvironment(data []byte) ([]byte, error) {
 	return []byte(res), resErr
 }
 
-// ParseYAMLData parse YAML data into a stack of "services".
+// ParseYAMLData parse YAML data into a stack of "services". Included files
+// are resolved relative to the working directory.
 func ParseYAMLData(fileData []byte, regex string, filter string, envsubst bool) (*Services, error) {
+	services, err := parseYAML(fileData, envsubst)
+	if err != nil {
+		return services, err
+	}
+
+	services, err = resolveIncludes(services, "", envsubst, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterServices(services, regex, filter)
+}
+
+// parseYAML unmarshals a single YAML document, without validating it
+func parseYAML(fileData []byte, envsubst bool) (*Services, error) {
 	var services Services
-	regexExists := len(regex) > 0
-	filterExists := len(filter) > 0
 
 	var source []byte
 	if envsubst {
@@ -93,6 +196,15 @@ func ParseYAMLData(fileData []byte, regex string, filter string, envsubst bool)
 		}
 	}
 
+	return &services, nil
+}
+
+// filterServices validates the provider and schema version of the stack,
+// and keeps the functions matching the regex or filter
+func filterServices(services *Services, regex string, filter string) (*Services, error) {
+	regexExists := len(regex) > 0
+	filterExists := len(filter) > 0
+
 	if services.Provider.Name != providerName {
 		return nil, fmt.Errorf(`['%s'] is the only valid "provider.name" for the Forge4Flow CLI, but you gave: %s`, providerName, services.Provider.Name)
 	}
@@ -105,6 +217,10 @@ func ParseYAMLData(fileData []byte, regex string, filter string, envsubst bool)
 		return nil, fmt.Errorf("pass in a regex or a filter, not both")
 	}
 
+	if err := validateDependencies(services.Functions); err != nil {
+		return nil, err
+	}
+
 	if regexExists || filterExists {
 		for k, function := range services.Functions {
 			var match bool
@@ -131,7 +247,7 @@ func ParseYAMLData(fileData []byte, regex string, filter string, envsubst bool)
 
 	}
 
-	return &services, nil
+	return services, nil
 }
 
 func makeHTTPClient(timeout *time.Duration) http.Client {
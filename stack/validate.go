@@ -0,0 +1,314 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package stack
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+var (
+	functionNameRegexp = regexp.MustCompile(functionNamePattern)
+	quantityRegexp     = regexp.MustCompile(quantityPattern)
+	secretNameRegexp   = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// ValidationError is a problem found in a stack file
+type ValidationError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+// ValidateYAMLFiles checks the stack files, and the files they include, for
+// unknown fields, invalid names, bad resource quantities and missing
+// handlers. The returned error is only set when a file can't be read or is
+// not valid YAML.
+func ValidateYAMLFiles(yamlFiles []string, envsubst bool) ([]ValidationError, error) {
+	v := &validator{
+		envsubst: envsubst,
+		visited:  map[string]bool{},
+	}
+
+	for _, yamlFile := range yamlFiles {
+		if err := v.validateFile(yamlFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return v.errors, nil
+}
+
+type validator struct {
+	envsubst bool
+	visited  map[string]bool
+	errors   []ValidationError
+
+	// file is the stack file being validated
+	file string
+}
+
+func (v *validator) validateFile(yamlFile string) error {
+	key := includeKey(yamlFile)
+	if v.visited[key] {
+		return nil
+	}
+	v.visited[key] = true
+
+	data, err := readYAML(yamlFile)
+	if err != nil {
+		return err
+	}
+
+	if v.envsubst {
+		if data, err = substituteEnvironment(data); err != nil {
+			return fmt.Errorf("%s: %w", yamlFile, err)
+		}
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("%s: %w", yamlFile, err)
+	}
+
+	if len(document.Content) == 0 {
+		return nil
+	}
+	root := document.Content[0]
+
+	v.file = yamlFile
+	v.walk(root, reflect.TypeOf(Services{}), "")
+	v.checkStack(root)
+
+	_, includes := mappingValue(root, "include")
+	if includes == nil || includes.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	for _, include := range includes.Content {
+		location := includePath(yamlFile, include.Value)
+		if _, err := os.Stat(location); err != nil && !isURL(location) {
+			v.add(include, "included file %q not found", include.Value)
+			continue
+		}
+
+		if err := v.validateFile(location); err != nil {
+			return err
+		}
+		v.file = yamlFile
+	}
+
+	return nil
+}
+
+func (v *validator) add(node *yaml.Node, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{
+		File:    v.file,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// walk reports keys which are not part of the type, path is the dotted path
+// of the node used in messages
+func (v *validator) walk(node *yaml.Node, t reflect.Type, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			v.add(node, "%s must be a mapping", describePath(path))
+			return
+		}
+
+		fields := map[string]reflect.Type{}
+		for i := 0; i < t.NumField(); i++ {
+			if name := yamlFieldName(t.Field(i)); len(name) > 0 {
+				fields[name] = t.Field(i).Type
+			}
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				v.add(key, "unknown field %q in %s", key.Value, describePath(path))
+				continue
+			}
+			v.walk(value, fieldType, joinPath(path, key.Value))
+		}
+
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			if !isNull(node) {
+				v.add(node, "%s must be a mapping", describePath(path))
+			}
+			return
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.walk(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))
+		}
+
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			if !isNull(node) {
+				v.add(node, "%s must be a list", describePath(path))
+			}
+			return
+		}
+
+		for _, item := range node.Content {
+			v.walk(item, t.Elem(), path)
+		}
+
+	default:
+		if node.Kind != yaml.ScalarNode {
+			v.add(node, "%s must be a single value", describePath(path))
+		}
+	}
+}
+
+// checkStack validates the values of the stack file
+func (v *validator) checkStack(root *yaml.Node) {
+	if _, version := mappingValue(root, "version"); version != nil && version.Kind == yaml.ScalarNode {
+		if !IsValidSchemaVersion(version.Value) {
+			v.add(version, "version must be one of %s, found: %s", ValidSchemaVersions, version.Value)
+		}
+	}
+
+	if _, provider := mappingValue(root, "provider"); provider != nil {
+		if _, name := mappingValue(provider, "name"); name != nil && name.Value != providerName {
+			v.add(name, "provider.name must be %q, found: %q", providerName, name.Value)
+		}
+	}
+
+	_, functions := mappingValue(root, "functions")
+	if functions == nil || functions.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(functions.Content); i += 2 {
+		name, function := functions.Content[i], functions.Content[i+1]
+		if !functionNameRegexp.MatchString(name.Value) {
+			v.add(name, "invalid function name %q, it can only contain a-z, 0-9 and dashes", name.Value)
+		}
+
+		if function.Kind == yaml.MappingNode {
+			v.checkFunction(name, function)
+		}
+	}
+}
+
+func (v *validator) checkFunction(name, function *yaml.Node) {
+	for _, resources := range []string{"limits", "requests"} {
+		_, node := mappingValue(function, resources)
+		if node == nil || node.Kind != yaml.MappingNode {
+			continue
+		}
+
+		for _, resource := range []string{"memory", "cpu"} {
+			if _, quantity := mappingValue(node, resource); quantity != nil && len(quantity.Value) > 0 {
+				if !quantityRegexp.MatchString(quantity.Value) {
+					v.add(quantity, "invalid %s %s quantity %q for function %q", resources, resource, quantity.Value, name.Value)
+				}
+			}
+		}
+	}
+
+	if _, secrets := mappingValue(function, "secrets"); secrets != nil && secrets.Kind == yaml.SequenceNode {
+		for _, secret := range secrets.Content {
+			if !secretNameRegexp.MatchString(secret.Value) {
+				v.add(secret, "invalid secret name %q for function %q", secret.Value, name.Value)
+			}
+		}
+	}
+
+	if _, namespace := mappingValue(function, "namespace"); namespace != nil && len(namespace.Value) > 0 {
+		if !functionNameRegexp.MatchString(namespace.Value) {
+			v.add(namespace, "invalid namespace %q for function %q", namespace.Value, name.Value)
+		}
+	}
+
+	_, lang := mappingValue(function, "lang")
+	if lang == nil || len(lang.Value) == 0 {
+		return
+	}
+
+	if _, skipBuild := mappingValue(function, "skip_build"); skipBuild != nil && skipBuild.Value == "true" {
+		return
+	}
+
+	_, handler := mappingValue(function, "handler")
+	if handler == nil || len(handler.Value) == 0 {
+		v.add(name, "function %q has a lang but no handler", name.Value)
+		return
+	}
+
+	if isURL(v.file) {
+		return
+	}
+
+	// Handlers are resolved from the working directory, as they are by build
+	if _, err := os.Stat(handler.Value); err != nil {
+		v.add(handler, "handler %q for function %q not found", handler.Value, name.Value)
+	}
+}
+
+// mappingValue returns the key and value nodes for a key of a mapping node
+func mappingValue(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			value := node.Content[i+1]
+			if value.Kind == yaml.AliasNode {
+				value = value.Alias
+			}
+			return node.Content[i], value
+		}
+	}
+	return nil, nil
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+func isURL(location string) bool {
+	u, err := url.Parse(location)
+	return err == nil && len(u.Scheme) > 0
+}
+
+func joinPath(path, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
+
+func describePath(path string) string {
+	if len(path) == 0 {
+		return "the stack file"
+	}
+	return path
+}
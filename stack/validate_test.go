@@ -0,0 +1,168 @@
+// Copyright (c) Forge4Flow DAO LLC 2024. All rights reserved.
+// Licensed under the MIT license.
+
+package stack
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func Test_ValidateYAMLFiles(t *testing.T) {
+	dir := t.TempDir()
+	handler := filepath.Join(dir, "api")
+	if err := os.Mkdir(handler, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	stackFile := writeStackFile(t, dir, "stack.yml", `version: 1.0
+provider:
+  name: functions4flow
+functions:
+  api:
+    lang: go
+    handler: `+handler+`
+    image: api:latest
+    limits:
+      memory: 128MB
+      cpu: 500m
+    enviroment:
+      log_level: debug
+    secrets:
+      - Db_Password
+  Bad_Name:
+    lang: go
+    handler: `+filepath.Join(dir, "missing")+`
+  worker:
+    lang: python3
+    image: worker:latest
+include:
+  - extra.yml
+`)
+	writeStackFile(t, dir, "extra.yml", `functions:
+  prebuilt:
+    image: prebuilt:1.0
+    skip_build: true
+    replicas: 2
+`)
+
+	problems, err := ValidateYAMLFiles([]string{stackFile}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	extraFile := filepath.Join(dir, "extra.yml")
+	want := []ValidationError{
+		{File: stackFile, Line: 12, Column: 5, Message: `unknown field "enviroment" in functions.api`},
+		{File: stackFile, Line: 10, Column: 15, Message: `invalid limits memory quantity "128MB" for function "api"`},
+		{File: stackFile, Line: 15, Column: 9, Message: `invalid secret name "Db_Password" for function "api"`},
+		{File: stackFile, Line: 16, Column: 3, Message: `invalid function name "Bad_Name", it can only contain a-z, 0-9 and dashes`},
+		{File: stackFile, Line: 18, Column: 14, Message: `handler "` + filepath.Join(dir, "missing") + `" for function "Bad_Name" not found`},
+		{File: stackFile, Line: 19, Column: 3, Message: `function "worker" has a lang but no handler`},
+		{File: extraFile, Line: 5, Column: 5, Message: `unknown field "replicas" in functions.prebuilt`},
+	}
+
+	if !reflect.DeepEqual(problems, want) {
+		t.Fatalf("want:\n%v\ngot:\n%v", want, problems)
+	}
+}
+
+func Test_ValidateYAMLFiles_Valid(t *testing.T) {
+	dir := t.TempDir()
+	stackFile := writeStackFile(t, dir, "stack.yml", `provider:
+  name: functions4flow
+functions:
+  figlet:
+    image: alexellis/figlet:latest
+    labels:
+      com.example.team: payments
+    requests:
+      memory: 64Mi
+      cpu: "0.5"
+`)
+
+	problems, err := ValidateYAMLFiles([]string{stackFile}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(problems) != 0 {
+		t.Fatalf("want no problems, got %v", problems)
+	}
+}
+
+func Test_JSONSchema(t *testing.T) {
+	schema := JSONSchema()
+
+	properties := schema["properties"].(map[string]interface{})
+	functions := properties["functions"].(map[string]interface{})
+
+	names := functions["propertyNames"].(map[string]interface{})
+	if names["pattern"] != functionNamePattern {
+		t.Errorf("want function names to be restricted, got %v", names)
+	}
+
+	function := functions["additionalProperties"].(map[string]interface{})
+	if function["additionalProperties"] != false {
+		t.Error("want unknown function fields to be rejected")
+	}
+
+	functionProperties := function["properties"].(map[string]interface{})
+	for _, name := range []string{"lang", "handler", "image", "environment", "limits", "build_secrets"} {
+		if _, ok := functionProperties[name]; !ok {
+			t.Errorf("want property %q in the function schema", name)
+		}
+	}
+
+	if _, ok := functionProperties["Name"]; ok {
+		t.Error("want fields tagged yaml:\"-\" to be left out")
+	}
+
+	limits := functionProperties["limits"].(map[string]interface{})["properties"].(map[string]interface{})
+	if limits["memory"].(map[string]interface{})["pattern"] != quantityPattern {
+		t.Errorf("want resource quantities to be restricted, got %v", limits["memory"])
+	}
+}
+
+func Test_JSONSchema_Version(t *testing.T) {
+	// compare as an editor would, after converting the schema and the stack
+	// file to JSON
+	toJSON := func(v interface{}) interface{} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out interface{}
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	schema := toJSON(JSONSchema()).(map[string]interface{})
+	version := schema["properties"].(map[string]interface{})["version"].(map[string]interface{})
+	enum := version["enum"].([]interface{})
+
+	for _, stackFile := range []string{"version: 1.0\n", "version: \"1.0\"\n"} {
+		var services map[string]interface{}
+		if err := yaml.Unmarshal([]byte(stackFile), &services); err != nil {
+			t.Fatal(err)
+		}
+		value := toJSON(services).(map[string]interface{})["version"]
+
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(allowed, value) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("want %q to be allowed by the schema's versions %v", stackFile, enum)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package versioncontrol
 
 import (
+	"context"
+	"fmt"
+	"path/filepath"
 	"strings"
 
+	v2execute "github.com/alexellis/go-execute/v2"
 	"github.com/forge4flow/forge-cli/exec"
 )
 
@@ -108,3 +112,48 @@ func GetGitBranch() string {
 	branch = strings.TrimSuffix(branch, "\n")
 	return branch
 }
+
+// GetGitChangedFiles returns the absolute paths of the files changed between
+// the merge base of ref and HEAD, as listed by `git diff ref...HEAD`
+func GetGitChangedFiles(ref string) ([]string, error) {
+	if len(ref) == 0 || strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid Git reference: %q", ref)
+	}
+
+	root, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root = strings.TrimSpace(root)
+
+	output, err := gitOutput("diff", "--name-only", "-z", ref+"...HEAD", "--")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, name := range strings.Split(output, "\x00") {
+		if len(name) > 0 {
+			files = append(files, filepath.Join(root, filepath.FromSlash(name)))
+		}
+	}
+	return files, nil
+}
+
+func gitOutput(args ...string) (string, error) {
+	task := v2execute.ExecTask{
+		Command:     "git",
+		Args:        args,
+		StreamStdio: false,
+	}
+
+	res, err := task.Execute(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	if res.ExitCode != 0 {
+		return "", fmt.Errorf("git %s failed: %s", strings.Join(args, " "), strings.TrimSpace(res.Stderr))
+	}
+	return res.Stdout, nil
+}